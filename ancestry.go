@@ -0,0 +1,101 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// EmptyTreeSha is the git tree containing no files, used by prme for the
+// root commit of the orphan base and head branches.
+const EmptyTreeSha = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// emptyTreeCommitMessage is the message of the root commit of the orphan
+// base and head branches.
+const emptyTreeCommitMessage = "empty-tree commit"
+
+// maxAncestryDepth is the most commits OrphanRoot follows, before giving
+// up on finding the root commit of a branch.
+const maxAncestryDepth = 100
+
+// ErrNotPrmeBranch is returned when a branch was not created by prme, so
+// prme will not delete it.
+var ErrNotPrmeBranch = errors.New("the branch was not created by prme")
+
+// Commit is a git commit.
+type Commit struct {
+	Sha     string `json:"sha"`
+	Message string `json:"message"`
+	Tree    struct {
+		Sha string `json:"sha"`
+	} `json:"tree"`
+	Parents []struct {
+		Sha string `json:"sha"`
+	} `json:"parents"`
+}
+
+// GetCommit returns the commit sha.
+func (r Repo) GetCommit(sha string) (*Commit, error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/commits/%s", r, sha)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting commit %q in repository %q", resp.StatusCode, apiURI, sha, r)
+	}
+	var c Commit
+	err = json.NewDecoder(resp.Body).Decode(&c)
+	if err != nil {
+		return nil, err
+	}
+	if c.Sha != sha {
+		return nil, fmt.Errorf("incorrect commit sha %q returned while getting commit %q", c.Sha, sha)
+	}
+	return &c, nil
+}
+
+// OrphanRoot returns the root commit of branch, found by following the
+// first parent of each commit. Merges into prme branches keep the prme
+// history as their first parent, so this finds the empty-tree commit of a
+// branch created by prme. An error is returned if the root is more than
+// maxAncestryDepth commits away.
+func (r Repo) OrphanRoot(branch string) (*Commit, error) {
+	sha, err := r.GetRef("heads/" + branch)
+	if err != nil {
+		return nil, err
+	}
+	for depth := 0; depth < maxAncestryDepth; depth++ {
+		c, err := r.GetCommit(sha)
+		if err != nil {
+			return nil, err
+		}
+		if len(c.Parents) == 0 {
+			return c, nil
+		}
+		sha = c.Parents[0].Sha
+	}
+	return nil, fmt.Errorf("the root commit of branch %q in repository %q is more than %d commits away", branch, r, maxAncestryDepth)
+}
+
+// VerifyPrmeBranch returns ErrNotPrmeBranch, with an explanation, unless
+// the history of branch begins with an empty-tree commit, as branches
+// created by prme do.
+func (r Repo) VerifyPrmeBranch(branch string) error {
+	root, err := r.OrphanRoot(branch)
+	if err != nil {
+		return fmt.Errorf("%w: unable to verify the history of branch %q in repository %q: %v", ErrNotPrmeBranch, branch, r, err)
+	}
+	return r.checkPrmeRoot(branch, root)
+}
+
+// checkPrmeRoot returns ErrNotPrmeBranch, with an explanation, unless root,
+// the root commit of branch, is an empty-tree commit.
+func (r Repo) checkPrmeRoot(branch string, root *Commit) error {
+	if root.Tree.Sha != EmptyTreeSha {
+		return fmt.Errorf("%w: the history of branch %q in repository %q begins with commit %s, which contains files, while branches created by prme begin with an empty commit. This may be a real development branch", ErrNotPrmeBranch, branch, r, root.Sha)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package prme_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// commitServer serves branch references, which are not found unless in
+// branches, and git commits, where parents
+// maps commit shas to their first parent, and trees maps commit shas to
+// their tree.
+func commitServer(t *testing.T, branches, parents, trees map[string]string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			for branch := range branches {
+				if r.RequestURI == "/repos/ivanfetch/ghapitest/git/refs/heads/"+branch {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+		}
+		for branch, sha := range branches {
+			if r.RequestURI == "/repos/ivanfetch/ghapitest/git/ref/heads/"+branch {
+				fmt.Fprintf(w, `{"ref": "refs/heads/%s", "object": {"sha": %q}}`, branch, sha)
+				return
+			}
+		}
+		for sha, tree := range trees {
+			if r.RequestURI == "/repos/ivanfetch/ghapitest/git/commits/"+sha {
+				parentsJSON := "[]"
+				if parent, ok := parents[sha]; ok {
+					parentsJSON = fmt.Sprintf(`[{"sha": %q}]`, parent)
+				}
+				fmt.Fprintf(w, `{"sha": %q, "tree": {"sha": %q}, "parents": %s}`, sha, tree, parentsJSON)
+				return
+			}
+		}
+		if !strings.HasPrefix(r.RequestURI, "/repos/ivanfetch/ghapitest/git/ref/heads/") {
+			t.Errorf("unexpected request for Github URL %q", r.RequestURI)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func TestVerifyPrmeBranch(t *testing.T) {
+	t.Parallel()
+
+	ts := commitServer(t,
+		map[string]string{"prme-full-content": "c3", "feature": "f2"},
+		map[string]string{"c3": "c2", "c2": "c1", "f2": "f1"},
+		map[string]string{
+			"c3": "t3", "c2": "t2", "c1": prme.EmptyTreeSha,
+			"f2": "tf2", "f1": "tf1",
+		},
+	)
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.VerifyPrmeBranch("prme-full-content")
+	if err != nil {
+		t.Fatalf("want a branch beginning with an empty-tree commit to be verified, got %v", err)
+	}
+	err = r.VerifyPrmeBranch("feature")
+	if !errors.Is(err, prme.ErrNotPrmeBranch) {
+		t.Fatalf("want %v for a development branch, got %v", prme.ErrNotPrmeBranch, err)
+	}
+}
+
+func TestDeleteReviewBranchesRequiresSharedRoot(t *testing.T) {
+	t.Parallel()
+
+	// Each branch begins with an empty-tree commit, but not the same one.
+	ts := commitServer(t,
+		map[string]string{"prme-full-review": "b1", "prme-full-content": "h2"},
+		map[string]string{"h2": "h1"},
+		map[string]string{"b1": prme.EmptyTreeSha, "h2": "th2", "h1": prme.EmptyTreeSha},
+	)
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.DeleteReviewBranches("prme-full-review", "prme-full-content")
+	if !errors.Is(err, prme.ErrNotPrmeBranch) {
+		t.Fatalf("want %v for branches with different roots, got %v", prme.ErrNotPrmeBranch, err)
+	}
+}
+
+func TestDeleteReviewBranches(t *testing.T) {
+	t.Parallel()
+
+	// The head branch has review fixes and a merge of the main branch.
+	ts := commitServer(t,
+		map[string]string{"prme-full-review": "e1", "prme-full-content": "h2"},
+		map[string]string{"h2": "h1", "h1": "e1"},
+		map[string]string{"e1": prme.EmptyTreeSha, "h1": "th1", "h2": "th2"},
+	)
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.DeleteReviewBranches("prme-full-review", "prme-full-content")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFirstExistingBranch(t *testing.T) {
+	t.Parallel()
+
+	ts := commitServer(t, map[string]string{"trunk": "t1", "develop": "d1"}, nil, nil)
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch, sha, err := r.FirstExistingBranch([]string{"main", "master", "trunk", "develop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch != "trunk" || sha != "t1" {
+		t.Errorf("want branch trunk at t1, got %s at %s", branch, sha)
+	}
+	_, _, err = r.FirstExistingBranch([]string{"main", "master"})
+	if !errors.Is(err, prme.ErrRefNotFound) {
+		t.Errorf("want %v when no branches exist, got %v", prme.ErrRefNotFound, err)
+	}
+}
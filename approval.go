@@ -0,0 +1,329 @@
+package prme
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrPlanSignature is returned by SignedPlans.Verify when the plans were not
+// signed by the expected key, or have been modified since.
+var ErrPlanSignature = errors.New("the plan signature is invalid")
+
+// ErrSameApprover is returned when applying plans as the same Github user
+// who made them, as a second person must approve them.
+var ErrSameApprover = errors.New("the plan must be applied by a different Github user than the one who made it")
+
+// ErrPlanOutdated is returned by Create when the full repository branch has
+// changed since the plan was made, so the approved content would not be
+// reviewed.
+var ErrPlanOutdated = errors.New("the full repository branch has changed since the plan was made")
+
+// AuthenticatedUser returns the Github user of the token of the client.
+func (c *Client) AuthenticatedUser() (*User, error) {
+	apiURI := "/user"
+	resp, err := c.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting the authenticated user", resp.StatusCode, apiURI)
+	}
+	var u User
+	err = json.NewDecoder(resp.Body).Decode(&u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// SignedPlans are plans of full reviews, signed by the user who made them,
+// so a second user can verify and apply them.
+type SignedPlans struct {
+	Plans []Plan `json:"plans"`
+	// PlannedBy is the Github user who made the plans.
+	PlannedBy string    `json:"planned_by"`
+	PlannedAt time.Time `json:"planned_at"`
+	// Signature is the Ed25519 signature of the JSON encoding of the other
+	// fields.
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// signedContent returns the content covered by the signature.
+func (sp SignedPlans) signedContent() ([]byte, error) {
+	sp.Signature = nil
+	return json.Marshal(sp)
+}
+
+// Sign signs the plans using key.
+func (sp *SignedPlans) Sign(key ed25519.PrivateKey) error {
+	content, err := sp.signedContent()
+	if err != nil {
+		return err
+	}
+	sp.Signature = ed25519.Sign(key, content)
+	return nil
+}
+
+// Verify returns ErrPlanSignature unless the plans are signed by the private
+// key of key.
+func (sp SignedPlans) Verify(key ed25519.PublicKey) error {
+	if len(sp.Signature) == 0 {
+		return fmt.Errorf("%w: the plans are not signed", ErrPlanSignature)
+	}
+	content, err := sp.signedContent()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, content, sp.Signature) {
+		return ErrPlanSignature
+	}
+	return nil
+}
+
+// TrustedKeys are the Ed25519 public keys of the Github users allowed to make
+// plans, keyed by their lower-case login.
+type TrustedKeys map[string]ed25519.PublicKey
+
+// ReadTrustedKeys returns the trusted keys in the directory dir, which
+// contains the PEM encoded public key of each Github user allowed to make
+// plans, in a file named after their login ending in .pub, such as
+// octocat.pub.
+func ReadTrustedKeys(dir string) (TrustedKeys, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(TrustedKeys)
+	for _, entry := range entries {
+		login := strings.TrimSuffix(entry.Name(), ".pub")
+		if entry.IsDir() || login == entry.Name() || login == "" {
+			continue
+		}
+		key, err := ReadVerificationKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys[strings.ToLower(login)] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the trusted keys directory %s does not contain any .pub files", dir)
+	}
+	return keys, nil
+}
+
+// VerifyPlanner returns ErrPlanSignature unless the plans are signed by the
+// trusted key of the Github user recorded as having made them, so nobody can
+// sign plans in the name of someone else.
+func (sp SignedPlans) VerifyPlanner(keys TrustedKeys) error {
+	key, ok := keys[strings.ToLower(sp.PlannedBy)]
+	if !ok {
+		return fmt.Errorf("%w: %q, who made the plans, does not have a trusted key", ErrPlanSignature, sp.PlannedBy)
+	}
+	err := sp.Verify(key)
+	if err != nil {
+		return fmt.Errorf("%w: the plans are not signed by the trusted key of %q", ErrPlanSignature, sp.PlannedBy)
+	}
+	return nil
+}
+
+// ReadSignedPlans returns the signed plans from the JSON file path.
+func ReadSignedPlans(path string) (*SignedPlans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sp SignedPlans
+	err = json.Unmarshal(data, &sp)
+	if err != nil {
+		return nil, fmt.Errorf("while reading plan file %s: %w", path, err)
+	}
+	return &sp, nil
+}
+
+// ReadSigningKey returns the Ed25519 private key from the PEM encoded PKCS #8
+// file path, such as created by: openssl genpkey -algorithm ed25519
+func ReadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing the signing key %s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the signing key %s must be an Ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// ReadVerificationKey returns the Ed25519 public key from the PEM encoded
+// file path, such as created by: openssl pkey -pubout
+func ReadVerificationKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing the public key %s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key %s must be an Ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// readPEMFile returns the content of the first PEM block of the file path.
+func readPEMFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("the file %s does not contain a PEM encoded key", path)
+	}
+	return block.Bytes, nil
+}
+
+// Creator returns a FullPullRequestCreator that creates the planned review
+// using token. The OrgConfig was applied when making the plan, so is
+// skipped, and CreateWithResult returns ErrPlanOutdated if the full
+// repository branch has changed since the plan was made.
+func (p Plan) Creator(token string) (*FullPullRequestCreator, error) {
+	f, err := NewFullPullRequestCreator(p.Repo,
+		WithToken(token),
+		WithFullRepoBranch(p.FullRepoBranch),
+		WithBaseBranchName(p.BaseBranch),
+		WithHeadBranchName(p.HeadBranch),
+		WithTitle(p.Title),
+		WithBody(p.Body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	f.Labels = p.Labels
+	f.Reviewers = p.Reviewers
+	f.Assignees = p.Assignees
+	f.Milestone = p.Milestone
+	f.SetCommitStatus = p.SetCommitStatus
+	f.CommentOnFullRepoBranch = p.CommentOnFullRepoBranch
+	f.ForceDelete = p.ForceDelete
+	f.BreakLock = p.BreakLock
+	f.RemindAfterDays = p.RemindAfterDays
+	f.Topic = p.Topic
+	f.RemoveTopic = p.RemoveTopic
+	f.AddTopic = p.AddTopic
+	f.Path = p.Path
+	f.MaxFileSize = p.MaxFileSize
+	f.Symlinks = p.Symlinks
+	f.Submodules = p.Submodules
+	f.NormalizeText = p.NormalizeText
+	f.TableOfContents = p.TableOfContents
+	f.Draft = p.Draft
+	f.HowTo = p.HowTo
+	f.HowToTemplate = p.HowToTemplate
+	f.RetryProtectedBranches = p.RetryProtectedBranches
+	f.CheckReviewerPushAccess = p.CheckReviewerPushAccess
+	f.SkipOrgConfig = true
+	f.plannedFullRepoSha = p.FullRepoSha
+	return f, nil
+}
+
+// runApplyCommand creates the full reviews of a plan file, signed by another
+// user.
+func runApplyCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme apply", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand creates the full reviews of a plan file, written by prme plan -o, after verifying the plan is signed by the trusted key of the Github user who made it, and that they are a different Github user. This requires two people to approve changes, such as for organization-wide reviews in high-control environments.
+
+Usage: %s -plan <plan file> -trusted-keys <directory>
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	CLIPlanFile := fs.String("plan", "", "The signed plan file to apply. This is also set via the PRME_PLAN environment variable.")
+	CLITrustedKeys := fs.String("trusted-keys", "", "A directory containing the PEM encoded Ed25519 public key of each Github user allowed to make plans, named after their login ending in .pub, such as octocat.pub. The plan must be signed by the key of the user who made it. This is also set via the PRME_TRUSTED_KEYS environment variable.")
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which to record created pull requests, used by other prme subcommands. This is also set via the PRME_STATE_FILE environment variable.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if *CLIPlanFile == "" || *CLITrustedKeys == "" {
+		fs.Usage()
+		return errors.New("please specify the plan file and the directory of trusted keys")
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	sp, err := ReadSignedPlans(*CLIPlanFile)
+	if err != nil {
+		return err
+	}
+	keys, err := ReadTrustedKeys(*CLITrustedKeys)
+	if err != nil {
+		return err
+	}
+	err = sp.VerifyPlanner(keys)
+	if err != nil {
+		return err
+	}
+	c, err := NewClient(token, clientOptions...)
+	if err != nil {
+		return err
+	}
+	u, err := c.AuthenticatedUser()
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Login, sp.PlannedBy) {
+		return fmt.Errorf("%w: %s", ErrSameApprover, u.Login)
+	}
+	fmt.Fprintf(output, "Applying the plan made by %s at %s, approved by %s\n", sp.PlannedBy, sp.PlannedAt.Format(time.RFC3339), u.Login)
+	var failed int
+	for _, plan := range sp.Plans {
+		f, err := plan.Creator(token)
+		if err != nil {
+			return err
+		}
+		f.StateFile = *CLIStateFile
+		f.clientOptions = append(f.clientOptions, clientOptions...)
+		PR, err := f.CreateWithResult()
+		if errors.Is(err, ErrAlreadyCreated) || errors.Is(err, ErrReviewExists) {
+			fmt.Fprintf(output, "%s: skipped, a full review pull request was already created at %s\n", plan.Repo, PR.HTMLURL)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(errOutput, "%s: %v\n", plan.Repo, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(output, "%s: a full pull request has been created at %s\n", plan.Repo, PR.HTMLURL)
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to apply the plans of %d repositories", failed)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package prme_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSignedPlansVerify(t *testing.T) {
+	t.Parallel()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := prme.SignedPlans{
+		Plans: []prme.Plan{
+			{
+				Repo:           "ivanfetch/ghapitest",
+				FullRepoBranch: "main",
+				FullRepoSha:    "c3",
+				Title:          "Full Review",
+			},
+		},
+		PlannedBy: "alice",
+		PlannedAt: time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC),
+	}
+	err = sp.Verify(publicKey)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying unsigned plans, got %v", prme.ErrPlanSignature, err)
+	}
+	err = sp.Sign(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sp.Verify(publicKey)
+	if err != nil {
+		t.Fatalf("want signed plans verified, got %v", err)
+	}
+	err = sp.Verify(otherPublicKey)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying plans using another key, got %v", prme.ErrPlanSignature, err)
+	}
+	modified := sp
+	modified.Plans = []prme.Plan{sp.Plans[0]}
+	modified.Plans[0].FullRepoSha = "c4"
+	err = modified.Verify(publicKey)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying modified plans, got %v", prme.ErrPlanSignature, err)
+	}
+	modified = sp
+	modified.PlannedBy = "bob"
+	err = modified.Verify(publicKey)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying plans with a modified planner, got %v", prme.ErrPlanSignature, err)
+	}
+}
+
+func TestReadSigningAndVerificationKeys(t *testing.T) {
+	t.Parallel()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "plan.key")
+	publicFile := filepath.Join(dir, "plan.pub")
+	err = os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPrivate, err := prme.ReadSigningKey(privateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPublic, err := prme.ReadVerificationKey(publicFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotPrivate.Equal(privateKey) || !gotPublic.Equal(publicKey) {
+		t.Fatal("got keys that differ from those written")
+	}
+	_, err = prme.ReadSigningKey(publicFile)
+	if err == nil {
+		t.Fatal("want an error reading a public key as the signing key, got none")
+	}
+}
+
+func TestAuthenticatedUser(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"login": "ivanfetch"}`)
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken", prme.WithAPIHost(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := c.AuthenticatedUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Login != "ivanfetch" {
+		t.Fatalf("want user ivanfetch, got %q", u.Login)
+	}
+}
+
+// writePublicKeyFile writes key to the PEM encoded file path.
+func writePublicKeyFile(t *testing.T, path string, key ed25519.PublicKey) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignedPlansVerifyPlanner(t *testing.T) {
+	t.Parallel()
+
+	alicePublicKey, alicePrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPublicKey, bobPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writePublicKeyFile(t, filepath.Join(dir, "Alice.pub"), alicePublicKey)
+	writePublicKeyFile(t, filepath.Join(dir, "bob.pub"), bobPublicKey)
+	keys, err := prme.ReadTrustedKeys(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := prme.SignedPlans{
+		Plans:     []prme.Plan{{Repo: "ivanfetch/ghapitest", FullRepoBranch: "main", FullRepoSha: "c3", Title: "Full Review"}},
+		PlannedBy: "alice",
+	}
+	err = sp.Sign(alicePrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sp.VerifyPlanner(keys)
+	if err != nil {
+		t.Fatalf("want plans signed by the trusted key of their planner verified, got %v", err)
+	}
+	// Bob signs plans using his own key, in the name of Alice, so he can
+	// apply them himself.
+	sp.Signature = nil
+	err = sp.Sign(bobPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sp.VerifyPlanner(keys)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying plans signed by bob in the name of alice, got %v", prme.ErrPlanSignature, err)
+	}
+	sp.PlannedBy = "mallory"
+	err = sp.VerifyPlanner(keys)
+	if !errors.Is(err, prme.ErrPlanSignature) {
+		t.Fatalf("want %v verifying plans made by a user without a trusted key, got %v", prme.ErrPlanSignature, err)
+	}
+}
+
+func TestApplyRejectsPlanSignedInTheNameOfAnotherUser(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("GH_TOKEN", "dummyToken")
+	t.Setenv("PRME_STATE_FILE", "")
+
+	alicePublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPublicKey, bobPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	trustedDir := filepath.Join(dir, "trusted")
+	err = os.Mkdir(trustedDir, 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePublicKeyFile(t, filepath.Join(trustedDir, "alice.pub"), alicePublicKey)
+	writePublicKeyFile(t, filepath.Join(trustedDir, "bob.pub"), bobPublicKey)
+	// Bob makes a plan, signs it using his own key, and records Alice as
+	// having made it, so that he can approve it himself.
+	sp := prme.SignedPlans{
+		Plans:     []prme.Plan{{Repo: "ivanfetch/ghapitest", FullRepoBranch: "main", FullRepoSha: "c3", Title: "Full Review"}},
+		PlannedBy: "alice",
+	}
+	err = sp.Sign(bobPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planFile := filepath.Join(dir, "plan.json")
+	err = os.WriteFile(planFile, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output, errOutput strings.Builder
+	got := prme.RunCLI([]string{"apply", "-plan", planFile, "-trusted-keys", trustedDir}, &output, &errOutput)
+	if got != 1 {
+		t.Errorf("want exit code 1 applying a plan signed in the name of another user, got %d", got)
+	}
+	if !strings.Contains(errOutput.String(), prme.ErrPlanSignature.Error()) {
+		t.Errorf("want an error about the plan signature, got %q", errOutput.String())
+	}
+	if output.Len() != 0 {
+		t.Errorf("want nothing applied, got %q", output.String())
+	}
+}
+
+func TestPlanCreatorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/topics", `{"names":["needs-audit"]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c", `{"sha":"t1","tree":[{"path":"services","mode":"040000","type":"tree","sha":"t2"}]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/t2", `{"sha":"t2","tree":[{"path":"main.go","mode":"100644","type":"blob","sha":"b1","size":10}]}`)
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+		prme.WithTopic("needs-audit"),
+		prme.WithPath("services"),
+		prme.WithMaxFileSize(1000000),
+		prme.WithSymlinks(prme.LinkMaterialize),
+		prme.WithSubmodules(prme.LinkSkip),
+		prme.WithNormalizedText(),
+		prme.WithTableOfContents(),
+		prme.WithDraft(),
+		prme.WithHowToComment("Push review fixes to {{.HeadBranch}}."),
+		prme.WithBreakLock(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SkipOrgConfig = true
+	f.RemoveTopic = true
+	f.AddTopic = "audit-in-progress"
+	f.RetryProtectedBranches = true
+	f.CheckReviewerPushAccess = true
+	plan, err := f.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotPlan prme.Plan
+	err = json.Unmarshal(planJSON, &gotPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gotPlan.Creator("dummyToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The creator of a plan always skips the OrgConfig, which was applied
+	// while planning.
+	if !cmp.Equal(*f, *got, cmpopts.IgnoreUnexported(prme.FullPullRequestCreator{})) {
+		t.Fatalf("got options that differ from those planned\ndiff reflects want vs. got: %s", cmp.Diff(*f, *got, cmpopts.IgnoreUnexported(prme.FullPullRequestCreator{})))
+	}
+}
@@ -0,0 +1,428 @@
+package prme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultAzureDevOpsURL is the URL of Azure DevOps Services, below which
+// repositories are addressed by organization, project, and name.
+const DefaultAzureDevOpsURL = "https://dev.azure.com"
+
+// azureAPIVersion is the version of the Azure DevOps REST API used.
+const azureAPIVersion = "7.1"
+
+// AzureDevOpsTokenEnvVar is the environment variable from which the prme
+// azure subcommand reads an Azure DevOps personal access token, the same
+// variable used by the Azure DevOps extension of the Azure CLI.
+const AzureDevOpsTokenEnvVar = "AZURE_DEVOPS_EXT_PAT"
+
+// AzureRepo is an Azure DevOps Repos git repository, whose methods use the
+// Azure DevOps REST API. Create it with NewAzureRepo.
+type AzureRepo struct {
+	Client                      *Client
+	organization, project, name string
+}
+
+func (r AzureRepo) String() string {
+	return r.organization + "/" + r.project + "/" + r.name
+}
+
+// NewAzureRepo returns the Azure DevOps repository organizationProjectName,
+// of the form organization/project/repository, authenticating using the
+// personal access token, which needs the Code (Read & write) scope. The
+// client options are applied as by NewRepo; use WithBaseURL for an Azure
+// DevOps Server collection, such as https://tfs.example.com/tfs/DefaultCollection,
+// whose repositories are then addressed as collection/project/repository.
+// Git commands always use HTTPS, authenticating using the token.
+func NewAzureRepo(organizationProjectName, token string, clientOptions ...ClientOption) (*AzureRepo, error) {
+	parts := strings.Split(organizationProjectName, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("the Azure DevOps repository must be of the form organization/project/repository, not %q", organizationProjectName)
+	}
+	options := append([]ClientOption{WithAPIHost(DefaultAzureDevOpsURL)}, clientOptions...)
+	// Azure DevOps accepts a personal access token as the password of any
+	// user name.
+	options = append(options, WithGitProtocol(GitProtocolHTTPS))
+	c, err := NewClient(token, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &AzureRepo{
+		Client:       c,
+		organization: parts[0],
+		project:      parts[1],
+		name:         parts[2],
+	}, nil
+}
+
+// CloneURL returns the URL used by git commands to clone the repository.
+func (r AzureRepo) CloneURL() string {
+	return fmt.Sprintf("%s/%s/%s/_git/%s", r.Client.apiHost, url.PathEscape(r.organization), url.PathEscape(r.project), url.PathEscape(r.name))
+}
+
+// apiURL returns the Azure DevOps REST API URL of the repository, followed
+// by path, with the API version and query parameters.
+func (r AzureRepo) apiURL(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", azureAPIVersion)
+	return fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s%s?%s", r.Client.apiHost, url.PathEscape(r.organization), url.PathEscape(r.project), url.PathEscape(r.name), path, query.Encode())
+}
+
+// do makes an Azure DevOps REST API request to URL, including body if it is
+// not nil.
+func (r AzureRepo) do(method, URL string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, URL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth("", r.Client.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.Client.doer().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Azure DevOps responds to unauthenticated requests with a sign-in
+	// page, using HTTP 203.
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Azure DevOps did not accept the personal access token while accessing repository %q", r)
+	}
+	return resp, nil
+}
+
+// Exists returns whether the repository exists.
+func (r AzureRepo) Exists() (bool, error) {
+	URL := r.apiURL("", nil)
+	resp, err := r.do(http.MethodGet, URL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d for %s while getting repository %q", resp.StatusCode, URL, r)
+	}
+	return true, nil
+}
+
+// BranchSha returns the commit sha of branch, or ErrRefNotFound if it does
+// not exist.
+func (r AzureRepo) BranchSha(branch string) (string, error) {
+	URL := r.apiURL("/refs", url.Values{"filter": {"heads/" + branch}})
+	resp, err := r.do(http.MethodGet, URL, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d for %s while getting branch %q in repository %q", resp.StatusCode, URL, branch, r)
+	}
+	var refs struct {
+		Value []struct {
+			Name     string `json:"name"`
+			ObjectID string `json:"objectId"`
+		} `json:"value"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&refs)
+	if err != nil {
+		return "", err
+	}
+	// The filter matches branches beginning with the name.
+	for _, ref := range refs.Value {
+		if ref.Name == "refs/heads/"+branch {
+			return ref.ObjectID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: branch %q in repository %q", ErrRefNotFound, branch, r)
+}
+
+// azurePullRequest is an Azure DevOps pull request.
+type azurePullRequest struct {
+	PullRequestID int    `json:"pullRequestId"`
+	Title         string `json:"title"`
+	Status        string `json:"status"`
+	IsDraft       bool   `json:"isDraft"`
+	URL           string `json:"url"`
+	SourceRefName string `json:"sourceRefName"`
+	TargetRefName string `json:"targetRefName"`
+	Repository    struct {
+		WebURL string `json:"webUrl"`
+	} `json:"repository"`
+}
+
+// pullRequest returns p as a PullRequest, whose State is open while p is
+// active.
+func (p azurePullRequest) pullRequest() *PullRequest {
+	state := "closed"
+	if p.Status == "active" {
+		state = "open"
+	}
+	return &PullRequest{
+		Number:  p.PullRequestID,
+		Title:   p.Title,
+		URL:     p.URL,
+		HTMLURL: p.Repository.WebURL + "/pullrequest/" + strconv.Itoa(p.PullRequestID),
+		State:   state,
+		Draft:   p.IsDraft,
+		Merged:  p.Status == "completed",
+		Head:    PullRequestBranch{Ref: strings.TrimPrefix(p.SourceRefName, "refs/heads/")},
+		Base:    PullRequestBranch{Ref: strings.TrimPrefix(p.TargetRefName, "refs/heads/")},
+	}
+}
+
+// FindActivePullRequest returns the active pull request from headBranch to
+// baseBranch, or nil if there is none.
+func (r AzureRepo) FindActivePullRequest(baseBranch, headBranch string) (*PullRequest, error) {
+	URL := r.apiURL("/pullrequests", url.Values{
+		"searchCriteria.status":        {"active"},
+		"searchCriteria.sourceRefName": {"refs/heads/" + headBranch},
+		"searchCriteria.targetRefName": {"refs/heads/" + baseBranch},
+	})
+	resp, err := r.do(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while finding pull requests in repository %q", resp.StatusCode, URL, r)
+	}
+	var PRs struct {
+		Value []azurePullRequest `json:"value"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&PRs)
+	if err != nil {
+		return nil, err
+	}
+	if len(PRs.Value) == 0 {
+		return nil, nil
+	}
+	return PRs.Value[0].pullRequest(), nil
+}
+
+// azurePullRequestRequest is the request body creating an Azure DevOps
+// pull request.
+type azurePullRequestRequest struct {
+	SourceRefName string `json:"sourceRefName"`
+	TargetRefName string `json:"targetRefName"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	IsDraft       bool   `json:"isDraft,omitempty"`
+}
+
+// azureMaxDescriptionLength is the longest description of an Azure DevOps
+// pull request.
+const azureMaxDescriptionLength = 4000
+
+// CreatePullRequest creates a pull request from headBranch to baseBranch,
+// as a draft if draft is true. Azure DevOps limits the body to 4000
+// characters.
+func (r AzureRepo) CreatePullRequest(baseBranch, headBranch, title, body string, draft bool) (*PullRequest, error) {
+	if len([]rune(body)) > azureMaxDescriptionLength {
+		return nil, fmt.Errorf("the body of an Azure DevOps pull request cannot be longer than %d characters", azureMaxDescriptionLength)
+	}
+	PRJSON, err := json.Marshal(azurePullRequestRequest{
+		SourceRefName: "refs/heads/" + headBranch,
+		TargetRefName: "refs/heads/" + baseBranch,
+		Title:         title,
+		Description:   body,
+		IsDraft:       draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+	URL := r.apiURL("/pullrequests", nil)
+	resp, err := r.do(http.MethodPost, URL, PRJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while creating pull request from %q to %q in repository %q", resp.StatusCode, URL, headBranch, baseBranch, r)
+	}
+	var PR azurePullRequest
+	err = json.NewDecoder(resp.Body).Decode(&PR)
+	if err != nil {
+		return nil, err
+	}
+	return PR.pullRequest(), nil
+}
+
+// CreateReviewBranches creates baseBranch at a new commit of the empty tree,
+// and headBranch at a merge of that commit and fullRepoSha, with
+// mergeMessage, so a pull request from headBranch to baseBranch contains
+// every file of fullRepoSha. Both branches are pushed together.
+func (r AzureRepo) CreateReviewBranches(baseBranch, headBranch, fullRepoSha, mergeMessage string) error {
+	tempDir, err := os.MkdirTemp("", "pr-me-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	repoDir := tempDir + "/" + r.name
+	// A bare clone has the commits without checking out files.
+	_, err = r.Client.runGitCommand(tempDir, "clone", "--bare", r.CloneURL(), repoDir)
+	if err != nil {
+		return err
+	}
+	emptySha, err := RunGitCommand(repoDir, "commit-tree", EmptyTreeSha, "-m", emptyTreeCommitMessage)
+	if err != nil {
+		return err
+	}
+	mergeSha, err := RunGitCommand(repoDir, "commit-tree", fullRepoSha+"^{tree}", "-p", emptySha, "-p", fullRepoSha, "-m", mergeMessage)
+	if err != nil {
+		return err
+	}
+	_, err = r.Client.runGitCommand(repoDir, "push", "origin", emptySha+":refs/heads/"+baseBranch, mergeSha+":refs/heads/"+headBranch)
+	return err
+}
+
+// CreateAzure creates the branches and pull request for a full review of
+// f.Repo, an Azure DevOps repository of the form
+// organization/project/repository, as described by NewAzureRepo. The
+// branches, title, body, and draft options of f are used, along with its
+// client options and output, while options specific to Github are ignored.
+// If the pull request is already open, it is returned along with
+// ErrReviewExists.
+func (f FullPullRequestCreator) CreateAzure() (*PullRequest, error) {
+	if f.FullRepoBranch == "" {
+		return nil, errors.New("the full repo branch cannot be empty")
+	}
+	if f.BaseBranch == "" {
+		return nil, errors.New("the base branch cannot be empty")
+	}
+	if f.HeadBranch == "" {
+		return nil, errors.New("the head branch cannot be empty")
+	}
+	if f.Title == "" {
+		return nil, errors.New("the title cannot be empty")
+	}
+	if f.Body == "" {
+		return nil, errors.New("the body cannot be empty")
+	}
+	r, err := NewAzureRepo(f.Repo, f.token, f.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("repository %q does not exist or the access token does not provide access", r)
+	}
+	PR, err := r.FindActivePullRequest(f.BaseBranch, f.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	if PR != nil {
+		return PR, fmt.Errorf("%w: %s", ErrReviewExists, PR.HTMLURL)
+	}
+	fullRepoSha, err := r.BranchSha(f.FullRepoBranch)
+	if err != nil {
+		return nil, err
+	}
+	for _, branch := range []struct{ kind, name string }{{"base", f.BaseBranch}, {"head", f.HeadBranch}} {
+		_, err := r.BranchSha(branch.name)
+		if err == nil {
+			return nil, fmt.Errorf("%s branch %q already exists in repository %q", branch.kind, branch.name, r)
+		}
+		if !errors.Is(err, ErrRefNotFound) {
+			return nil, err
+		}
+	}
+	f.progress("creating branches %s and %s", f.BaseBranch, f.HeadBranch)
+	err = r.CreateReviewBranches(f.BaseBranch, f.HeadBranch, fullRepoSha, f.mergeCommitMessage())
+	if err != nil {
+		return nil, err
+	}
+	f.progress("creating the pull request")
+	return r.CreatePullRequest(f.BaseBranch, f.HeadBranch, f.Title, f.Body, f.Draft)
+}
+
+// runAzureCommand creates the full review of an Azure DevOps repository.
+func runAzureCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme azure", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand creates a pull request that reviews all content of an Azure DevOps Repos git repository.
+
+The %s environment variable must be set to an Azure DevOps personal access token with the Code (Read & write) scope.
+
+Usage: %s [flags] <organization>/<project>/<repository>
+
+Available command-line flags:
+`,
+			AzureDevOpsTokenEnvVar, fs.Name())
+		fs.PrintDefaults()
+	}
+	defaults, err := NewFullPullRequestCreator("dummyRepo")
+	if err != nil {
+		return err
+	}
+	CLIURL := fs.String("devops-url", DefaultAzureDevOpsURL, "The URL of Azure DevOps, or of an Azure DevOps Server, such as https://tfs.example.com/tfs, in which case the repository is specified as <collection>/<project>/<repository>. This is also set via the PRME_DEVOPS_URL environment variable.")
+	CLITitle := fs.String("title", defaults.Title, "The title of the pull request. This is also set via the PRME_TITLE environment variable.")
+	CLIBody := fs.String("body", defaults.Body, "The body of the pull request, up to 4000 characters. This is also set via the PRME_BODY environment variable.")
+	CLIFullRepoBranch := fs.String("fbranch", defaults.FullRepoBranch, "The existing branch containing all repository files, which is reviewed. This is also set via the PRME_FBRANCH environment variable.")
+	CLIBaseBranch := fs.String("bbranch", defaults.BaseBranch, "The base branch of the pull request, which is created empty. This is also set via the PRME_BBRANCH environment variable.")
+	CLIHeadBranch := fs.String("hbranch", defaults.HeadBranch, "The head branch of the pull request, which is created containing all files of the full repository branch. This is also set via the PRME_HBRANCH environment variable.")
+	CLIDraft := fs.Bool("draft", false, "Create the pull request as a draft. This is also set via the PRME_DRAFT environment variable.")
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one repository, in the form organization/project/repository")
+	}
+	token := os.Getenv(AzureDevOpsTokenEnvVar)
+	if token == "" {
+		return fmt.Errorf("Please set the %s environment variable to an Azure DevOps personal access token.", AzureDevOpsTokenEnvVar)
+	}
+	f, err := NewFullPullRequestCreator(fs.Arg(0),
+		WithToken(token),
+		WithTitle(*CLITitle),
+		WithBody(*CLIBody),
+		WithFullRepoBranch(*CLIFullRepoBranch),
+		WithBaseBranchName(*CLIBaseBranch),
+		WithHeadBranchName(*CLIHeadBranch),
+		WithClientOptions(WithBaseURL(*CLIURL)),
+		WithOutput(output),
+		WithErrOutput(errOutput),
+	)
+	if err != nil {
+		return err
+	}
+	f.Draft = *CLIDraft
+	PR, err := f.CreateAzure()
+	if errors.Is(err, ErrReviewExists) {
+		fmt.Fprintf(errOutput, "A full review pull request is already open for repository %s, at:\n", f.Repo)
+		fmt.Fprintln(output, PR.HTMLURL)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "A full pull request has been created at %s\n", PR.HTMLURL)
+	return nil
+}
@@ -0,0 +1,141 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testAzureRepoPath = "/myorg/myproject/_apis/git/repositories/myrepo"
+
+func TestNewAzureRepoInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"myorg/myrepo", "myorg//myrepo", "myorg/myproject/myrepo/extra"} {
+		_, err := prme.NewAzureRepo(name, "dummyToken")
+		if err == nil {
+			t.Errorf("want an error for the repository %q", name)
+		}
+	}
+}
+
+func TestAzureRepoCloneURL(t *testing.T) {
+	t.Parallel()
+
+	r, err := prme.NewAzureRepo("myorg/my project/myrepo", "dummyToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://dev.azure.com/myorg/my%20project/_git/myrepo"
+	if r.CloneURL() != want {
+		t.Errorf("want clone URL %q, got %q", want, r.CloneURL())
+	}
+	r, err = prme.NewAzureRepo("DefaultCollection/myproject/myrepo", "dummyToken", prme.WithBaseURL("https://tfs.example.com/tfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "https://tfs.example.com/tfs/DefaultCollection/myproject/_git/myrepo"
+	if r.CloneURL() != want {
+		t.Errorf("want clone URL %q for Azure DevOps Server, got %q", want, r.CloneURL())
+	}
+}
+
+func TestAzureRepoBranchSha(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, testAzureRepoPath+"/refs", `{"value":[
+		{"name":"refs/heads/main-old","objectId":"a1"},
+		{"name":"refs/heads/main","objectId":"c3"}
+	],"count":2}`)
+	r, err := prme.NewAzureRepo("myorg/myproject/myrepo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha, err := r.BranchSha("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sha != "c3" {
+		t.Errorf("want sha c3 for branch main, got %q", sha)
+	}
+	_, err = r.BranchSha("main-o")
+	if !errors.Is(err, prme.ErrRefNotFound) {
+		t.Errorf("want %v for a branch only matched by prefix, got %v", prme.ErrRefNotFound, err)
+	}
+	want := testAzureRepoPath + "/refs?api-version=7.1&filter=heads%2Fmain"
+	if got := fc.Requests()[0].URI; got != want {
+		t.Errorf("want request URI %q, got %q", want, got)
+	}
+}
+
+func TestAzureRepoCreatePullRequest(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPost, testAzureRepoPath+"/pullrequests", prme.FakeResponse{
+		StatusCode: http.StatusCreated,
+		Body: `{"pullRequestId":7,"title":"Full Review","status":"active","isDraft":true,
+			"sourceRefName":"refs/heads/prme-full-content","targetRefName":"refs/heads/prme-full-review",
+			"repository":{"webUrl":"https://dev.azure.com/myorg/myproject/_git/myrepo"}}`,
+	})
+	r, err := prme.NewAzureRepo("myorg/myproject/myrepo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.CreatePullRequest("prme-full-review", "prme-full-content", "Full Review", "Review all files", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &prme.PullRequest{
+		Number:  7,
+		Title:   "Full Review",
+		HTMLURL: "https://dev.azure.com/myorg/myproject/_git/myrepo/pullrequest/7",
+		State:   "open",
+		Draft:   true,
+		Head:    prme.PullRequestBranch{Ref: "prme-full-content"},
+		Base:    prme.PullRequestBranch{Ref: "prme-full-review"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect pull request\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+	wantBody := `{"sourceRefName":"refs/heads/prme-full-content","targetRefName":"refs/heads/prme-full-review","title":"Full Review","description":"Review all files","isDraft":true}`
+	if gotBody := string(fc.Requests()[0].Body); gotBody != wantBody {
+		t.Errorf("want request body %s, got %s", wantBody, gotBody)
+	}
+}
+
+func TestCreateAzureReviewExists(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, testAzureRepoPath, `{"id":"r1","name":"myrepo"}`)
+	fc.SetJSONResponse(http.MethodGet, testAzureRepoPath+"/pullrequests", `{"value":[{"pullRequestId":7,"status":"active",
+		"repository":{"webUrl":"https://dev.azure.com/myorg/myproject/_git/myrepo"}}],"count":1}`)
+	f, err := prme.NewFullPullRequestCreator("myorg/myproject/myrepo",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	PR, err := f.CreateAzure()
+	if !errors.Is(err, prme.ErrReviewExists) {
+		t.Fatalf("want %v, got %v", prme.ErrReviewExists, err)
+	}
+	if PR == nil || PR.HTMLURL != "https://dev.azure.com/myorg/myproject/_git/myrepo/pullrequest/7" {
+		t.Errorf("want the existing pull request, got %+v", PR)
+	}
+}
@@ -0,0 +1,119 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxBatchPauses is the number of times a batch pauses for Github
+// maintenance or abuse rate limits, while creating a pull request for a
+// single repository, before giving up on that repository.
+const maxBatchPauses = 3
+
+// batchCountdownInterval is how often the remaining pause is displayed.
+const batchCountdownInterval = 10 * time.Second
+
+// BatchResult is the outcome of creating a full pull request for one
+// repository of a batch.
+type BatchResult struct {
+	Repo string
+	// FullRepoBranch is the branch reviewed, when reviewing several
+	// branches using CreateWorkspace.
+	FullRepoBranch string
+	PullRequest    *PullRequest
+	Err            error
+	// Duration is how long was spent on the repository, including pauses.
+	Duration time.Duration
+}
+
+// batchPause returns how long a batch should pause after err, and whether
+// err is a Github maintenance or abuse rate limit error.
+func batchPause(err error) (time.Duration, bool) {
+	var abuseErr *AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return abuseErr.RetryAfter, true
+	}
+	var maintenanceErr *MaintenanceError
+	if errors.As(err, &maintenanceErr) {
+		return maintenanceErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// countdown pauses for d, using the clock of f, displaying the remaining
+// time to output.
+func (f FullPullRequestCreator) countdown(output io.Writer, d time.Duration) {
+	for d > 0 {
+		fmt.Fprintf(output, "Resuming in %v...\n", d.Round(time.Second))
+		step := batchCountdownInterval
+		if d < step {
+			step = d
+		}
+		f.sleep(step)
+		d -= step
+	}
+}
+
+// shareHTTPClient returns a copy of f whose Github API clients all use the
+// same HTTP client, built once from the client options of f, so a batch
+// reuses its pool of connections, including the connection opened while
+// listing repositories, instead of a TLS handshake for each repository when
+// options such as WithCACertificate customize the transport. If the client
+// cannot be built, f is returned, and the error is reported by Create.
+func (f FullPullRequestCreator) shareHTTPClient() FullPullRequestCreator {
+	if f.sharedHTTPClient {
+		return f
+	}
+	c, err := NewClient(f.token, f.clientOptions...)
+	if err != nil {
+		return f
+	}
+	// Copy clientOptions, so f does not share the appended option.
+	f.clientOptions = append(f.clientOptions[:len(f.clientOptions):len(f.clientOptions)], WithHTTPClient(c.httpClient))
+	f.sharedHTTPClient = true
+	return f
+}
+
+// CreateBatch creates a full pull request for each of repos, using the
+// remaining properties of f. If Github maintenance or abuse rate limits are
+// encountered, the whole batch pauses, displaying a countdown to output,
+// before retrying the current repository. Repositories excluded by the
+// repository filters are skipped before anything is created, as are
+// remaining repositories once an APICallBudget is exhausted.
+func (f FullPullRequestCreator) CreateBatch(repos []string, output io.Writer) []BatchResult {
+	f = f.shareHTTPClient()
+	results := make([]BatchResult, 0, len(repos))
+	for i, repo := range repos {
+		repoCreator := f
+		repoCreator.Repo = repo
+		repoCreator.batchRepos = nil
+		repoCreator.batchOwner = ""
+		result := BatchResult{Repo: repo}
+		if !f.repoAllowed(repo) {
+			result.Err = fmt.Errorf("%w: %s", ErrRepoExcluded, repo)
+			results = append(results, result)
+			continue
+		}
+		started := f.now()
+		for pauses := 0; ; pauses++ {
+			result.PullRequest, result.Err = repoCreator.CreateWithResult()
+			pause, ok := batchPause(result.Err)
+			if !ok || pauses == maxBatchPauses {
+				break
+			}
+			fmt.Fprintf(output, "Pausing the batch while processing repository %s: %v\n", repo, result.Err)
+			f.countdown(output, pause)
+		}
+		result.Duration = f.now().Sub(started)
+		results = append(results, result)
+		if errors.Is(result.Err, ErrAPIBudgetExhausted) {
+			for _, skipped := range repos[i+1:] {
+				results = append(results, BatchResult{Repo: skipped, Err: result.Err})
+			}
+			break
+		}
+	}
+	return results
+}
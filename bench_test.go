@@ -0,0 +1,145 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// benchRepoSizes are the numbers of files of the synthetic repositories
+// used by benchmarks, from a small service to a large monorepo.
+var benchRepoSizes = []int{100, 10000, 100000}
+
+// benchLatencies are simulated Github API latencies.
+var benchLatencies = []time.Duration{0, time.Millisecond}
+
+// syntheticFiles returns the paths of a synthetic repository of n files,
+// spread across nested directories like a real source tree.
+func syntheticFiles(n int) []string {
+	files := make([]string, n)
+	for i := range files {
+		files[i] = fmt.Sprintf("pkg%03d/sub%02d/file%06d.go", i/1000, i/50%20, i)
+	}
+	return files
+}
+
+// syntheticTreeJSON returns the recursive git tree of the synthetic
+// repository of n files, as returned by the Github API. Every tenth file is
+// 2MiB, and the rest are 4KiB.
+func syntheticTreeJSON(n int) string {
+	tree := prme.Tree{Sha: "t1"}
+	dirs := make(map[string]bool)
+	for i, file := range syntheticFiles(n) {
+		for _, dir := range []string{file[:len("pkg000")], file[:len("pkg000/sub00")]} {
+			if !dirs[dir] {
+				dirs[dir] = true
+				tree.Entries = append(tree.Entries, prme.TreeEntry{Path: dir, Mode: "040000", Type: "tree", Sha: "d" + dir})
+			}
+		}
+		size := int64(4096)
+		if i%10 == 0 {
+			size = 2 << 20
+		}
+		tree.Entries = append(tree.Entries, prme.TreeEntry{Path: file, Mode: "100644", Type: "blob", Sha: fmt.Sprintf("b%06d", i), Size: size})
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// newBenchFakeClient returns a FakeClient responding like the Github API for
+// the synthetic repository ivanfetch/ghapitest of n files, with latency.
+func newBenchFakeClient(b *testing.B, n int, latency time.Duration) *prme.FakeClient {
+	b.Helper()
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		b.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/t1", syntheticTreeJSON(n))
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/git/trees", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha":"t2"}`})
+	fc.SetLatency(latency)
+	return fc
+}
+
+func BenchmarkPlan(b *testing.B) {
+	for _, latency := range benchLatencies {
+		b.Run(fmt.Sprintf("latency=%v", latency), func(b *testing.B) {
+			fc := newBenchFakeClient(b, benchRepoSizes[0], latency)
+			f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+				prme.WithToken("dummyToken"),
+				prme.WithAPIMiddleware(fc.Middleware()),
+			)
+			if err != nil {
+				b.Fatal(err)
+			}
+			f.Labels = []string{"audit"}
+			f.Reviewers = []string{"ivanfetch"}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := f.Plan()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkOmitLargeFiles(b *testing.B) {
+	for _, n := range benchRepoSizes {
+		for _, latency := range benchLatencies {
+			b.Run(fmt.Sprintf("files=%d/latency=%v", n, latency), func(b *testing.B) {
+				fc := newBenchFakeClient(b, n, latency)
+				r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, omitted, err := r.OmitLargeFiles("t1", 1<<20)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if len(omitted) != (n+9)/10 {
+						b.Fatalf("want %d files omitted, got %d", (n+9)/10, len(omitted))
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSplitPaths(b *testing.B) {
+	for _, n := range benchRepoSizes {
+		b.Run(fmt.Sprintf("files=%d", n), func(b *testing.B) {
+			files := syntheticFiles(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				prme.SplitPaths(files, 3000)
+			}
+		})
+	}
+}
+
+func BenchmarkTableOfContents(b *testing.B) {
+	for _, n := range benchRepoSizes {
+		b.Run(fmt.Sprintf("files=%d", n), func(b *testing.B) {
+			files := syntheticFiles(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				prme.TableOfContents("https://github.com/ivanfetch/ghapitest/pull/7", files, 65536)
+			}
+		})
+	}
+}
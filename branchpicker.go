@@ -0,0 +1,132 @@
+package prme
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BranchPicker chooses the full repository branch from the branches of a
+// repository, when the requested full repository branch does not exist.
+// The default branch of the repository is included in branches.
+type BranchPicker func(repo string, branches []string, defaultBranch string) (string, error)
+
+// FirstExistingBranch returns the first of branches that exists in the
+// repository, and the sha of its commit. An error wrapping ErrRefNotFound is
+// returned if none exist.
+func (r Repo) FirstExistingBranch(branches []string) (branch, sha string, err error) {
+	for _, branch := range branches {
+		sha, err := r.GetRef("heads/" + branch)
+		if err == nil {
+			return branch, sha, nil
+		}
+		if !errors.Is(err, ErrRefNotFound) {
+			return "", "", err
+		}
+	}
+	return "", "", fmt.Errorf("%w: none of the branches %s exist in repository %q", ErrRefNotFound, strings.Join(branches, ", "), r)
+}
+
+// resolveFullRepoBranch returns the full repository branch to use, and the
+// sha of its commit. FullRepoBranch of f can be a comma-separated list of
+// branches, of which the first that exists is used. If none exist, the
+// branch is chosen by pickFullRepoBranch.
+func (f FullPullRequestCreator) resolveFullRepoBranch(r *Repo) (branch, sha string, err error) {
+	var candidates []string
+	for _, candidate := range strings.Split(f.FullRepoBranch, ",") {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+	branch, sha, err = r.FirstExistingBranch(candidates)
+	if !errors.Is(err, ErrRefNotFound) {
+		return branch, sha, err
+	}
+	branch, err = f.pickFullRepoBranch(r)
+	if err != nil {
+		return "", "", err
+	}
+	sha, err = r.GetRef("heads/" + branch)
+	if errors.Is(err, ErrRefNotFound) {
+		return "", "", fmt.Errorf("full repository branch %q does not exist in repository %q", branch, r)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return branch, sha, nil
+}
+
+// pickFullRepoBranch returns the branch to use in place of the full
+// repository branch of f, which does not exist. The BranchPicker of f
+// chooses, or the default branch of the repository is used if f has none.
+func (f FullPullRequestCreator) pickFullRepoBranch(r *Repo) (string, error) {
+	repository, err := r.GetRepository()
+	if err != nil {
+		return "", err
+	}
+	if repository.DefaultBranch == "" {
+		return "", fmt.Errorf("full repository branch %q does not exist in repository %q, which has no default branch", f.FullRepoBranch, r)
+	}
+	if f.BranchPicker == nil {
+		return repository.DefaultBranch, nil
+	}
+	branches, err := r.ListBranches("")
+	if err != nil {
+		return "", err
+	}
+	return f.BranchPicker(r.String(), branches, repository.DefaultBranch)
+}
+
+// promptBranchPicker returns a BranchPicker that asks which branch to use,
+// displaying the branches to output and reading the choice from input.
+func promptBranchPicker(input io.Reader, output io.Writer) BranchPicker {
+	reader := bufio.NewReader(input)
+	return func(repo string, branches []string, defaultBranch string) (string, error) {
+		fmt.Fprintf(output, "The full repository branch does not exist in repository %s. Choose the branch containing all repository content:\n", repo)
+		for i, branch := range branches {
+			marker := ""
+			if branch == defaultBranch {
+				marker = " (default)"
+			}
+			fmt.Fprintf(output, "%3d) %s%s\n", i+1, branch, marker)
+		}
+		for {
+			fmt.Fprintf(output, "Branch number or name [%s]: ", defaultBranch)
+			line, err := reader.ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				return defaultBranch, nil
+			}
+			if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(branches) {
+				return branches[n-1], nil
+			}
+			for _, branch := range branches {
+				if branch == answer {
+					return branch, nil
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("%q is not a branch of repository %s", answer, repo)
+			}
+			fmt.Fprintf(output, "%q is not one of the listed branches.\n", answer)
+		}
+	}
+}
+
+// isTerminal returns true if f is an interactive terminal. The null device
+// is also a character device, so is excluded.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	nullInfo, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, nullInfo)
+}
@@ -0,0 +1,66 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrAPIBudgetExhausted is returned for API requests made after an
+// APICallBudget has been used up.
+var ErrAPIBudgetExhausted = errors.New("the maximum number of Github API calls has been reached")
+
+// APICallBudget limits the number of Github API requests, and can be shared
+// by multiple clients to limit an entire run.
+type APICallBudget struct {
+	mu        sync.Mutex
+	max, used int
+}
+
+// NewAPICallBudget returns a budget allowing max API requests.
+func NewAPICallBudget(max int) (*APICallBudget, error) {
+	if max < 1 {
+		return nil, fmt.Errorf("the maximum number of API calls must be at least 1, not %d", max)
+	}
+	return &APICallBudget{max: max}, nil
+}
+
+// Used returns the number of API requests made using the budget.
+func (b *APICallBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// take uses one API request from the budget, returning
+// ErrAPIBudgetExhausted if none remain.
+func (b *APICallBudget) take() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used >= b.max {
+		return fmt.Errorf("%w (%d)", ErrAPIBudgetExhausted, b.max)
+	}
+	b.used++
+	return nil
+}
+
+// WithAPICallBudget limits API requests made by an instance of the client to
+// the remaining budget b.
+func WithAPICallBudget(b *APICallBudget) ClientOption {
+	return func(c *Client) error {
+		if b == nil {
+			return errors.New("the API call budget cannot be nil")
+		}
+		c.middleware = append(c.middleware, func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				err := b.take()
+				if err != nil {
+					return nil, err
+				}
+				return next.Do(req)
+			})
+		})
+		return nil
+	}
+}
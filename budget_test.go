@@ -0,0 +1,53 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPICallBudgetIsSharedBetweenClients(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	budget, err := prme.NewAPICallBudget(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		c, err := prme.NewClient("dummyToken",
+			prme.WithHTTPClient(ts.Client()),
+			prme.WithAPIHost(ts.URL),
+			prme.WithAPICallBudget(budget),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.MakeAPIRequest(http.MethodGet, "/rate_limit")
+		if err != nil {
+			t.Fatalf("API call %d: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithAPICallBudget(budget),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.MakeAPIRequest(http.MethodGet, "/rate_limit")
+	if !errors.Is(err, prme.ErrAPIBudgetExhausted) {
+		t.Fatalf("want error %v, got %v", prme.ErrAPIBudgetExhausted, err)
+	}
+	if budget.Used() != 2 {
+		t.Fatalf("want 2 API calls used, got %d", budget.Used())
+	}
+}
@@ -0,0 +1,115 @@
+package prme
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// WithCacheDir caches the responses of GET API requests in dir, which is
+// created if it does not exist. Cached responses are revalidated using their
+// ETag, and a Github HTTP 304 (not modified) response does not count against
+// the API rate limit.
+func WithCacheDir(dir string) ClientOption {
+	return func(c *Client) error {
+		if dir == "" {
+			return errors.New("the cache directory cannot be empty")
+		}
+		err := os.MkdirAll(dir, 0o700)
+		if err != nil {
+			return fmt.Errorf("while creating cache directory: %w", err)
+		}
+		c.middleware = append(c.middleware, cacheMiddleware(dir))
+		return nil
+	}
+}
+
+// cachedResponse is an API response stored in the cache directory.
+type cachedResponse struct {
+	URL        string
+	ETag       string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// cacheFileName returns the file that caches the response for req. The
+// authorization header is part of the key so responses are not shared
+// between tokens.
+func cacheFileName(dir string, req *http.Request) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s", req.Header.Get("Authorization"), req.URL)
+	return filepath.Join(dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func cacheMiddleware(dir string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				return next.Do(req)
+			}
+			fileName := cacheFileName(dir, req)
+			var cached *cachedResponse
+			data, err := os.ReadFile(fileName)
+			if err == nil {
+				var cr cachedResponse
+				if json.Unmarshal(data, &cr) == nil && cr.ETag != "" {
+					cached = &cr
+					req.Header.Set("If-None-Match", cr.ETag)
+				}
+			}
+			resp, err := next.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode == http.StatusNotModified && cached != nil {
+				resp.Body.Close()
+				header := cached.Header.Clone()
+				// Keep current rate limit information.
+				for name, values := range resp.Header {
+					header[name] = values
+				}
+				return &http.Response{
+					Status:        http.StatusText(cached.StatusCode),
+					StatusCode:    cached.StatusCode,
+					Proto:         resp.Proto,
+					ProtoMajor:    resp.ProtoMajor,
+					ProtoMinor:    resp.ProtoMinor,
+					Header:        header,
+					Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+					ContentLength: int64(len(cached.Body)),
+					Request:       req,
+				}, nil
+			}
+			ETag := resp.Header.Get("ETag")
+			if resp.StatusCode != http.StatusOK || ETag == "" {
+				return resp, nil
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			data, err = json.Marshal(cachedResponse{
+				URL:        req.URL.String(),
+				ETag:       ETag,
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header,
+				Body:       body,
+			})
+			if err == nil {
+				// Failing to cache should not fail the request.
+				_ = os.WriteFile(fileName, data, 0o600)
+			}
+			return resp, nil
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheDirRevalidatesWithETag(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"abc123"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc123"`)
+		fmt.Fprint(w, `{"full_name":"ivanfetch/ghapitest"}`)
+	}))
+	defer ts.Close()
+
+	cacheDir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		// A new client per request simulates separate invocations.
+		c, err := prme.NewClient("dummyToken",
+			prme.WithHTTPClient(ts.Client()),
+			prme.WithAPIHost(ts.URL),
+			prme.WithCacheDir(cacheDir),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.MakeAPIRequest(http.MethodGet, "/repos/ivanfetch/ghapitest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want HTTP %d for request %d, got %d", http.StatusOK, i+1, resp.StatusCode)
+		}
+		want := `{"full_name":"ivanfetch/ghapitest"}`
+		if want != string(body) {
+			t.Fatalf("want body %q for request %d, got %q", want, i+1, body)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("want 2 requests, 1 of them revalidated, got %d requests and %d revalidated", requests, notModified)
+	}
+}
@@ -0,0 +1,76 @@
+package prme
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrChaos is returned by Create when chaos mode injects a failure, as set
+// by WithChaos.
+var ErrChaos = errors.New("failure injected by chaos mode")
+
+// chaosSteps are the steps of Create at which chaos mode can inject a
+// failure, in the order they run.
+var chaosSteps = []string{
+	"lock",
+	"create-branches",
+	"merge",
+	"create-pull-request",
+	"record-state",
+	"label",
+	"request-reviewers",
+	"commit-status",
+	"commit-comment",
+}
+
+// ChaosSteps returns the steps at which WithChaos can inject failures, in
+// the order they run.
+func ChaosSteps() []string {
+	return append([]string{}, chaosSteps...)
+}
+
+// WithChaos makes Create fail with ErrChaos immediately before each of
+// steps, one of ChaosSteps, such as to exercise how automation recovers from
+// a review that was partially created.
+func WithChaos(steps ...string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.chaos = make(map[string]bool)
+		for _, step := range steps {
+			if !stringsContain(chaosSteps, step) {
+				return fmt.Errorf("unknown chaos step %q, the steps are: %s", step, strings.Join(chaosSteps, ", "))
+			}
+			f.chaos[step] = true
+		}
+		return nil
+	}
+}
+
+// injectChaos returns ErrChaos if chaos mode injects a failure at step.
+func (f FullPullRequestCreator) injectChaos(step string) error {
+	if f.chaos[step] {
+		return fmt.Errorf("%w before the %s step", ErrChaos, step)
+	}
+	return nil
+}
+
+// hiddenFlags are command-line flags that are not displayed in help, as
+// they are only intended for testing.
+var hiddenFlags = map[string]bool{"chaos": true}
+
+// printFlagDefaults displays the default values of the command-line flags of
+// fs, like flag.FlagSet.PrintDefaults, excluding hidden flags.
+func printFlagDefaults(fs *flag.FlagSet, errOutput io.Writer) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(errOutput)
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			// The value may have already been set by parsing.
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
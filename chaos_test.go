@@ -0,0 +1,104 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithChaosRejectsUnknownSteps(t *testing.T) {
+	t.Parallel()
+
+	for _, step := range prme.ChaosSteps() {
+		_, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest", prme.WithChaos(step))
+		if err != nil {
+			t.Errorf("want chaos step %q accepted, got %v", step, err)
+		}
+	}
+	_, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest", prme.WithChaos("merge", "launch-rockets"))
+	if err == nil || !strings.Contains(err.Error(), "launch-rockets") {
+		t.Fatalf("want an error for an unknown chaos step, got %v", err)
+	}
+}
+
+func TestChaosFlagRejectsUnknownSteps(t *testing.T) {
+	t.Setenv("GH_TOKEN", "dummyToken")
+
+	_, err := prme.NewFullPullRequestCreatorFromArgs([]string{"-chaos", "label,launch-rockets", "ivanfetch/ghapitest"}, io.Discard, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "launch-rockets") {
+		t.Fatalf("want an error for an unknown chaos step, got %v", err)
+	}
+	_, err = prme.NewFullPullRequestCreatorFromArgs([]string{"-chaos", "label,record-state", "ivanfetch/ghapitest"}, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateRecordsThePullRequestBeforeLaterFailures(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("PRME_STATE_KEY", "")
+	t.Setenv("PRME_STATE_KEYCHAIN", "")
+	t.Setenv("GIT_AUTHOR_NAME", "prme")
+	t.Setenv("GIT_AUTHOR_EMAIL", "prme@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "prme")
+	t.Setenv("GIT_COMMITTER_EMAIL", "prme@example.com")
+	// Clone and push the review branches to a local repository instead of
+	// Github.
+	remote := filepath.Join(t.TempDir(), "ghapitest.git")
+	_, err := prme.RunGitCommand("", "init", "--bare", remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "url."+remote+".insteadOf")
+	t.Setenv("GIT_CONFIG_VALUE_0", "ssh://git@github.com/ivanfetch/ghapitest")
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", prme.FakeResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Oauth-Scopes": {"repo"}},
+		Body:       `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`,
+	})
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c", `{"sha":"t1","tree":[{"path":"README.md","mode":"100644","type":"blob","sha":"b1","size":10}]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/git/tags", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha":"a1"}`})
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/git/refs", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{}`})
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/merges", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha":"d4"}`})
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/pulls", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"number":1,"html_url":"https://github.com/ivanfetch/ghapitest/pull/1","state":"open"}`})
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+		prme.WithChaos("label"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SkipOrgConfig = true
+	f.StateFile = filepath.Join(t.TempDir(), "state.json")
+	PR, err := f.CreateWithResult()
+	if !errors.Is(err, prme.ErrChaos) {
+		t.Fatalf("want error %v, got %v", prme.ErrChaos, err)
+	}
+	if PR == nil || PR.Number != 1 {
+		t.Fatalf("want the created pull request returned with the error, got %+v", PR)
+	}
+	store, err := prme.NewStateStore(f.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Reviews) != 1 || st.Reviews[0].URL != PR.HTMLURL {
+		t.Fatalf("want the pull request recorded in the state store, got %+v", st.Reviews)
+	}
+}
@@ -0,0 +1,139 @@
+package prme
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DeleteReviewBranches deletes the base and head branches of a full review,
+// ignoring either that does not exist. As a safety net against deleting
+// real development branches, nothing is deleted unless each branch begins
+// with an empty-tree commit, and both branches begin with the same one,
+// which prme creates for the pair.
+func (r Repo) DeleteReviewBranches(baseBranch, headBranch string) error {
+	var rootSha string
+	var existing []string
+	for _, branch := range []string{baseBranch, headBranch} {
+		root, err := r.OrphanRoot(branch)
+		if errors.Is(err, ErrRefNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: unable to verify the history of branch %q in repository %q: %v", ErrNotPrmeBranch, branch, r, err)
+		}
+		err = r.checkPrmeRoot(branch, root)
+		if err != nil {
+			return err
+		}
+		if rootSha != "" && root.Sha != rootSha {
+			return fmt.Errorf("%w: branches %q and %q in repository %q begin with different empty commits, %s and %s, so were not created together by prme", ErrNotPrmeBranch, baseBranch, headBranch, r, rootSha, root.Sha)
+		}
+		rootSha = root.Sha
+		existing = append(existing, branch)
+	}
+	for _, branch := range existing {
+		err := r.DeleteRef("heads/" + branch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cleanup removes a full review created by prme, such as after trying prme
+// or in tests: the open pull request from the head to the base branch is
+// closed, and both branches are deleted, as by DeleteReviewBranches. The
+// closed pull request is returned, or nil if there was no open pull
+// request. If a StateFile is set, the review is recorded as closed.
+func (f FullPullRequestCreator) Cleanup() (*PullRequest, error) {
+	r, err := NewRepo(f.Repo, f.token, f.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	PR, err := r.FindOpenPullRequest(f.BaseBranch, f.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	if PR != nil {
+		f.progress("closing pull request %s", PR.HTMLURL)
+		err = r.ClosePullRequest(PR.Number)
+		if err != nil {
+			return nil, err
+		}
+		PR.State = "closed"
+		if f.StateFile != "" {
+			store, err := NewStateStore(f.StateFile)
+			if err != nil {
+				return nil, err
+			}
+			err = store.setReviewState(r.String(), PR.Number, PR.State)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	f.progress("deleting branches %s and %s", f.BaseBranch, f.HeadBranch)
+	err = r.DeleteReviewBranches(f.BaseBranch, f.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	return PR, nil
+}
+
+// runCleanupCommand closes the review of a repository and deletes its
+// branches.
+func runCleanupCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme cleanup", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand removes a full review created by prme, closing its pull request and deleting its base and head branches. Only branches whose history begins with an empty commit created by prme are deleted.
+
+Usage: %s [flags] <repository owner>/<repository name>
+
+The flags are the same as those used to create full reviews, so the branches are named the same way.
+
+Available command-line flags:
+`,
+			fs.Name())
+		printFlagDefaults(fs, errOutput)
+	}
+	CLIOwner := addOwnerFlag(fs)
+	applyCreatorFlags, err := addCreatorFlags(fs, output)
+	if err != nil {
+		return err
+	}
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one repository, in the form OwnerName/RepositoryName")
+	}
+	f, err := NewFullPullRequestCreator(qualifyRepoName(fs.Arg(0), *CLIOwner))
+	if err != nil {
+		return err
+	}
+	f.token = os.Getenv("GH_TOKEN")
+	err = applyCreatorFlags(f)
+	if err != nil {
+		return err
+	}
+	if f.token == "" {
+		return errors.New("Please set the GH_TOKEN environment variable to a Github personal access token.")
+	}
+	PR, err := f.Cleanup()
+	if err != nil {
+		return err
+	}
+	if PR != nil {
+		fmt.Fprintf(output, "Closed the pull request %s and deleted the branches %s and %s of %s\n", PR.HTMLURL, f.BaseBranch, f.HeadBranch, f.Repo)
+		return nil
+	}
+	fmt.Fprintf(output, "There was no open pull request from %s to %s, deleted any of those branches of %s\n", f.HeadBranch, f.BaseBranch, f.Repo)
+	return nil
+}
@@ -0,0 +1,68 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanup(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[
+		{"number": 7, "state": "open", "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "base": {"ref": "prme-full-review"}, "head": {"ref": "prme-full-content"}}
+	]`)
+	fc.SetJSONResponse(http.MethodPatch, "/repos/ivanfetch/ghapitest/pulls/7", `{"number": 7, "state": "closed"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/prme-full-review", `{"ref": "refs/heads/prme-full-review", "object": {"sha": "e1"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/prme-full-content", `{"ref": "refs/heads/prme-full-content", "object": {"sha": "h1"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/commits/e1", `{"sha": "e1", "tree": {"sha": "`+prme.EmptyTreeSha+`"}, "parents": []}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/commits/h1", `{"sha": "h1", "tree": {"sha": "th1"}, "parents": [{"sha": "e1"}]}`)
+	fc.SetResponse(http.MethodDelete, "/repos/ivanfetch/ghapitest/git/refs/heads/prme-full-review", prme.FakeResponse{StatusCode: http.StatusNoContent})
+	fc.SetResponse(http.MethodDelete, "/repos/ivanfetch/ghapitest/git/refs/heads/prme-full-content", prme.FakeResponse{StatusCode: http.StatusNoContent})
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	store, err := prme.NewStateStore(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AddReview(prme.ReviewRecord{Repo: "ivanfetch/ghapitest", Number: 7, State: "open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.StateFile = stateFile
+	PR, err := f.Cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PR == nil || PR.Number != 7 || PR.State != "closed" {
+		t.Fatalf("want pull request 7 to be closed, got %+v", PR)
+	}
+	var deleted []string
+	for _, req := range fc.Requests() {
+		if req.Method == http.MethodDelete {
+			deleted = append(deleted, req.URI)
+		}
+	}
+	if len(deleted) != 2 {
+		t.Errorf("want both review branches deleted, got %v", deleted)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Reviews[0].State != "closed" {
+		t.Errorf("want the recorded review to be closed, got %q", st.Reviews[0].State)
+	}
+}
@@ -0,0 +1,131 @@
+package prme
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and sleeps, so tests can control the passage of time.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the Clock of the operating system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// ManualClock is a Clock for tests, whose time only changes when advanced.
+// Sleeping advances the clock instead of waiting. It is safe for concurrent
+// use.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a ManualClock set to now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (mc *ManualClock) Now() time.Time {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.now
+}
+
+func (mc *ManualClock) Sleep(d time.Duration) {
+	mc.Advance(d)
+}
+
+// Advance moves the clock forward by d.
+func (mc *ManualClock) Advance(d time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.now = mc.now.Add(d)
+}
+
+// WithClientClock sets the clock used by the client, such as while waiting
+// for rate limits to reset.
+func WithClientClock(clock Clock) ClientOption {
+	return func(c *Client) error {
+		if clock == nil {
+			return errors.New("the clock cannot be nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
+
+// WithClock sets the clock used by f and its Github API client, such as to
+// pause batches, record when reviews are created, schedule prme serve jobs,
+// and wait for rate limits to reset.
+func WithClock(clock Clock) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if clock == nil {
+			return errors.New("the clock cannot be nil")
+		}
+		f.clock = clock
+		f.clientOptions = append(f.clientOptions, WithClientClock(clock))
+		return nil
+	}
+}
+
+// WithRandSource sets the source of randomness, such as for the jitter of
+// retries by prme serve, so it is reproducible.
+func WithRandSource(src rand.Source) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if src == nil {
+			return errors.New("the random source cannot be nil")
+		}
+		f.rand = &lockedRand{rand: rand.New(src)}
+		return nil
+	}
+}
+
+// lockedRand is a random number generator that is safe for concurrent use.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (lr *lockedRand) Float64() float64 {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.rand.Float64()
+}
+
+// now returns the current time of the clock of f.
+func (f FullPullRequestCreator) now() time.Time {
+	if f.clock == nil {
+		return time.Now()
+	}
+	return f.clock.Now()
+}
+
+// sleep pauses for d, using the clock of f.
+func (f FullPullRequestCreator) sleep(d time.Duration) {
+	if f.clock == nil {
+		time.Sleep(d)
+		return
+	}
+	f.clock.Sleep(d)
+}
+
+// randFloat64 returns a random number in [0.0,1.0), using the random source
+// of f.
+func (f FullPullRequestCreator) randFloat64() float64 {
+	if f.rand == nil {
+		return rand.Float64()
+	}
+	return f.rand.Float64()
+}
@@ -0,0 +1,64 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	clock := prme.NewManualClock(start)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("want time %v, got %v", start, clock.Now())
+	}
+	clock.Sleep(time.Minute)
+	clock.Advance(time.Hour)
+	want := start.Add(time.Hour + time.Minute)
+	if !clock.Now().Equal(want) {
+		t.Fatalf("want time %v after sleeping and advancing, got %v", want, clock.Now())
+	}
+}
+
+func TestMakeAPIRequestWithRateLimitUsesClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	clock := prme.NewManualClock(start)
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(start.Add(90*time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken", prme.WithAPIHost(ts.URL), prme.WithClientClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.MakeAPIRequestWithRateLimit(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Fatalf("want the request retried once the rate limit reset, got HTTP %d after %d requests", resp.StatusCode, requests)
+	}
+	want := start.Add(90 * time.Second)
+	if !clock.Now().Equal(want) {
+		t.Fatalf("want the clock advanced to the rate limit reset at %v, got %v", want, clock.Now())
+	}
+}
@@ -2,8 +2,9 @@ package main
 
 import (
 	"github.com/ivanfetch/prme"
+	"os"
 )
 
 func main() {
-	prme.RunCLI()
+	os.Exit(prme.RunCLI(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,183 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// User is a Github user account.
+type User struct {
+	Login string `json:"login"`
+}
+
+// IssueComment is a comment on an issue or the conversation of a pull
+// request.
+type IssueComment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	HTMLURL   string    `json:"html_url"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewComment is a comment on a line of a file in a pull request.
+type ReviewComment struct {
+	ID       int64  `json:"id,omitempty"`
+	Body     string `json:"body"`
+	CommitID string `json:"commit_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	// Side is LEFT or RIGHT, the side of the diff the comment applies to.
+	Side      string    `json:"side,omitempty"`
+	InReplyTo int64     `json:"in_reply_to_id,omitempty"`
+	HTMLURL   string    `json:"html_url,omitempty"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateIssueComment adds a comment to the conversation of the issue or pull
+// request number.
+func (r Repo) CreateIssueComment(number int, body string) (*IssueComment, error) {
+	if body == "" {
+		return nil, errors.New("the comment body cannot be empty")
+	}
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d/comments", r, number)
+	commentJSON, err := json.Marshal(struct {
+		Body string `json:"body"`
+	}{
+		Body: body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, commentJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while commenting on issue %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	var comment IssueComment
+	err = json.NewDecoder(resp.Body).Decode(&comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListReviewComments returns all review comments of the pull request number.
+func (r Repo) ListReviewComments(number int) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d/comments", r, number)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []ReviewComment
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing review comments for pull request %d in repository %q: %w", number, r, err)
+	}
+	return comments, nil
+}
+
+// CreateReviewComment adds comment to a line of a file in the pull request
+// number. The Body, CommitID, and Path fields of comment are required.
+func (r Repo) CreateReviewComment(number int, comment ReviewComment) (*ReviewComment, error) {
+	if comment.Body == "" || comment.CommitID == "" || comment.Path == "" {
+		return nil, errors.New("the body, commit ID, and path of a review comment cannot be empty")
+	}
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d/comments", r, number)
+	commentJSON, err := json.Marshal(struct {
+		Body      string `json:"body"`
+		CommitID  string `json:"commit_id"`
+		Path      string `json:"path"`
+		Line      int    `json:"line,omitempty"`
+		Side      string `json:"side,omitempty"`
+		InReplyTo int64  `json:"in_reply_to,omitempty"`
+	}{
+		Body:      comment.Body,
+		CommitID:  comment.CommitID,
+		Path:      comment.Path,
+		Line:      comment.Line,
+		Side:      comment.Side,
+		InReplyTo: comment.InReplyTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, commentJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while creating a review comment on %q in pull request %d in repository %q", resp.StatusCode, apiURI, comment.Path, number, r)
+	}
+	var created ReviewComment
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListIssueComments returns all comments in the conversation of the issue or
+// pull request number.
+func (r Repo) ListIssueComments(number int) ([]IssueComment, error) {
+	var comments []IssueComment
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d/comments", r, number)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []IssueComment
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing comments for issue %d in repository %q: %w", number, r, err)
+	}
+	return comments, nil
+}
+
+// Review is a review submitted on a pull request.
+type Review struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	// State is one of APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED, or
+	// PENDING.
+	State       string    `json:"state"`
+	CommitID    string    `json:"commit_id"`
+	HTMLURL     string    `json:"html_url"`
+	User        User      `json:"user"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// ListReviews returns all reviews of the pull request number.
+func (r Repo) ListReviews(number int) ([]Review, error) {
+	var reviews []Review
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d/reviews", r, number)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []Review
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		reviews = append(reviews, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing reviews for pull request %d in repository %q: %w", number, r, err)
+	}
+	return reviews, nil
+}
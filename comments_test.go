@@ -0,0 +1,163 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateIssueComment(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestCreateIssueComment.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/issues/7/comments"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w.WriteHeader(http.StatusCreated)
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.CreateIssueComment(7, "Please review every file.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://github.com/ivanfetch/ghapitest/pull/7#issuecomment-903164022"
+	if want != got.HTMLURL {
+		t.Fatalf("want comment URL %q, got %q", want, got.HTMLURL)
+	}
+}
+
+func TestListReviewComments(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestListReviewComments.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls/7/comments?per_page=100"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := r.ListReviewComments(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range comments {
+		got = append(got, c.User.Login+":"+c.Path)
+	}
+	want := []string{"reviewer1:README.md", "reviewer2:docs/usage.md"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect review comments\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestCreateReviewComment(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestCreateReviewComment.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls/7/comments"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		var gotBody map[string]interface{}
+		err := json.NewDecoder(r.Body).Decode(&gotBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantBody := map[string]interface{}{
+			"body":      "Reviewed.",
+			"commit_id": "c98eeaa40364b0486743720e8e7340e52fd98c46",
+			"path":      "README.md",
+			"line":      float64(1),
+			"side":      "RIGHT",
+		}
+		if !cmp.Equal(wantBody, gotBody) {
+			t.Errorf("got incorrect request body\ndiff reflects want vs. got: %s", cmp.Diff(wantBody, gotBody))
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w.WriteHeader(http.StatusCreated)
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.CreateReviewComment(7, prme.ReviewComment{
+		Body:     "Reviewed.",
+		CommitID: "c98eeaa40364b0486743720e8e7340e52fd98c46",
+		Path:     "README.md",
+		Line:     1,
+		Side:     "RIGHT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want int64 = 693371033
+	if want != got.ID {
+		t.Fatalf("want review comment ID %d, got %d", want, got.ID)
+	}
+}
@@ -0,0 +1,124 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrMergeConflict is returned by MergeBranch when the branches cannot be
+// merged without resolving conflicts.
+var ErrMergeConflict = errors.New("the branches have merge conflicts")
+
+// conflictBranchSuffix is appended to the head branch of a review to name
+// the branch to which its refresh conflicts are pushed.
+const conflictBranchSuffix = "-conflicts"
+
+// RefreshConflict is a refresh of a review that could not be merged
+// automatically.
+type RefreshConflict struct {
+	// Branch is the merge of the full repository branch into the review
+	// head branch, committed including conflict markers.
+	Branch string
+	// Files are the paths that have conflicts.
+	Files []string
+}
+
+// PushConflictBranch merges fullRepoBranch into the review headBranch in a
+// temporary clone, commits the merge including its conflict markers, and
+// pushes that to a new branch named after headBranch, where the conflicts
+// can be resolved before the branch is merged into the review.
+func (r Repo) PushConflictBranch(headBranch, fullRepoBranch string) (*RefreshConflict, error) {
+	conflictBranch := headBranch + conflictBranchSuffix
+	exists, err := r.BranchExists(conflictBranch)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("the branch %q in repository %q already exists, please resolve the conflicts there and merge it into %q, or delete it", conflictBranch, r, headBranch)
+	}
+	tempDir, err := os.MkdirTemp("", "pr-me-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+	tempDirWithRepo := tempDir + "/" + r.String()
+	_, err = r.runGitCommand(tempDir, "clone", "--branch", headBranch, r.CloneURL(), r.String())
+	if err != nil {
+		return nil, err
+	}
+	_, mergeErr := RunGitCommand(tempDirWithRepo, "merge", "--no-ff", "--no-commit", "origin/"+fullRepoBranch)
+	conflicted, err := RunGitCommand(tempDirWithRepo, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if conflicted == "" {
+		if mergeErr != nil {
+			return nil, mergeErr
+		}
+		return nil, fmt.Errorf("%q merges into %q without conflicts in repository %q", fullRepoBranch, headBranch, r)
+	}
+	_, err = RunGitCommand(tempDirWithRepo, "add", "--all")
+	if err != nil {
+		return nil, err
+	}
+	_, err = RunGitCommand(tempDirWithRepo, "commit", "--no-verify", "-m", fmt.Sprintf("Refresh the full review with changes from %s, including conflicts", fullRepoBranch))
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.runGitCommand(tempDirWithRepo, "push", "origin", "HEAD:refs/heads/"+conflictBranch)
+	if err != nil {
+		return nil, explainPushError(r.String(), err)
+	}
+	return &RefreshConflict{
+		Branch: conflictBranch,
+		Files:  strings.Split(conflicted, "\n"),
+	}, nil
+}
+
+// compareURL returns the URL comparing head to base in the repository of
+// the pull request PRURL.
+func compareURL(PRURL, base, head string) string {
+	repoURL := PRURL
+	if i := strings.LastIndex(PRURL, "/pull/"); i >= 0 {
+		repoURL = PRURL[:i]
+	}
+	return fmt.Sprintf("%s/compare/%s...%s", repoURL, base, head)
+}
+
+// ConflictComment returns a markdown comment for the review pull request
+// PRURL, explaining how to resolve conflicts c from refreshing headBranch
+// with changes from fullRepoBranch.
+func ConflictComment(PRURL, headBranch, fullRepoBranch string, c RefreshConflict) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Refreshing this review with changes from `%s` has merge conflicts in these files:\n\n", fullRepoBranch)
+	for _, file := range c.Files {
+		fmt.Fprintf(&b, "* `%s`\n", file)
+	}
+	fmt.Fprintf(&b, "\nThe merge, including conflict markers, has been pushed to the branch `%s`. [View its changes to this review](%s).\n\n", c.Branch, compareURL(PRURL, headBranch, c.Branch))
+	b.WriteString("To resolve the conflicts:\n\n```\n")
+	fmt.Fprintf(&b, "git fetch origin %s\n", c.Branch)
+	fmt.Fprintf(&b, "git checkout %s\n", c.Branch)
+	b.WriteString("# Edit the files above to resolve their conflict markers.\n")
+	b.WriteString("git commit -a -m 'Resolve conflicts refreshing the review'\n")
+	fmt.Fprintf(&b, "git push origin %s\n", c.Branch)
+	b.WriteString("```\n\n")
+	fmt.Fprintf(&b, "Then merge `%s` into `%s` to complete the refresh, and delete it.\n", c.Branch, headBranch)
+	return b.String()
+}
+
+// HandleRefreshConflict pushes the conflicts of refreshing review to a
+// branch, using PushConflictBranch, and comments on its pull request with
+// instructions to resolve them.
+func (r Repo) HandleRefreshConflict(review ReviewRecord) (*RefreshConflict, error) {
+	c, err := r.PushConflictBranch(review.HeadBranch, review.FullRepoBranch)
+	if err != nil {
+		return nil, fmt.Errorf("while pushing the conflicts of refreshing %s: %w", review.URL, err)
+	}
+	_, err = r.CreateIssueComment(review.Number, ConflictComment(review.URL, review.HeadBranch, review.FullRepoBranch, *c))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,56 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestMergeBranchReturnsErrMergeConflict(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/merges", prme.FakeResponse{
+		StatusCode: http.StatusConflict,
+		Body:       `{"message": "Merge conflict"}`,
+	})
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RefreshReview("prme-full-content", "main")
+	if !errors.Is(err, prme.ErrMergeConflict) {
+		t.Fatalf("want error %v, got %v", prme.ErrMergeConflict, err)
+	}
+}
+
+func TestConflictComment(t *testing.T) {
+	t.Parallel()
+
+	c := prme.RefreshConflict{
+		Branch: "prme-full-content-conflicts",
+		Files:  []string{"main.go", "docs/README.md"},
+	}
+	got := prme.ConflictComment("https://github.com/owner/repo/pull/7", "prme-full-content", "main", c)
+	want := "Refreshing this review with changes from `main` has merge conflicts in these files:\n\n" +
+		"* `main.go`\n" +
+		"* `docs/README.md`\n\n" +
+		"The merge, including conflict markers, has been pushed to the branch `prme-full-content-conflicts`. [View its changes to this review](https://github.com/owner/repo/compare/prme-full-content...prme-full-content-conflicts).\n\n" +
+		"To resolve the conflicts:\n\n" +
+		"```\n" +
+		"git fetch origin prme-full-content-conflicts\n" +
+		"git checkout prme-full-content-conflicts\n" +
+		"# Edit the files above to resolve their conflict markers.\n" +
+		"git commit -a -m 'Resolve conflicts refreshing the review'\n" +
+		"git push origin prme-full-content-conflicts\n" +
+		"```\n\n" +
+		"Then merge `prme-full-content-conflicts` into `prme-full-content` to complete the refresh, and delete it.\n"
+	if want != got {
+		t.Fatalf("got incorrect comment\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
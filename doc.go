@@ -0,0 +1,22 @@
+// Package prme creates a Github pull request that reviews all content of a
+// repository, by merging it into an empty branch, and manages those reviews
+// once they are created.
+//
+// The supported API is:
+//
+//   - FullPullRequestCreator, created by NewFullPullRequestCreator with
+//     FullPullRequestCreatorOption functions such as WithToken, whose Create
+//     and Plan methods create and plan reviews. WithClientOptions passes
+//     ClientOption functions to its Github API client.
+//   - Repo, created by NewRepo with ClientOption functions, whose methods
+//     use the Github API for a repository.
+//   - AzureRepo, created by NewAzureRepo, and the CreateAzure method of
+//     FullPullRequestCreator, for Azure DevOps Repos.
+//   - Finalizer, StateStore, and the other types used by the prme
+//     subcommands.
+//   - FakeClient and the APIClient interface, for testing programs that use
+//     prme.
+//
+// Functions whose names begin with Run, such as RunCLI, implement the prme
+// command and may change along with its command-line flags.
+package prme
@@ -0,0 +1,92 @@
+package prme
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultServiceRetryAfter is how long to wait after Github maintenance or
+// an abuse rate limit, when Github does not specify a Retry-After header.
+const defaultServiceRetryAfter = time.Minute
+
+// AbuseRateLimitError is returned when Github abuse detection, also called
+// the secondary rate limit, rejects an API request.
+type AbuseRateLimitError struct {
+	URI        string
+	RetryAfter time.Duration
+}
+
+func (e *AbuseRateLimitError) Error() string {
+	return fmt.Sprintf("Github abuse detection (the secondary rate limit) rejected %s, retry after %v", e.URI, e.RetryAfter)
+}
+
+// MaintenanceError is returned when Github is unavailable, typically due to
+// maintenance.
+type MaintenanceError struct {
+	URI        string
+	RetryAfter time.Duration
+}
+
+func (e *MaintenanceError) Error() string {
+	return fmt.Sprintf("Github is unavailable (HTTP %d) for %s, retry after %v", http.StatusServiceUnavailable, e.URI, e.RetryAfter)
+}
+
+// RateLimitError is returned when the Github primary rate limit has been
+// exceeded, and will not reset soon enough to wait for it.
+type RateLimitError struct {
+	URI        string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("the Github rate limit for %s will not reset for %v", e.URI, e.RetryAfter.Round(time.Second))
+}
+
+// ResponseTooLargeError is returned when a Github API response is larger
+// than the limit of the client, such as a huge error page from a
+// misbehaving proxy.
+type ResponseTooLargeError struct {
+	URI   string
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("the response to %s is larger than the limit of %d bytes", e.URI, e.Limit)
+}
+
+// retryAfter returns the duration of the Retry-After header of resp, or
+// defaultServiceRetryAfter.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return defaultServiceRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// serviceError returns an AbuseRateLimitError or MaintenanceError if resp
+// indicates one, closing the body of resp. Otherwise nil is returned and the
+// body of resp remains readable.
+func serviceError(URI string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusServiceUnavailable:
+		resp.Body.Close()
+		return &MaintenanceError{URI: URI, RetryAfter: retryAfter(resp)}
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		lowerBody := strings.ToLower(string(body))
+		if strings.Contains(lowerBody, "secondary rate limit") || strings.Contains(lowerBody, "abuse detection") {
+			return &AbuseRateLimitError{URI: URI, RetryAfter: retryAfter(resp)}
+		}
+		resp.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package prme
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Archive formats of an exported review.
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// ExportManifestName is the file in an exported review archive containing
+// its ExportManifest.
+const ExportManifestName = "prme-review-manifest.json"
+
+// ExportManifest describes an exported review, alongside the reviewed
+// content in the archive.
+type ExportManifest struct {
+	Repo        string      `json:"repo"`
+	PullRequest PullRequest `json:"pull_request"`
+	// ReviewedSha is the commit whose content is in the archive.
+	ReviewedSha    string          `json:"reviewed_sha"`
+	ExportedAt     time.Time       `json:"exported_at"`
+	Participants   []string        `json:"participants"`
+	Reviews        []Review        `json:"reviews"`
+	Comments       []IssueComment  `json:"comments"`
+	ReviewComments []ReviewComment `json:"review_comments"`
+}
+
+// ParsePullRequestURL returns the repository, in the form owner/name, and
+// the number of a pull request URL such as
+// https://github.com/owner/name/pull/7.
+func ParsePullRequestURL(PRURL string) (repo string, number int, err error) {
+	u, err := url.Parse(PRURL)
+	if err != nil {
+		return "", 0, err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] != "pull" {
+		return "", 0, fmt.Errorf("%q is not a pull request URL, such as https://github.com/owner/name/pull/7", PRURL)
+	}
+	number, err = strconv.Atoi(parts[3])
+	if err != nil || number < 1 {
+		return "", 0, fmt.Errorf("invalid pull request number %q in URL %q", parts[3], PRURL)
+	}
+	return parts[0] + "/" + parts[1], number, nil
+}
+
+// downloadArchive writes the Github archive, in format, of the repository
+// content at the commit sha to w.
+func (r Repo) downloadArchive(format, sha string, w io.Writer) error {
+	archiveType := "tarball"
+	if format == ArchiveFormatZip {
+		archiveType = "zipball"
+	}
+	apiURI := fmt.Sprintf("/repos/%s/%s/%s", r, archiveType, sha)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while downloading commit %q of repository %q", resp.StatusCode, apiURI, sha, r)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// ExportReview writes an archive, in format, of the content reviewed in
+// pull request number, and an ExportManifest of its review comments and
+// participants, for keeping audit evidence outside of Github.
+func (r Repo) ExportReview(number int, format string, w io.Writer) error {
+	if format != ArchiveFormatTarGz && format != ArchiveFormatZip {
+		return fmt.Errorf("unsupported archive format %q, use %s or %s", format, ArchiveFormatTarGz, ArchiveFormatZip)
+	}
+	PR, err := r.GetPullRequest(number)
+	if err != nil {
+		return err
+	}
+	manifest := ExportManifest{
+		Repo:        r.String(),
+		PullRequest: *PR,
+		ReviewedSha: PR.Head.Sha,
+		ExportedAt:  time.Now().UTC(),
+	}
+	manifest.Reviews, err = r.ListReviews(number)
+	if err != nil {
+		return err
+	}
+	manifest.Comments, err = r.ListIssueComments(number)
+	if err != nil {
+		return err
+	}
+	manifest.ReviewComments, err = r.ListReviewComments(number)
+	if err != nil {
+		return err
+	}
+	manifest.Participants = participants(*PR, manifest.Reviews, manifest.Comments, manifest.ReviewComments)
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	err = r.downloadArchive(format, PR.Head.Sha, &content)
+	if err != nil {
+		return err
+	}
+	if format == ArchiveFormatZip {
+		return addToZip(content.Bytes(), ExportManifestName, manifestJSON, w)
+	}
+	return addToTarGz(&content, ExportManifestName, manifestJSON, w)
+}
+
+// participants returns the sorted, unique logins of the author, reviewers,
+// and commenters of a pull request.
+func participants(PR PullRequest, reviews []Review, comments []IssueComment, reviewComments []ReviewComment) []string {
+	logins := map[string]bool{PR.User.Login: true}
+	for _, review := range reviews {
+		logins[review.User.Login] = true
+	}
+	for _, comment := range comments {
+		logins[comment.User.Login] = true
+	}
+	for _, comment := range reviewComments {
+		logins[comment.User.Login] = true
+	}
+	delete(logins, "")
+	sorted := make([]string, 0, len(logins))
+	for login := range logins {
+		sorted = append(sorted, login)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// addToTarGz writes the gzipped tar archive from archive to w, with an
+// additional file name containing content.
+func addToTarGz(archive io.Reader, name string, content []byte, w io.Writer) error {
+	gr, err := gzip.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("while reading the repository archive: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("while reading the repository archive: %w", err)
+		}
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, tr)
+		if err != nil {
+			return err
+		}
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(content)
+	if err != nil {
+		return err
+	}
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// addToZip writes the zip archive to w, with an additional file name
+// containing content.
+func addToZip(archive []byte, name string, content []byte, w io.Writer) error {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return fmt.Errorf("while reading the repository archive: %w", err)
+	}
+	zw := zip.NewWriter(w)
+	for _, f := range zr.File {
+		err = zw.Copy(f)
+		if err != nil {
+			return err
+		}
+	}
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// runExportCommand exports the content and comments of a review to an
+// archive.
+func runExportCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme export", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand exports the content reviewed in a pull request to an archive, including a %s file of its review comments and participants.
+
+Usage: %s [flags] <pull request URL>
+
+Available command-line flags:
+`,
+			ExportManifestName, fs.Name())
+		fs.PrintDefaults()
+	}
+	CLIFormat := fs.String("format", ArchiveFormatTarGz, fmt.Sprintf("The archive format, either %s or %s. This is also set via the PRME_FORMAT environment variable.", ArchiveFormatTarGz, ArchiveFormatZip))
+	CLIOutputFile := fs.String("o", "", "The archive file to write. By default, this is <repository name>-review-<pull request number>.<format>.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one pull request URL")
+	}
+	repoName, number, err := ParsePullRequestURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	r, err := NewRepo(repoName, token, clientOptions...)
+	if err != nil {
+		return err
+	}
+	outputFile := *CLIOutputFile
+	if outputFile == "" {
+		outputFile = fmt.Sprintf("%s-review-%d.%s", strings.SplitN(repoName, "/", 2)[1], number, *CLIFormat)
+	}
+	var archive bytes.Buffer
+	err = r.ExportReview(number, *CLIFormat, &archive)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(outputFile, archive.Bytes(), 0o644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "The review %s has been exported to %s\n", fs.Arg(0), outputFile)
+	return nil
+}
@@ -0,0 +1,124 @@
+package prme_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePullRequestURL(t *testing.T) {
+	t.Parallel()
+
+	repo, number, err := prme.ParsePullRequestURL("https://github.com/ivanfetch/ghapitest/pull/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo != "ivanfetch/ghapitest" || number != 7 {
+		t.Fatalf("want ivanfetch/ghapitest and 7, got %q and %d", repo, number)
+	}
+	for _, URL := range []string{
+		"https://github.com/ivanfetch/ghapitest",
+		"https://github.com/ivanfetch/ghapitest/issues/7",
+		"https://github.com/ivanfetch/ghapitest/pull/seven",
+	} {
+		_, _, err = prme.ParsePullRequestURL(URL)
+		if err == nil {
+			t.Errorf("want an error for URL %q", URL)
+		}
+	}
+}
+
+func TestExportReview(t *testing.T) {
+	t.Parallel()
+
+	var tarball bytes.Buffer
+	gw := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gw)
+	err := tw.WriteHeader(&tar.Header{Name: "ghapitest-05db72c/README.md", Mode: 0o644, Size: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tw.Write([]byte("hello\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gw.Close()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/ivanfetch/ghapitest/pulls/7":
+			fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "user": {"login": "ivanfetch"}, "head": {"ref": "review", "sha": "05db72cfac1ee0eef0ceb72193f648f229d6fcc3"}}`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/reviews":
+			fmt.Fprint(w, `[{"id": 1, "state": "APPROVED", "user": {"login": "octocat"}}]`)
+		case "/repos/ivanfetch/ghapitest/issues/7/comments":
+			fmt.Fprint(w, `[{"id": 2, "body": "Looks good", "user": {"login": "hubot"}}]`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/comments":
+			fmt.Fprint(w, `[{"id": 3, "body": "Typo", "path": "README.md", "user": {"login": "octocat"}}]`)
+		case "/repos/ivanfetch/ghapitest/tarball/05db72cfac1ee0eef0ceb72193f648f229d6fcc3":
+			w.Write(tarball.Bytes())
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	err = r.ExportReview(7, prme.ArchiveFormatTarGz, &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gr, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var gotNames []string
+	var manifest prme.ExportManifest
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotNames = append(gotNames, hdr.Name)
+		if hdr.Name == prme.ExportManifestName {
+			err = json.NewDecoder(tr).Decode(&manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	wantNames := []string{"ghapitest-05db72c/README.md", prme.ExportManifestName}
+	if !cmp.Equal(wantNames, gotNames) {
+		t.Fatalf("got incorrect archive files\ndiff reflects want vs. got: %s", cmp.Diff(wantNames, gotNames))
+	}
+	wantParticipants := []string{"hubot", "ivanfetch", "octocat"}
+	if !cmp.Equal(wantParticipants, manifest.Participants) {
+		t.Errorf("got incorrect participants\ndiff reflects want vs. got: %s", cmp.Diff(wantParticipants, manifest.Participants))
+	}
+	if manifest.ReviewedSha != "05db72cfac1ee0eef0ceb72193f648f229d6fcc3" || len(manifest.Reviews) != 1 || len(manifest.Comments) != 1 || len(manifest.ReviewComments) != 1 {
+		t.Errorf("got incorrect manifest %+v", manifest)
+	}
+}
@@ -0,0 +1,170 @@
+package prme
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APIClient is the behavior of Client, so code using prme can substitute
+// FakeClient in tests.
+type APIClient interface {
+	Do(method, URI string, body []byte) (*Response, error)
+	MakeAPIRequest(method, URI string) (*http.Response, error)
+	MakeAPIRequestWithData(method, URI string, body []byte) (*http.Response, error)
+	MakeAPIRequestWithRateLimit(method, URI string) (*http.Response, error)
+	RateLimit() RateLimit
+	CheckToken() error
+	AuthenticatedUser() (*User, error)
+	ListOwnerRepositories(owner string) ([]Repository, error)
+	SearchIssues(query string) ([]Issue, error)
+	SearchRepositories(query string) ([]Repository, error)
+}
+
+var _ APIClient = (*Client)(nil)
+
+// FakeResponse is a response returned by FakeClient.
+type FakeResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// FakeRequest is a request received by FakeClient.
+type FakeRequest struct {
+	Method, URI string
+	Body        []byte
+}
+
+// FakeClient is an in-memory Client for tests, which returns the responses
+// set for each endpoint instead of making Github API requests. Endpoints
+// without a response return HTTP 404, like the Github API. Responses pass
+// through the same error handling, rate limiting, and pagination as Client.
+// It is safe for concurrent use.
+type FakeClient struct {
+	*Client
+
+	mu        sync.Mutex
+	responses map[string]FakeResponse
+	requests  []FakeRequest
+	// latency is set by SetLatency.
+	latency time.Duration
+}
+
+var _ APIClient = (*FakeClient)(nil)
+
+// NewFakeClient returns a FakeClient with no responses set. Client options,
+// such as WithClientClock, are applied to the embedded Client.
+func NewFakeClient(options ...ClientOption) (*FakeClient, error) {
+	fc := &FakeClient{
+		responses: make(map[string]FakeResponse),
+	}
+	// The fake middleware is added last, so middleware from options still
+	// sees each request.
+	options = append(options[:len(options):len(options)], WithMiddleware(fc.Middleware()))
+	c, err := NewClient("fake-token", options...)
+	if err != nil {
+		return nil, err
+	}
+	fc.Client = c
+	return fc, nil
+}
+
+// Middleware returns Middleware that responds to API requests using fc,
+// instead of sending them, such as to use the responses of fc with a
+// FullPullRequestCreator via WithAPIMiddleware. Requests are recorded by
+// fc.
+func (fc *FakeClient) Middleware() Middleware {
+	return func(Doer) Doer {
+		return DoerFunc(fc.respond)
+	}
+}
+
+// fakeEndpoint returns the key of responses of the endpoint.
+func fakeEndpoint(method, URI string) string {
+	return method + " " + URI
+}
+
+// SetResponse sets the response to requests for method and URI, which is
+// relative to the API host. A URI without a query string also matches
+// requests with one, such as for pagination.
+func (fc *FakeClient) SetResponse(method, URI string, resp FakeResponse) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.responses[fakeEndpoint(method, URI)] = resp
+}
+
+// SetJSONResponse sets an HTTP 200 response with the JSON body to requests
+// for method and URI, as described by SetResponse.
+func (fc *FakeClient) SetJSONResponse(method, URI string, body string) {
+	fc.SetResponse(method, URI, FakeResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:       body,
+	})
+}
+
+// SetLatency delays every response by d, using the clock of the embedded
+// Client, to simulate the latency of Github API requests, such as to
+// benchmark how many requests are made one after another.
+func (fc *FakeClient) SetLatency(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.latency = d
+}
+
+// Requests returns the requests received, in order.
+func (fc *FakeClient) Requests() []FakeRequest {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return append([]FakeRequest{}, fc.requests...)
+}
+
+// respond records req and returns its configured response.
+func (fc *FakeClient) respond(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	URI := req.URL.RequestURI()
+	fc.mu.Lock()
+	fc.requests = append(fc.requests, FakeRequest{Method: req.Method, URI: URI, Body: body})
+	fr, ok := fc.responses[fakeEndpoint(req.Method, URI)]
+	if !ok {
+		fr, ok = fc.responses[fakeEndpoint(req.Method, req.URL.Path)]
+	}
+	latency := fc.latency
+	fc.mu.Unlock()
+	if latency > 0 {
+		fc.Client.clock.Sleep(latency)
+	}
+	if !ok {
+		fr = FakeResponse{
+			StatusCode: http.StatusNotFound,
+			Body:       fmt.Sprintf(`{"message":"Not Found","documentation_url":"no response is set for %s %s"}`, req.Method, URI),
+		}
+	}
+	header := http.Header{}
+	for k, v := range fr.Header {
+		header[k] = append([]string{}, v...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fr.StatusCode, http.StatusText(fr.StatusCode)),
+		StatusCode:    fr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewBufferString(fr.Body)),
+		ContentLength: int64(len(fr.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFakeClient(t *testing.T) {
+	t.Parallel()
+
+	var c prme.APIClient
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = fc
+	fc.SetJSONResponse(http.MethodGet, "/user", `{"login":"ivanfetch"}`)
+	fc.SetJSONResponse(http.MethodGet, "/search/issues", `{"items":[{"number":1,"title":"Full review","state":"open","pull_request":{"html_url":"https://github.com/ivanfetch/ghapitest/pull/1"}}]}`)
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/labels", prme.FakeResponse{StatusCode: http.StatusCreated})
+
+	u, err := c.AuthenticatedUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Login != "ivanfetch" {
+		t.Errorf("want login ivanfetch, got %q", u.Login)
+	}
+	issues, err := c.SearchIssues("is:pr head:prme-full-content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || !issues[0].IsPullRequest() {
+		t.Errorf("want one pull request, got %+v", issues)
+	}
+	resp, err := c.MakeAPIRequestWithData(http.MethodPost, "/repos/ivanfetch/ghapitest/labels", []byte(`{"name":"prme"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("want HTTP %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	resp, err = c.MakeAPIRequest(http.MethodGet, "/repos/ivanfetch/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("want HTTP %d for an endpoint without a response, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	want := []prme.FakeRequest{
+		{Method: http.MethodGet, URI: "/user"},
+		{Method: http.MethodGet, URI: "/search/issues?q=is%3Apr+head%3Aprme-full-content&per_page=100"},
+		{Method: http.MethodPost, URI: "/repos/ivanfetch/ghapitest/labels", Body: []byte(`{"name":"prme"}`)},
+		{Method: http.MethodGet, URI: "/repos/ivanfetch/missing"},
+	}
+	if !cmp.Equal(want, fc.Requests()) {
+		t.Error(cmp.Diff(want, fc.Requests()))
+	}
+}
+
+func TestFakeClientLatency(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	clock := prme.NewManualClock(start)
+	fc, err := prme.NewFakeClient(prme.WithClientClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetLatency(250 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		resp, err := fc.MakeAPIRequest(http.MethodGet, "/repos/ivanfetch/ghapitest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	want := start.Add(500 * time.Millisecond)
+	if !clock.Now().Equal(want) {
+		t.Errorf("want time %v after two delayed responses, got %v", want, clock.Now())
+	}
+}
+
+func TestFakeClientServiceErrors(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", prme.FakeResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	})
+	_, err = fc.MakeAPIRequest(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	var maintenanceErr *prme.MaintenanceError
+	if !errors.As(err, &maintenanceErr) {
+		t.Fatalf("want a MaintenanceError, got %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClosePullRequest closes the pull request number without merging it.
+func (r Repo) ClosePullRequest(number int) error {
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d", r, number)
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPatch, apiURI, []byte(`{"state":"closed"}`))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while closing pull request %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	return nil
+}
+
+// CreateAnnotatedTag creates the annotated tag name, with message,
+// pointing at the commit sha.
+func (r Repo) CreateAnnotatedTag(name, message, sha string) error {
+	tagSha, err := r.createTagObject(name, message, sha)
+	if err != nil {
+		return err
+	}
+	// The tag object is only visible as a tag once a reference points at it.
+	return r.CreateRef("tags/"+name, tagSha)
+}
+
+// createTagObject creates a tag object name, with message, pointing at the
+// commit sha, without creating a reference to it, and returns the sha of
+// the tag object.
+func (r Repo) createTagObject(name, message, sha string) (tagSha string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/tags", r)
+	tagJSON, err := json.Marshal(struct {
+		Tag     string `json:"tag"`
+		Message string `json:"message"`
+		Object  string `json:"object"`
+		Type    string `json:"type"`
+	}{
+		Tag:     name,
+		Message: message,
+		Object:  sha,
+		Type:    "commit",
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, tagJSON)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("HTTP %d for %s while creating tag %q in repository %q", resp.StatusCode, apiURI, name, r)
+	}
+	var tagAPIResp struct{ Sha string }
+	err = json.NewDecoder(resp.Body).Decode(&tagAPIResp)
+	if err != nil {
+		return "", err
+	}
+	if tagAPIResp.Sha == "" {
+		return "", fmt.Errorf("the Github API did not return a tag object sha while creating tag %q in repository %q", name, r)
+	}
+	return tagAPIResp.Sha, nil
+}
+
+// getTagMessage returns the message of the tag object sha.
+func (r Repo) getTagMessage(sha string) (string, error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/tags/%s", r, sha)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d for %s while getting tag object %q in repository %q", resp.StatusCode, apiURI, sha, r)
+	}
+	var tagAPIResp struct{ Message string }
+	err = json.NewDecoder(resp.Body).Decode(&tagAPIResp)
+	if err != nil {
+		return "", err
+	}
+	return tagAPIResp.Message, nil
+}
+
+// LockConversation locks the conversation of the issue or pull request
+// number, so only collaborators can comment, giving reason, such as
+// resolved.
+func (r Repo) LockConversation(number int, reason string) error {
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d/lock", r, number)
+	lockJSON, err := json.Marshal(struct {
+		LockReason string `json:"lock_reason,omitempty"`
+	}{
+		LockReason: reason,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPut, apiURI, lockJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("HTTP %d for %s while locking the conversation of pull request %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	return nil
+}
+
+// SummaryComment returns a comment summarizing the review described by rr,
+// finalized at finalizedAt, as a permanent record of the review.
+func SummaryComment(rr ReviewReport, finalizedAt time.Time) string {
+	var b strings.Builder
+	b.WriteString("## Full Review Completed\n\n")
+	fmt.Fprintf(&b, "This review of %s was open for %s, from %s to %s, and the reviewed commit is %s.\n\n", rr.Repo, formatDays(finalizedAt.Sub(rr.PullRequest.CreatedAt)), rr.PullRequest.CreatedAt.UTC().Format("2006-01-02"), finalizedAt.UTC().Format("2006-01-02"), rr.PullRequest.Head.Sha)
+	fmt.Fprintf(&b, "* Files: %d, of which %d have review comments (%.1f%%)\n", rr.Files, rr.CommentedFiles, rr.CoveragePercent())
+	fmt.Fprintf(&b, "* Unresolved threads: %d\n", len(rr.UnresolvedThreads))
+	if len(rr.Reviewers) == 0 {
+		b.WriteString("* Reviewers: none\n")
+		return b.String()
+	}
+	b.WriteString("* Reviewers:\n")
+	for _, reviewer := range rr.Reviewers {
+		fmt.Fprintf(&b, "  * %s: %d files commented, %d comments, %d approvals\n", reviewer.Login, reviewer.FilesCommented, reviewer.Comments, reviewer.Approvals)
+	}
+	return b.String()
+}
+
+// Release is a Github release.
+type Release struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Body            string `json:"body,omitempty"`
+	HTMLURL         string `json:"html_url,omitempty"`
+}
+
+// CreateRelease creates a Github release, which also creates its tag if it
+// does not exist.
+func (r Repo) CreateRelease(release Release) (*Release, error) {
+	apiURI := fmt.Sprintf("/repos/%s/releases", r)
+	releaseJSON, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, releaseJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while creating release %q in repository %q", resp.StatusCode, apiURI, release.TagName, r)
+	}
+	var created Release
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Finalizer completes a full review, closing its pull request and
+// optionally recording the review in the repository itself.
+type Finalizer struct {
+	Token, Repo string
+	// ClientOptions construct the Github API client, such as WithBaseURL
+	// for a Github Enterprise Server instance.
+	ClientOptions []ClientOption
+	// Number is the pull request of the review. If Number is zero, the most
+	// recent open review of Repo in the state store is used.
+	Number int
+	// Tag, if not empty, is the name of a tag created at the reviewed
+	// commit, such as reviewed/2024-06. The tag is annotated, unless Release
+	// is true, in which case a Github release is created for the tag.
+	Tag     string
+	Release bool
+	// Note adds a git note to the reviewed commit, in NotesRef, which
+	// records the review without creating a tag.
+	Note bool
+	// DeleteBranches deletes the base and head branches of the review. Be
+	// sure review fixes have been merged from the head branch first.
+	DeleteBranches bool
+	// RetainBranches protects the base and head branches of the review so
+	// they are read-only, instead of deleting them, for organizations that
+	// must retain the reviewed snapshot.
+	RetainBranches bool
+	// StateFile is the state store in which created reviews are recorded.
+	StateFile string
+	// Force finalizes the review even if it has unresolved review threads.
+	Force bool
+	// RequireApprovals is the number of approvals the pull request needs to
+	// be finalized, without any reviewers requesting changes.
+	RequireApprovals int
+	// Summarize comments on the pull request with a SummaryComment before
+	// closing it, and Lock locks its conversation afterwards, leaving a
+	// tidy permanent record.
+	Summarize bool
+	Lock      bool
+}
+
+// ErrInsufficientApprovals is returned by Finalize when the pull request
+// does not have RequireApprovals approvals, or reviewers requested changes.
+var ErrInsufficientApprovals = errors.New("the review does not have the required approvals")
+
+// CheckApprovals returns ErrInsufficientApprovals if the pull request number
+// has fewer than required approvals, or a reviewer requested changes. Like
+// Github, only the latest review of each reviewer that approved, requested
+// changes, or was dismissed counts.
+func (r Repo) CheckApprovals(number, required int) error {
+	reviews, err := r.ListReviews(number)
+	if err != nil {
+		return err
+	}
+	// Reviews are listed in chronological order.
+	latest := make(map[string]string)
+	var reviewers []string
+	for _, review := range reviews {
+		switch review.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			if _, ok := latest[review.User.Login]; !ok {
+				reviewers = append(reviewers, review.User.Login)
+			}
+			latest[review.User.Login] = review.State
+		}
+	}
+	var approvals int
+	var changesRequested []string
+	for _, login := range reviewers {
+		switch latest[login] {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = append(changesRequested, login)
+		}
+	}
+	if len(changesRequested) > 0 {
+		return fmt.Errorf("%w: %s requested changes to pull request %d in repository %q", ErrInsufficientApprovals, strings.Join(changesRequested, ", "), number, r)
+	}
+	if approvals < required {
+		return fmt.Errorf("%w: pull request %d in repository %q has %d approvals, and %d are required", ErrInsufficientApprovals, number, r, approvals, required)
+	}
+	return nil
+}
+
+// ErrUnresolvedThreads is returned by Finalize when the review has
+// unresolved review threads, unless Force is set.
+var ErrUnresolvedThreads = errors.New("the review has unresolved review threads")
+
+// maxListedThreads is the most unresolved threads described by
+// CheckThreadsResolved.
+const maxListedThreads = 3
+
+// CheckThreadsResolved returns ErrUnresolvedThreads, describing some of the
+// threads, if the pull request number has unresolved review threads.
+func (r Repo) CheckThreadsResolved(number int) error {
+	threads, err := r.ListReviewThreads(number)
+	if err != nil {
+		return err
+	}
+	var unresolved []string
+	for _, thread := range threads {
+		if thread.IsResolved {
+			continue
+		}
+		location := thread.Path
+		if thread.Line > 0 {
+			location = fmt.Sprintf("%s:%d", thread.Path, thread.Line)
+		}
+		if thread.URL != "" {
+			location += " " + thread.URL
+		}
+		unresolved = append(unresolved, location)
+	}
+	if len(unresolved) == 0 {
+		return nil
+	}
+	described := unresolved
+	if len(described) > maxListedThreads {
+		described = described[:maxListedThreads]
+	}
+	return fmt.Errorf("%w: %d threads of pull request %d in repository %q are unresolved, such as %s", ErrUnresolvedThreads, len(unresolved), number, r, strings.Join(described, ", "))
+}
+
+// ErrNoOpenReview is returned by Finalize when no open review is recorded
+// in the state store for the repository.
+var ErrNoOpenReview = errors.New("no open full review is recorded for the repository")
+
+// Finalize closes the review pull request, creates the tag, release, or
+// git note if configured, and returns the pull request. Reviews with
+// unresolved review threads are not finalized unless Force is set, nor
+// reviews without RequireApprovals approvals.
+func (f Finalizer) Finalize() (*PullRequest, error) {
+	r, err := NewRepo(f.Repo, f.Token, f.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	var store *StateStore
+	if f.StateFile != "" {
+		store, err = NewStateStore(f.StateFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	number := f.Number
+	if number == 0 {
+		if store == nil {
+			return nil, errors.New("the pull request number is required without a state store")
+		}
+		record, err := store.latestOpenReview(r.String())
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoOpenReview, r)
+		}
+		number = record.Number
+	}
+	PR, err := r.GetPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	if f.RequireApprovals > 0 {
+		err = r.CheckApprovals(PR.Number, f.RequireApprovals)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !f.Force {
+		err = r.CheckThreadsResolved(PR.Number)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.Summarize {
+		rr, err := r.NewReviewReport(PR.Number)
+		if err != nil {
+			return nil, fmt.Errorf("while summarizing the review %s: %w", PR.HTMLURL, err)
+		}
+		_, err = r.CreateIssueComment(PR.Number, SummaryComment(*rr, time.Now()))
+		if err != nil {
+			return nil, fmt.Errorf("while commenting a summary of the review %s: %w", PR.HTMLURL, err)
+		}
+	}
+	if PR.State == "open" {
+		err = r.ClosePullRequest(PR.Number)
+		if err != nil {
+			return nil, err
+		}
+		PR.State = "closed"
+	}
+	if f.Tag != "" {
+		message := fmt.Sprintf("Full review %s of commit %s", PR.HTMLURL, PR.Head.Sha)
+		if f.Release {
+			_, err = r.CreateRelease(Release{
+				TagName:         f.Tag,
+				TargetCommitish: PR.Head.Sha,
+				Name:            f.Tag,
+				Body:            message,
+			})
+		} else {
+			err = r.CreateAnnotatedTag(f.Tag, message, PR.Head.Sha)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("while recording the completed review %s: %w", PR.HTMLURL, err)
+		}
+	}
+	if f.Note {
+		err = r.AddNote(NotesRef, PR.Head.Sha, ReviewNote(PR.HTMLURL, time.Now()))
+		if err != nil {
+			return nil, fmt.Errorf("while adding a git note for the completed review %s: %w", PR.HTMLURL, err)
+		}
+	}
+	if f.Lock {
+		err = r.LockConversation(PR.Number, "resolved")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.DeleteBranches {
+		err = r.DeleteReviewBranches(PR.Base.Ref, PR.Head.Ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.RetainBranches {
+		err = r.RetainReviewBranches(PR.Base.Ref, PR.Head.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("while retaining the branches of the review %s: %w", PR.HTMLURL, err)
+		}
+	}
+	if store != nil {
+		err = store.setReviewState(r.String(), PR.Number, PR.State)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return PR, nil
+}
+
+// latestOpenReview returns the most recently created open review of repo,
+// or nil if there is none. Repository names are compared case-insensitively,
+// as Github does.
+func (s StateStore) latestOpenReview(repo string) (*ReviewRecord, error) {
+	st, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	var latest *ReviewRecord
+	for i, review := range st.Reviews {
+		if !strings.EqualFold(review.Repo, repo) || review.State != "open" {
+			continue
+		}
+		if latest == nil || review.CreatedAt.After(latest.CreatedAt) {
+			latest = &st.Reviews[i]
+		}
+	}
+	return latest, nil
+}
+
+// setReviewState updates the state of recorded reviews of pull request
+// number in repo.
+func (s StateStore) setReviewState(repo string, number int, state string) error {
+	return s.update(func(st *State) {
+		for i, review := range st.Reviews {
+			if strings.EqualFold(review.Repo, repo) && review.Number == number {
+				st.Reviews[i].State = state
+			}
+		}
+	})
+}
+
+// runFinalizeCommand completes the full review of a repository.
+func runFinalizeCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme finalize", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand completes the full review of a repository, closing its pull request.
+
+Usage: %s [flags] <repository owner>/<repository name>
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which prme records created pull requests. This is also set via the PRME_STATE_FILE environment variable.")
+	CLINumber := fs.Int("number", 0, "The pull request number of the review. By default, the most recent open review recorded in the state store is used.")
+	CLITag := fs.String("tag", "", fmt.Sprintf("The name of an annotated tag to create at the reviewed commit, such as reviewed/%s. This is also set via the PRME_TAG environment variable.", time.Now().Format("2006-01")))
+	CLIRelease := fs.Bool("release", false, "Create a Github release for the -tag, instead of only an annotated tag. This is also set via the PRME_RELEASE environment variable.")
+	CLINote := fs.Bool("note", false, fmt.Sprintf("Add a git note to the reviewed commit, in refs/%s, recording the pull request, date, and prme version without creating a tag. This is also set via the PRME_NOTE environment variable.", NotesRef))
+	CLIOwner := addOwnerFlag(fs)
+	CLIDeleteBranches := fs.Bool("delete-branches", false, "Delete the base and head branches of the review. Merge review fixes from the head branch first! This is also set via the PRME_DELETE_BRANCHES environment variable.")
+	CLIRequireApprovals := fs.Int("require-approvals", 0, "The number of approvals the pull request needs to be finalized, without any reviewers requesting changes. This is also set via the PRME_REQUIRE_APPROVALS environment variable.")
+	CLIRetainBranches := fs.Bool("retain-branches", false, "Protect the base and head branches of the review so they are read-only and cannot be deleted, to retain the reviewed snapshot. This requires the admin permission for the repository. This is also set via the PRME_RETAIN_BRANCHES environment variable.")
+	CLISummarize := fs.Bool("summary", false, "Comment on the pull request with a summary of the review, including its duration, files, and reviewers, before closing it. This is also set via the PRME_SUMMARY environment variable.")
+	CLILock := fs.Bool("lock", false, "Lock the conversation of the pull request once it is closed, so only collaborators can comment. This is also set via the PRME_LOCK environment variable.")
+	CLIForce := fs.Bool("force", false, "Finalize the review even if its pull request has unresolved review threads.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one repository, in the form OwnerName/RepositoryName")
+	}
+	if *CLIDeleteBranches && *CLIRetainBranches {
+		return errors.New("please specify either -delete-branches or -retain-branches, not both")
+	}
+	if *CLIRequireApprovals < 0 {
+		return errors.New("the -require-approvals flag cannot be negative")
+	}
+	if *CLIRelease && *CLITag == "" {
+		return errors.New("the -release flag requires the -tag flag")
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	f := Finalizer{
+		Token:            token,
+		ClientOptions:    clientOptions,
+		Repo:             qualifyRepoName(fs.Arg(0), *CLIOwner),
+		Number:           *CLINumber,
+		Tag:              *CLITag,
+		Release:          *CLIRelease,
+		Note:             *CLINote,
+		DeleteBranches:   *CLIDeleteBranches,
+		RetainBranches:   *CLIRetainBranches,
+		StateFile:        *CLIStateFile,
+		Force:            *CLIForce,
+		RequireApprovals: *CLIRequireApprovals,
+		Summarize:        *CLISummarize,
+		Lock:             *CLILock,
+	}
+	PR, err := f.Finalize()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "The full review %s has been finalized\n", PR.HTMLURL)
+	return nil
+}
@@ -0,0 +1,315 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClosePullRequest(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls/7"
+		if r.Method != http.MethodPatch || wantRequestURL != r.RequestURI {
+			t.Errorf("Want PATCH %q for Github URL, got %s %q", wantRequestURL, r.Method, r.RequestURI)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != `{"state":"closed"}` {
+			t.Errorf("want the pull request closed, got body %s", body)
+		}
+		fmt.Fprint(w, `{"number": 7, "state": "closed"}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.ClosePullRequest(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateAnnotatedTag(t *testing.T) {
+	t.Parallel()
+
+	var gotRequests []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.RequestURI)
+		var got map[string]string
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch r.RequestURI {
+		case "/repos/ivanfetch/ghapitest/git/tags":
+			want := map[string]string{
+				"tag":     "reviewed/2021-08",
+				"message": "Full review",
+				"object":  "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25",
+				"type":    "commit",
+			}
+			if !cmp.Equal(want, got) {
+				t.Errorf("got incorrect tag\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha": "940bd336248efae0f9ee5bc7b2d5c985887b16ac"}`)
+		case "/repos/ivanfetch/ghapitest/git/refs":
+			want := map[string]string{
+				"ref": "refs/tags/reviewed/2021-08",
+				"sha": "940bd336248efae0f9ee5bc7b2d5c985887b16ac",
+			}
+			if !cmp.Equal(want, got) {
+				t.Errorf("got incorrect tag reference\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CreateAnnotatedTag("reviewed/2021-08", "Full review", "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"POST /repos/ivanfetch/ghapitest/git/tags",
+		"POST /repos/ivanfetch/ghapitest/git/refs",
+	}
+	if !cmp.Equal(wantRequests, gotRequests) {
+		t.Fatalf("got incorrect requests\ndiff reflects want vs. got: %s", cmp.Diff(wantRequests, gotRequests))
+	}
+}
+
+func TestCreateRelease(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/releases"
+		if wantRequestURL != r.RequestURI {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		var got prme.Release
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := prme.Release{
+			TagName:         "reviewed/2021-08",
+			TargetCommitish: "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25",
+			Name:            "reviewed/2021-08",
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("got incorrect release\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"tag_name": "reviewed/2021-08", "html_url": "https://github.com/ivanfetch/ghapitest/releases/tag/reviewed/2021-08"}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.CreateRelease(prme.Release{
+		TagName:         "reviewed/2021-08",
+		TargetCommitish: "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25",
+		Name:            "reviewed/2021-08",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HTMLURL != "https://github.com/ivanfetch/ghapitest/releases/tag/reviewed/2021-08" {
+		t.Fatalf("got incorrect release %+v", got)
+	}
+}
+
+func TestCheckThreadsResolved(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		threads     string
+		wantErr     bool
+	}{
+		{
+			description: "resolved threads",
+			threads:     `[{"isResolved": true, "path": "go.mod", "line": 1, "comments": {"nodes": []}}]`,
+		},
+		{
+			description: "an unresolved thread",
+			threads:     `[{"isResolved": true, "path": "go.mod", "line": 1, "comments": {"nodes": []}}, {"isResolved": false, "path": "README.md", "line": 3, "comments": {"nodes": [{"url": "https://github.com/owner/repo/pull/7#discussion_r1"}]}}]`,
+			wantErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			fc, err := prme.NewFakeClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fc.SetJSONResponse(http.MethodPost, "/graphql", `{"data": {"repository": {"pullRequest": {"reviewThreads": {"nodes": `+tc.threads+`, "pageInfo": {"hasNextPage": false}}}}}}`)
+			r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = r.CheckThreadsResolved(7)
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, prme.ErrUnresolvedThreads) {
+				t.Fatalf("want ErrUnresolvedThreads, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "README.md:3 https://github.com/owner/repo/pull/7#discussion_r1") {
+				t.Errorf("want the error to describe the unresolved thread, got %q", err)
+			}
+		})
+	}
+}
+
+func TestCheckApprovals(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		reviews     string
+		required    int
+		wantErr     bool
+	}{
+		{
+			description: "enough approvals",
+			reviews:     `[{"state": "APPROVED", "user": {"login": "octocat"}}, {"state": "COMMENTED", "user": {"login": "hubot"}}, {"state": "APPROVED", "user": {"login": "hubot"}}]`,
+			required:    2,
+		},
+		{
+			description: "too few approvals",
+			reviews:     `[{"state": "APPROVED", "user": {"login": "octocat"}}, {"state": "APPROVED", "user": {"login": "octocat"}}]`,
+			required:    2,
+			wantErr:     true,
+		},
+		{
+			description: "changes requested",
+			reviews:     `[{"state": "APPROVED", "user": {"login": "octocat"}}, {"state": "CHANGES_REQUESTED", "user": {"login": "hubot"}}]`,
+			required:    1,
+			wantErr:     true,
+		},
+		{
+			description: "changes requested and later approved",
+			reviews:     `[{"state": "CHANGES_REQUESTED", "user": {"login": "hubot"}}, {"state": "COMMENTED", "user": {"login": "hubot"}}, {"state": "APPROVED", "user": {"login": "hubot"}}]`,
+			required:    1,
+		},
+		{
+			description: "approval dismissed",
+			reviews:     `[{"state": "APPROVED", "user": {"login": "hubot"}}, {"state": "DISMISSED", "user": {"login": "hubot"}}]`,
+			required:    1,
+			wantErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			fc, err := prme.NewFakeClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/pulls/7/reviews", tc.reviews)
+			r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = r.CheckApprovals(7, tc.required)
+			if tc.wantErr && !errors.Is(err, prme.ErrInsufficientApprovals) {
+				t.Fatalf("want ErrInsufficientApprovals, got %v", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSummaryComment(t *testing.T) {
+	t.Parallel()
+
+	rr := prme.ReviewReport{
+		Repo: "owner/repo",
+		PullRequest: prme.PullRequest{
+			CreatedAt: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+			Head:      prme.PullRequestBranch{Sha: "05db72c"},
+		},
+		Files:          8,
+		CommentedFiles: 2,
+		Reviewers: []prme.ReviewerActivity{
+			{Login: "octocat", FilesCommented: 2, Comments: 5, Reviews: 1, Approvals: 1},
+		},
+		UnresolvedThreads: []prme.ReviewThread{{Path: "README.md"}},
+	}
+	got := prme.SummaryComment(rr, time.Date(2024, 6, 13, 21, 0, 0, 0, time.UTC))
+	want := `## Full Review Completed
+
+This review of owner/repo was open for 12d 12h, from 2024-06-01 to 2024-06-13, and the reviewed commit is 05db72c.
+
+* Files: 8, of which 2 have review comments (25.0%)
+* Unresolved threads: 1
+* Reviewers:
+  * octocat: 2 files commented, 5 comments, 1 approvals
+`
+	if !cmp.Equal(want, got) {
+		t.Errorf("got incorrect summary\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestLockConversation(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPut, "/repos/owner/repo/issues/7/lock", prme.FakeResponse{StatusCode: http.StatusNoContent})
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.LockConversation(7, "resolved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := fc.Requests()
+	want := `{"lock_reason":"resolved"}`
+	if got := string(requests[len(requests)-1].Body); got != want {
+		t.Errorf("want request body %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,203 @@
+package prme
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ListOpenPullRequests returns all open pull requests of the repository.
+func (r Repo) ListOpenPullRequests() ([]PullRequest, error) {
+	var PRs []PullRequest
+	apiURI := fmt.Sprintf("/repos/%s/pulls?state=open", r)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []PullRequest
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		PRs = append(PRs, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing open pull requests in repository %q: %w", r, err)
+	}
+	return PRs, nil
+}
+
+// LeftoverBranches returns the branches beginning with prefix that were
+// created by prme, but are not the base or head branch of an open pull
+// request, such as those left by runs that failed part way. Branches whose
+// history does not begin with an empty commit are not returned, as they
+// were not created by prme, nor protected branches, such as those retained
+// by RetainReviewBranches. ErrRepoLocked is returned while prme is
+// creating a review in the repository, as its branches would appear to be
+// leftovers.
+func (r Repo) LeftoverBranches(prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, errors.New("the branch prefix cannot be empty")
+	}
+	_, err := r.GetRef(LockRef)
+	if err == nil {
+		return nil, fmt.Errorf("%w: prme is creating a review in repository %q", ErrRepoLocked, r)
+	}
+	if !errors.Is(err, ErrRefNotFound) {
+		return nil, err
+	}
+	branches, err := r.ListBranches(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(branches) == 0 {
+		return nil, nil
+	}
+	PRs, err := r.ListOpenPullRequests()
+	if err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for _, PR := range PRs {
+		inUse[PR.Base.Ref] = true
+		inUse[PR.Head.Ref] = true
+	}
+	var leftovers []string
+	for _, branch := range branches {
+		if inUse[branch] {
+			continue
+		}
+		if r.VerifyPrmeBranch(branch) != nil {
+			continue
+		}
+		protected, err := r.BranchProtected(branch)
+		if err != nil {
+			return nil, err
+		}
+		if protected {
+			continue
+		}
+		leftovers = append(leftovers, branch)
+	}
+	return leftovers, nil
+}
+
+// confirm asks question, returning true if the answer read from input is
+// yes.
+func confirm(input io.Reader, output io.Writer, question string) (bool, error) {
+	fmt.Fprintf(output, "%s [y/N]: ", question)
+	line, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// runGCCommand deletes branches left by prme runs that failed part way.
+func runGCCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme gc", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand finds branches created by prme that are not the base or head branch of an open pull request, such as those left by runs that failed part way, and deletes them after confirmation. Only branches beginning with the branch prefix, whose history begins with an empty commit created by prme, are deleted.
+
+Usage: %s [flags] <repository> [<repository>...]
+       %s [flags] -org <organization>
+
+Available command-line flags:
+`,
+			fs.Name(), fs.Name())
+		fs.PrintDefaults()
+	}
+	CLIBranchPrefix := fs.String("branch-prefix", DefaultBranchPrefix, "The prefix of branch names created by prme. This is also set via the PRME_BRANCH_PREFIX environment variable.")
+	CLIOrg := fs.String("org", "", "An organization or user, whose repositories are scanned instead of specifying repositories. Archived repositories are skipped. This is also set via the PRME_ORG environment variable.")
+	CLIOwner := addOwnerFlag(fs)
+	CLIYes := fs.Bool("yes", false, "Delete the branches without asking for confirmation, such as when not run interactively.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() == 0 && *CLIOrg == "" {
+		fs.Usage()
+		return errors.New("please specify at least one repository, in the form OwnerName/RepositoryName, or the -org flag")
+	}
+	if fs.NArg() > 0 && *CLIOrg != "" {
+		return errors.New("please specify either repositories or the -org flag, not both")
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	var repoNames []string
+	for _, arg := range fs.Args() {
+		repoNames = append(repoNames, qualifyRepoName(arg, *CLIOwner))
+	}
+	if *CLIOrg != "" {
+		c, err := NewClient(token, clientOptions...)
+		if err != nil {
+			return err
+		}
+		repos, err := c.ListOwnerRepositories(*CLIOrg)
+		if err != nil {
+			return err
+		}
+		for _, repository := range repos {
+			if !repository.Archived {
+				repoNames = append(repoNames, repository.FullName)
+			}
+		}
+	}
+	type leftover struct {
+		repo   *Repo
+		branch string
+	}
+	var leftovers []leftover
+	for _, repoName := range repoNames {
+		r, err := NewRepo(repoName, token, clientOptions...)
+		if err != nil {
+			return err
+		}
+		branches, err := r.LeftoverBranches(*CLIBranchPrefix)
+		if errors.Is(err, ErrRepoLocked) {
+			fmt.Fprintf(errOutput, "%s: skipped, %v\n", r, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			fmt.Fprintf(output, "%s: %s\n", r, branch)
+			leftovers = append(leftovers, leftover{repo: r, branch: branch})
+		}
+	}
+	if len(leftovers) == 0 {
+		fmt.Fprintln(output, "No leftover branches were found")
+		return nil
+	}
+	if !*CLIYes {
+		if !isTerminal(os.Stdin) {
+			return errors.New("please specify -yes to delete the branches when not running interactively")
+		}
+		ok, err := confirm(os.Stdin, output, fmt.Sprintf("Delete these %d branches?", len(leftovers)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(output, "No branches were deleted")
+			return nil
+		}
+	}
+	for _, l := range leftovers {
+		err = l.repo.DeleteRef("heads/" + l.branch)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "%s: deleted %s\n", l.repo, l.branch)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package prme_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestLeftoverBranches(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/matching-refs/heads/prme-", `[
+		{"ref": "refs/heads/prme-full-review"},
+		{"ref": "refs/heads/prme-full-content"},
+		{"ref": "refs/heads/prme-old-review"},
+		{"ref": "refs/heads/prme-notes"}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[
+		{"number": 7, "state": "open", "base": {"ref": "prme-full-review"}, "head": {"ref": "prme-full-content"}}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/prme-old-review", `{"ref": "refs/heads/prme-old-review", "object": {"sha": "c1"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/commits/c1", `{"sha": "c1", "tree": {"sha": "`+prme.EmptyTreeSha+`"}, "parents": []}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/prme-notes", `{"ref": "refs/heads/prme-notes", "object": {"sha": "n1"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/commits/n1", `{"sha": "n1", "tree": {"sha": "t1"}, "parents": []}`)
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.LeftoverBranches("prme-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"prme-old-review"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect leftover branches\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
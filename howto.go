@@ -0,0 +1,87 @@
+package prme
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultHowTo is the English HowTo template of LocalizedText.
+const defaultHowTo = "This pull request is a full review of the `{{.FullRepoBranch}}` branch of {{.Repo}}, created by [prme](https://github.com/ivanfetch/prme). " +
+	"Its base branch `{{.BaseBranch}}` is empty, so every file of the repository appears as a change, which can be commented on like any other pull request.\n\n" +
+	"* Push fixes found during the review to the head branch `{{.HeadBranch}}`, or open pull requests into that branch.\n" +
+	"* Do not merge this pull request, which would add the whole repository to the empty base branch.\n" +
+	"* Once the review is complete, it is finalized by closing this pull request, and fixes are merged from `{{.HeadBranch}}` into `{{.FullRepoBranch}}`.\n"
+
+// HowToData is the data available to a HowTo template.
+type HowToData struct {
+	Repo, FullRepoBranch, BaseBranch, HeadBranch string
+}
+
+// HowToComment returns the comment explaining the review workflow, using
+// the text/template tmpl with data.
+func HowToComment(tmpl string, data HowToData) (string, error) {
+	t, err := template.New("howto").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("while parsing the how-to template: %w", err)
+	}
+	var b strings.Builder
+	err = t.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("while executing the how-to template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// WithHowToComment comments on the pull request once it is created,
+// explaining the review workflow to reviewers, using the text/template tmpl
+// whose data is HowToData. If tmpl is empty, the template of the language
+// set by WithLanguage, or English, is used.
+func WithHowToComment(tmpl string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if tmpl != "" {
+			_, err := HowToComment(tmpl, HowToData{})
+			if err != nil {
+				return err
+			}
+		}
+		f.HowTo = true
+		if tmpl != "" {
+			f.HowToTemplate = tmpl
+		}
+		return nil
+	}
+}
+
+// howToComment returns the comment explaining the review workflow for the
+// review of repository r.
+func (f FullPullRequestCreator) howToComment(r *Repo) (string, error) {
+	tmpl := f.HowToTemplate
+	if tmpl == "" {
+		tmpl = defaultHowTo
+	}
+	return HowToComment(tmpl, HowToData{
+		Repo:           r.String(),
+		FullRepoBranch: f.FullRepoBranch,
+		BaseBranch:     f.BaseBranch,
+		HeadBranch:     f.HeadBranch,
+	})
+}
+
+// commentHowTo comments on PR explaining the review workflow, if requested
+// by HowTo.
+func (f FullPullRequestCreator) commentHowTo(r *Repo, PR *PullRequest) error {
+	if !f.HowTo {
+		return nil
+	}
+	body, err := f.howToComment(r)
+	if err != nil {
+		return err
+	}
+	_, err = r.CreateIssueComment(PR.Number, body)
+	if err != nil {
+		return err
+	}
+	f.progress("commented how to contribute review fixes")
+	return nil
+}
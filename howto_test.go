@@ -0,0 +1,61 @@
+package prme_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"strings"
+	"testing"
+)
+
+func TestHowToComment(t *testing.T) {
+	t.Parallel()
+
+	got, err := prme.HowToComment("Push fixes to {{.HeadBranch}} of {{.Repo}}, then merge them into {{.FullRepoBranch}}. Do not merge into {{.BaseBranch}}.", prme.HowToData{
+		Repo:           "owner/repo",
+		FullRepoBranch: "main",
+		BaseBranch:     "prme-full-review",
+		HeadBranch:     "prme-full-content",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Push fixes to prme-full-content of owner/repo, then merge them into main. Do not merge into prme-full-review."
+	if want != got {
+		t.Fatalf("got incorrect comment\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestHowToCommentLocalized(t *testing.T) {
+	t.Parallel()
+
+	data := prme.HowToData{
+		Repo:           "owner/repo",
+		FullRepoBranch: "main",
+		BaseBranch:     "prme-full-review",
+		HeadBranch:     "prme-full-content",
+	}
+	for _, lang := range prme.Languages() {
+		text, err := prme.Localize(lang, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := prme.HowToComment(text.HowTo, data)
+		if err != nil {
+			t.Fatalf("language %s: %v", lang, err)
+		}
+		for _, want := range []string{"`main`", "`prme-full-review`", "`prme-full-content`", "owner/repo"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("language %s: want the comment to contain %s, got %q", lang, want, got)
+			}
+		}
+	}
+}
+
+func TestWithHowToCommentInvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.NewFullPullRequestCreator("owner/repo", prme.WithHowToComment("Push fixes to {{.ReviewBranch}}"))
+	if err == nil {
+		t.Fatal("want an error for a template using an unknown field")
+	}
+}
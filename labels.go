@@ -0,0 +1,247 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Label is a Github issue and pull request label.
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
+}
+
+// newLabel returns the label name, using the hexadecimal color with or
+// without a leading #.
+func newLabel(name, color string) Label {
+	return Label{
+		Name:  name,
+		Color: strings.TrimPrefix(color, "#"),
+	}
+}
+
+// addLabelsRequest is the request body of AddLabels.
+type addLabelsRequest struct {
+	Labels []string `json:"labels"`
+}
+
+// EnsureLabel creates the label name in the repository, using the
+// hexadecimal color such as "0e8a16", if the label does not already exist.
+func (r Repo) EnsureLabel(name, color string) error {
+	if name == "" {
+		return errors.New("the label name cannot be empty")
+	}
+	apiURI := fmt.Sprintf("/repos/%s/labels/%s", r, url.PathEscape(name))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("HTTP %d for %s while determining if label %q exists in repository %q", resp.StatusCode, apiURI, name, r)
+	}
+	apiURI = fmt.Sprintf("/repos/%s/labels", r)
+	labelJSON, err := json.Marshal(newLabel(name, color))
+	if err != nil {
+		return err
+	}
+	resp, err = r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, labelJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP %d for %s while creating label %q in repository %q", resp.StatusCode, apiURI, name, r)
+	}
+	return nil
+}
+
+// AddLabels adds the existing labels to the issue or pull request number.
+func (r Repo) AddLabels(number int, labels []string) error {
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d/labels", r, number)
+	labelsJSON, err := json.Marshal(addLabelsRequest{Labels: labels})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, labelsJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while adding labels %v to %d in repository %q", resp.StatusCode, apiURI, labels, number, r)
+	}
+	return nil
+}
+
+// addAssigneesRequest is the request body of AddAssignees.
+type addAssigneesRequest struct {
+	Assignees []string `json:"assignees"`
+}
+
+// AddAssignees assigns the users assignees to the issue or pull request
+// number. Github ignores users who cannot be assigned, such as those without
+// access to the repository, which are returned as unassigned.
+func (r Repo) AddAssignees(number int, assignees []string) (unassigned []string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d/assignees", r, number)
+	assigneesJSON, err := json.Marshal(addAssigneesRequest{Assignees: assignees})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, assigneesJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while assigning %v to %d in repository %q", resp.StatusCode, apiURI, assignees, number, r)
+	}
+	var issueAPIResp struct {
+		Assignees []User `json:"assignees"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&issueAPIResp)
+	if err != nil {
+		return nil, err
+	}
+	for _, assignee := range assignees {
+		assigned := false
+		for _, user := range issueAPIResp.Assignees {
+			if strings.EqualFold(user.Login, assignee) {
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			unassigned = append(unassigned, assignee)
+		}
+	}
+	return unassigned, nil
+}
+
+// Milestone is a Github milestone, used to group issues and pull requests.
+type Milestone struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// FindMilestone returns the open or closed milestone with the given title. A
+// nil milestone is returned if none matches.
+func (r Repo) FindMilestone(title string) (*Milestone, error) {
+	var found *Milestone
+	apiURI := fmt.Sprintf("/repos/%s/milestones?state=all", r)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []Milestone
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			if found == nil && page[i].Title == title {
+				found = &page[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while finding milestone %q in repository %q: %w", title, r, err)
+	}
+	return found, nil
+}
+
+// GetMilestone returns the milestone number. A nil milestone is returned if
+// it does not exist.
+func (r Repo) GetMilestone(number int) (*Milestone, error) {
+	apiURI := fmt.Sprintf("/repos/%s/milestones/%d", r, number)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting milestone %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	var m Milestone
+	err = json.NewDecoder(resp.Body).Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ErrMilestoneNotFound is returned by ResolveMilestone when no milestone
+// matches.
+var ErrMilestoneNotFound = errors.New("the milestone does not exist")
+
+// ResolveMilestone returns the milestone whose title is titleOrNumber, or
+// otherwise whose number it is.
+func (r Repo) ResolveMilestone(titleOrNumber string) (*Milestone, error) {
+	m, err := r.FindMilestone(titleOrNumber)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return m, nil
+	}
+	number, convErr := strconv.Atoi(titleOrNumber)
+	if convErr == nil && number > 0 {
+		m, err = r.GetMilestone(number)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q in repository %q", ErrMilestoneNotFound, titleOrNumber, r)
+}
+
+// setMilestoneRequest is the request body of SetMilestone.
+type setMilestoneRequest struct {
+	Milestone int `json:"milestone"`
+}
+
+// SetMilestone adds the issue or pull request number to the milestone
+// milestoneNumber.
+func (r Repo) SetMilestone(number, milestoneNumber int) error {
+	apiURI := fmt.Sprintf("/repos/%s/issues/%d", r, number)
+	milestoneJSON, err := json.Marshal(setMilestoneRequest{Milestone: milestoneNumber})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPatch, apiURI, milestoneJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while setting milestone %d of %d in repository %q", resp.StatusCode, apiURI, milestoneNumber, number, r)
+	}
+	return nil
+}
+
+// WithMilestone adds the pull request to the milestone whose title, or
+// otherwise number, is titleOrNumber. The milestone must exist.
+func WithMilestone(titleOrNumber string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if titleOrNumber == "" {
+			return errors.New("the milestone cannot be empty")
+		}
+		f.Milestone = titleOrNumber
+		return nil
+	}
+}
@@ -0,0 +1,248 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnsureLabelCreatesMissingLabel(t *testing.T) {
+	t.Parallel()
+
+	var created bool
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.RequestURI == "/repos/ivanfetch/ghapitest/labels/full%20review":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.RequestURI == "/repos/ivanfetch/ghapitest/labels":
+			var got prme.Label
+			err := json.NewDecoder(r.Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := prme.Label{Name: "full review", Color: "0e8a16"}
+			if !cmp.Equal(want, got) {
+				t.Errorf("got incorrect label\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+			}
+			created = true
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected %s request for Github URL %q", r.Method, r.RequestURI)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.EnsureLabel("full review", "#0e8a16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("the missing label was not created")
+	}
+}
+
+func TestFindMilestone(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestFindMilestone.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/milestones?state=all&per_page=100"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.FindMilestone("Audit 2021")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Number != 2 {
+		t.Fatalf("want milestone number 2, got %+v", got)
+	}
+	got, err = r.FindMilestone("does not exist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("want no milestone, got %+v", got)
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/issues/7/labels"
+		if r.Method != http.MethodPost || r.RequestURI != wantRequestURL {
+			t.Errorf("Want POST %q for Github URL, got %s %q", wantRequestURL, r.Method, r.RequestURI)
+		}
+		var got struct {
+			Labels []string `json:"labels"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"full-review", "audit"}
+		if !cmp.Equal(want, got.Labels) {
+			t.Errorf("got incorrect labels\ndiff reflects want vs. got: %s", cmp.Diff(want, got.Labels))
+		}
+		io.WriteString(w, `[]`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.AddLabels(7, []string{"full-review", "audit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddAssignees(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/issues/7/assignees"
+		if r.Method != http.MethodPost || r.RequestURI != wantRequestURL {
+			t.Errorf("Want POST %q for Github URL, got %s %q", wantRequestURL, r.Method, r.RequestURI)
+		}
+		var got struct {
+			Assignees []string `json:"assignees"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"ivanfetch", "outsider"}
+		if !cmp.Equal(want, got.Assignees) {
+			t.Errorf("got incorrect assignees\ndiff reflects want vs. got: %s", cmp.Diff(want, got.Assignees))
+		}
+		// Github ignores users who cannot be assigned.
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"number": 7, "assignees": [{"login": "IvanFetch"}]}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.AddAssignees(7, []string{"ivanfetch", "outsider"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"outsider"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect unassigned users\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestResolveMilestone(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/milestones", `[
+		{"number": 3, "title": "Audit 2021", "state": "open"},
+		{"number": 4, "title": "2022", "state": "open"}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/milestones/12", `{"number": 12, "title": "Release planning", "state": "closed"}`)
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		titleOrNumber string
+		want          int
+	}{
+		{"Audit 2021", 3},
+		// A title that is a number takes precedence over milestone numbers.
+		{"2022", 4},
+		{"12", 12},
+	}
+	for _, tc := range testCases {
+		got, err := r.ResolveMilestone(tc.titleOrNumber)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.titleOrNumber, err)
+		}
+		if tc.want != got.Number {
+			t.Errorf("%s: want milestone %d, got %d", tc.titleOrNumber, tc.want, got.Number)
+		}
+	}
+	for _, titleOrNumber := range []string{"does not exist", "13"} {
+		_, err = r.ResolveMilestone(titleOrNumber)
+		if !errors.Is(err, prme.ErrMilestoneNotFound) {
+			t.Errorf("%s: want error %v, got %v", titleOrNumber, prme.ErrMilestoneNotFound, err)
+		}
+	}
+}
+
+func TestSetMilestone(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodPatch, "/repos/ivanfetch/ghapitest/issues/7", `{"number": 7}`)
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.SetMilestone(7, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := fc.Requests()
+	want := `{"milestone":3}`
+	if len(requests) != 1 || want != string(requests[0].Body) {
+		t.Fatalf("want one request with body %s, got %+v", want, requests)
+	}
+}
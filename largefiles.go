@@ -0,0 +1,107 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OmittedFilesManifest is the file listing files omitted from the review for
+// being larger than the maximum file size, at the root of the review
+// content.
+const OmittedFilesManifest = "PRME-OMITTED-FILES.md"
+
+// WithMaxFileSize omits files larger than maxSize bytes from the review,
+// listing them in OmittedFilesManifest instead, so Github can display the
+// pull request diff.
+func WithMaxFileSize(maxSize int64) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if maxSize < 0 {
+			return fmt.Errorf("the maximum file size cannot be negative, got %d", maxSize)
+		}
+		f.MaxFileSize = maxSize
+		return nil
+	}
+}
+
+// omittedFilesManifest returns the content of OmittedFilesManifest listing
+// omitted, the files larger than maxSize bytes.
+func omittedFilesManifest(omitted []TreeEntry, maxSize int64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Files Omitted From This Review\n\nThese files are larger than %d bytes, so prme omitted them to keep the pull request diff viewable. Review them in the full repository branch.\n\n", maxSize)
+	for _, entry := range omitted {
+		fmt.Fprintf(&b, "* `%s` (%d bytes, blob %s)\n", entry.Path, entry.Size, entry.Sha)
+	}
+	return b.String()
+}
+
+// OmitLargeFiles creates a tree from treeish, a tree or commit sha, without
+// files larger than maxSize bytes, adding OmittedFilesManifest listing them.
+// The sha of the new tree is returned along with the omitted files. If no
+// files are omitted, the sha of the existing tree is returned.
+func (r Repo) OmitLargeFiles(treeish string, maxSize int64) (treeSha string, omitted []TreeEntry, err error) {
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return "", nil, err
+	}
+	if tree.Truncated {
+		return "", nil, fmt.Errorf("Github truncated the files of tree %q in repository %q, so files larger than %d bytes cannot be omitted", treeish, r, maxSize)
+	}
+	for _, entry := range tree.Entries {
+		if entry.Type == "blob" && entry.Size > maxSize {
+			omitted = append(omitted, entry)
+		}
+	}
+	if len(omitted) == 0 {
+		return tree.Sha, nil, nil
+	}
+	for _, entry := range omitted {
+		if entry.Path == OmittedFilesManifest {
+			return "", nil, errors.New("the repository already has a " + OmittedFilesManifest + " file larger than the maximum file size")
+		}
+	}
+	var changes []interface{}
+	for _, entry := range omitted {
+		changes = append(changes, deletedTreeEntry{Path: entry.Path, Mode: entry.Mode, Type: entry.Type})
+	}
+	changes = append(changes, contentTreeEntry{Path: OmittedFilesManifest, Mode: "100644", Type: "blob", Content: omittedFilesManifest(omitted, maxSize)})
+	treeSha, err = r.changeTree(tree.Sha, changes)
+	if err != nil {
+		return "", nil, err
+	}
+	return treeSha, omitted, nil
+}
+
+// deletedTreeEntry deletes a file from the base tree when creating a tree,
+// as its sha is null.
+type deletedTreeEntry struct {
+	Path string  `json:"path"`
+	Mode string  `json:"mode"`
+	Type string  `json:"type"`
+	Sha  *string `json:"sha"`
+}
+
+// contentTreeEntry adds a file with content when creating a tree.
+type contentTreeEntry struct {
+	Path    string `json:"path"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// changeTree creates a tree from baseTree with changes, which are TreeEntry,
+// deletedTreeEntry, or contentTreeEntry, returning the sha of the new tree.
+func (r Repo) changeTree(baseTree string, changes []interface{}) (string, error) {
+	treeJSON, err := json.Marshal(struct {
+		BaseTree string        `json:"base_tree"`
+		Tree     []interface{} `json:"tree"`
+	}{
+		BaseTree: baseTree,
+		Tree:     changes,
+	})
+	if err != nil {
+		return "", err
+	}
+	return r.postTree(treeJSON)
+}
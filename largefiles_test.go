@@ -0,0 +1,89 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOmitLargeFiles(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", `{"sha": "roottree", "tree": [
+		{"path": "README.md", "mode": "100644", "type": "blob", "sha": "readme", "size": 100},
+		{"path": "assets", "mode": "040000", "type": "tree", "sha": "assetstree"},
+		{"path": "assets/video.mp4", "mode": "100644", "type": "blob", "sha": "video", "size": 5000000}
+	]}`)
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/git/trees", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha": "filteredtree"}`})
+
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeSha, omitted, err := r.OmitLargeFiles("fullsha", 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if treeSha != "filteredtree" {
+		t.Errorf("want tree %q, got %q", "filteredtree", treeSha)
+	}
+	if len(omitted) != 1 || omitted[0].Path != "assets/video.mp4" {
+		t.Fatalf("want assets/video.mp4 to be omitted, got %v", omitted)
+	}
+	var gotTree struct {
+		BaseTree string `json:"base_tree"`
+		Tree     []map[string]interface{}
+	}
+	requests := fc.Requests()
+	err = json.Unmarshal(requests[len(requests)-1].Body, &gotTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotTree.BaseTree != "roottree" {
+		t.Errorf("want base tree %q, got %q", "roottree", gotTree.BaseTree)
+	}
+	if len(gotTree.Tree) != 2 {
+		t.Fatalf("want a deleted file and the manifest in the tree request, got %v", gotTree.Tree)
+	}
+	wantDeleted := map[string]interface{}{"path": "assets/video.mp4", "mode": "100644", "type": "blob", "sha": nil}
+	if !cmp.Equal(wantDeleted, gotTree.Tree[0]) {
+		t.Errorf("got incorrect deleted file\ndiff reflects want vs. got: %s", cmp.Diff(wantDeleted, gotTree.Tree[0]))
+	}
+	if gotTree.Tree[1]["path"] != prme.OmittedFilesManifest {
+		t.Errorf("want the manifest %q, got %v", prme.OmittedFilesManifest, gotTree.Tree[1]["path"])
+	}
+	manifest, _ := gotTree.Tree[1]["content"].(string)
+	if !strings.Contains(manifest, "`assets/video.mp4` (5000000 bytes") {
+		t.Errorf("want the manifest to list assets/video.mp4, got %q", manifest)
+	}
+}
+
+func TestOmitLargeFilesWithoutLargeFiles(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", `{"sha": "roottree", "tree": [
+		{"path": "README.md", "mode": "100644", "type": "blob", "sha": "readme", "size": 100}
+	]}`)
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeSha, omitted, err := r.OmitLargeFiles("fullsha", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if treeSha != "roottree" || len(omitted) != 0 {
+		t.Fatalf("want the existing tree without omitted files, got %q and %v", treeSha, omitted)
+	}
+}
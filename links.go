@@ -0,0 +1,156 @@
+package prme
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// How symlinks and submodules are included in the review, as set by
+// WithSymlinks and WithSubmodules.
+const (
+	// LinkKeep includes symlinks and submodules as they are, which Github
+	// displays as the path of the symlink target, or the commit of the
+	// submodule.
+	LinkKeep = "keep"
+	// LinkSkip omits symlinks and submodules from the review.
+	LinkSkip = "skip"
+	// LinkMaterialize replaces symlinks with the file or directory they
+	// target, and submodules with a file describing the commit they point
+	// to, since their content is in another repository.
+	LinkMaterialize = "materialize"
+)
+
+// Git modes of symlinks and submodules, which Github calls gitlinks.
+const (
+	symlinkMode   = "120000"
+	submoduleMode = "160000"
+)
+
+// maxSymlinkDepth is the most symlinks followed when materializing a
+// symlink that targets another symlink.
+const maxSymlinkDepth = 10
+
+// validateLinkHandling returns an error if handling is not one of LinkKeep,
+// LinkSkip, or LinkMaterialize.
+func validateLinkHandling(kind, handling string) error {
+	switch handling {
+	case LinkKeep, LinkSkip, LinkMaterialize:
+		return nil
+	}
+	return fmt.Errorf("unknown handling %q for %s, it must be %s, %s, or %s", handling, kind, LinkKeep, LinkSkip, LinkMaterialize)
+}
+
+// WithSymlinks sets how symlinks are included in the review, to LinkKeep,
+// LinkSkip, or LinkMaterialize.
+func WithSymlinks(handling string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		err := validateLinkHandling("symlinks", handling)
+		if err != nil {
+			return err
+		}
+		f.Symlinks = handling
+		return nil
+	}
+}
+
+// WithSubmodules sets how submodules are included in the review, to
+// LinkKeep, LinkSkip, or LinkMaterialize.
+func WithSubmodules(handling string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		err := validateLinkHandling("submodules", handling)
+		if err != nil {
+			return err
+		}
+		f.Submodules = handling
+		return nil
+	}
+}
+
+// linkHandling returns how f includes symlinks and submodules, where empty
+// means LinkKeep.
+func (f FullPullRequestCreator) linkHandling() (symlinks, submodules string) {
+	symlinks, submodules = f.Symlinks, f.Submodules
+	if symlinks == "" {
+		symlinks = LinkKeep
+	}
+	if submodules == "" {
+		submodules = LinkKeep
+	}
+	return symlinks, submodules
+}
+
+// submoduleDescription is the content of the file replacing a submodule
+// that is materialized.
+func submoduleDescription(entry TreeEntry) string {
+	return fmt.Sprintf("This is a git submodule at commit %s. Its content is in another repository, listed in the .gitmodules file of this repository.\n", entry.Sha)
+}
+
+// symlinkTarget returns the entry of tree targeted by the symlink entry,
+// following symlinks to symlinks. Nil is returned if the target is outside
+// the tree or does not exist.
+func (r Repo) symlinkTarget(tree *Tree, entry TreeEntry) (*TreeEntry, error) {
+	byPath := make(map[string]TreeEntry, len(tree.Entries))
+	for _, e := range tree.Entries {
+		byPath[e.Path] = e
+	}
+	for depth := 0; depth < maxSymlinkDepth; depth++ {
+		blob, err := r.GetBlob(entry.Sha)
+		if err != nil {
+			return nil, err
+		}
+		target := string(blob.Content)
+		if path.IsAbs(target) {
+			return nil, nil
+		}
+		target = path.Join(path.Dir(entry.Path), target)
+		if target == ".." || strings.HasPrefix(target, "../") {
+			return nil, nil
+		}
+		targetEntry, ok := byPath[target]
+		if !ok {
+			return nil, nil
+		}
+		if targetEntry.Mode != symlinkMode {
+			return &targetEntry, nil
+		}
+		entry = targetEntry
+	}
+	return nil, nil
+}
+
+// HandleLinks creates a tree from treeish, a tree or commit sha, with
+// symlinks and submodules handled as described by LinkKeep, LinkSkip, and
+// LinkMaterialize, returning the sha of the new tree. Symlinks whose target
+// is outside the tree or does not exist are kept when materializing. If
+// nothing is changed, the sha of the existing tree is returned.
+func (r Repo) HandleLinks(treeish, symlinks, submodules string) (string, error) {
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return "", err
+	}
+	if tree.Truncated {
+		return "", fmt.Errorf("Github truncated the files of tree %q in repository %q, so symlinks and submodules cannot be handled", treeish, r)
+	}
+	var changes []interface{}
+	for _, entry := range tree.Entries {
+		switch {
+		case entry.Mode == symlinkMode && symlinks == LinkSkip, entry.Mode == submoduleMode && submodules == LinkSkip:
+			changes = append(changes, deletedTreeEntry{Path: entry.Path, Mode: entry.Mode, Type: entry.Type})
+		case entry.Mode == symlinkMode && symlinks == LinkMaterialize:
+			target, err := r.symlinkTarget(tree, entry)
+			if err != nil {
+				return "", err
+			}
+			if target != nil {
+				changes = append(changes, TreeEntry{Path: entry.Path, Mode: target.Mode, Type: target.Type, Sha: target.Sha})
+			}
+		case entry.Mode == submoduleMode && submodules == LinkMaterialize:
+			changes = append(changes, contentTreeEntry{Path: entry.Path, Mode: "100644", Type: "blob", Content: submoduleDescription(entry)})
+		}
+	}
+	if len(changes) == 0 {
+		return tree.Sha, nil
+	}
+	return r.changeTree(tree.Sha, changes)
+}
@@ -0,0 +1,111 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+// setLinksResponses sets responses for a tree with symlinks and a
+// submodule.
+func setLinksResponses(fc *prme.FakeClient) {
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", `{"sha": "roottree", "tree": [
+		{"path": "README.md", "mode": "100644", "type": "blob", "sha": "readme", "size": 100},
+		{"path": "docs", "mode": "040000", "type": "tree", "sha": "docstree"},
+		{"path": "docs/readme", "mode": "120000", "type": "blob", "sha": "readmelink", "size": 12},
+		{"path": "docs/passwd", "mode": "120000", "type": "blob", "sha": "passwdlink", "size": 11},
+		{"path": "vendor/lib", "mode": "160000", "type": "commit", "sha": "libcommit"}
+	]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/blobs/readmelink", `{"sha": "readmelink", "encoding": "utf-8", "content": "../README.md"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/blobs/passwdlink", `{"sha": "passwdlink", "encoding": "utf-8", "content": "/etc/passwd"}`)
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/git/trees", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha": "newtree"}`})
+}
+
+func TestHandleLinks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description          string
+		symlinks, submodules string
+		wantTree             string
+		wantChanges          []map[string]interface{}
+	}{
+		{
+			description: "keep",
+			symlinks:    prme.LinkKeep,
+			submodules:  prme.LinkKeep,
+			wantTree:    "roottree",
+		},
+		{
+			description: "skip",
+			symlinks:    prme.LinkSkip,
+			submodules:  prme.LinkSkip,
+			wantTree:    "newtree",
+			wantChanges: []map[string]interface{}{
+				{"path": "docs/readme", "mode": "120000", "type": "blob", "sha": nil},
+				{"path": "docs/passwd", "mode": "120000", "type": "blob", "sha": nil},
+				{"path": "vendor/lib", "mode": "160000", "type": "commit", "sha": nil},
+			},
+		},
+		{
+			description: "materialize",
+			symlinks:    prme.LinkMaterialize,
+			submodules:  prme.LinkMaterialize,
+			wantTree:    "newtree",
+			wantChanges: []map[string]interface{}{
+				{"path": "docs/readme", "mode": "100644", "type": "blob", "sha": "readme"},
+				{"path": "vendor/lib", "mode": "100644", "type": "blob", "content": "This is a git submodule at commit libcommit. Its content is in another repository, listed in the .gitmodules file of this repository.\n"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			fc, err := prme.NewFakeClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			setLinksResponses(fc)
+			r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.HandleLinks("fullsha", tc.symlinks, tc.submodules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.wantTree {
+				t.Errorf("want tree %q, got %q", tc.wantTree, got)
+			}
+			var gotChanges []map[string]interface{}
+			for _, req := range fc.Requests() {
+				if req.Method != http.MethodPost {
+					continue
+				}
+				var treeReq struct {
+					Tree []map[string]interface{} `json:"tree"`
+				}
+				err = json.Unmarshal(req.Body, &treeReq)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotChanges = treeReq.Tree
+			}
+			if !cmp.Equal(tc.wantChanges, gotChanges) {
+				t.Errorf("got incorrect tree changes\ndiff reflects want vs. got: %s", cmp.Diff(tc.wantChanges, gotChanges))
+			}
+		})
+	}
+}
+
+func TestWithSymlinksInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.NewFullPullRequestCreator("owner/repo", prme.WithToken("dummyToken"), prme.WithSymlinks("follow"))
+	if err == nil {
+		t.Fatal("want an error for an unknown symlink handling")
+	}
+}
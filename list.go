@@ -0,0 +1,134 @@
+package prme
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// runListCommand lists the full review pull requests recorded in the state
+// store. Unless offline, the current state of each pull request is
+// retrieved from Github, and open reviews that are stale are marked, or
+// refreshed.
+func runListCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme list", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand lists full review pull requests created by prme, as recorded in the state store.
+
+Usage: %s [flags]
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which prme records created pull requests. This is also set via the PRME_STATE_FILE environment variable.")
+	CLIOffline := fs.Bool("offline", false, "Only use the state store, without making Github API calls. This is also set via the PRME_OFFLINE environment variable.")
+	CLIStaleCommits := fs.Int("stale-commits", 50, "Mark open reviews as stale once the full repository branch has this many commits that are not in the review. Zero disables this check. This is also set via the PRME_STALE_COMMITS environment variable.")
+	CLIStaleDays := fs.Int("stale-days", 30, "Mark open reviews as stale once the oldest commit of the full repository branch that is not in the review is this many days old. Zero disables this check. This is also set via the PRME_STALE_DAYS environment variable.")
+	CLIRefresh := fs.Bool("refresh", false, "Refresh stale reviews, by merging the full repository branch into their head branch. This is also set via the PRME_REFRESH environment variable.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	store, err := NewStateStore(*CLIStateFile)
+	if err != nil {
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if len(st.Reviews) == 0 {
+		fmt.Fprintf(output, "No pull requests are recorded in the state store %s\n", *CLIStateFile)
+		return nil
+	}
+	token, clientOptions, err := CLIClient.apply(os.Getenv("GH_TOKEN"))
+	if err != nil {
+		return err
+	}
+	if !*CLIOffline && token == "" {
+		return fmt.Errorf("Please set the GH_TOKEN environment variable to a Github personal access token, or use the -offline flag to only list the state store.")
+	}
+	policy := StalenessPolicy{
+		MaxCommits: *CLIStaleCommits,
+		MaxAge:     time.Duration(*CLIStaleDays) * 24 * time.Hour,
+	}
+	// Pull request states are applied to the state store when it is saved,
+	// to not lose reviews recorded meanwhile by other runs.
+	type pullRequestID struct {
+		repo   string
+		number int
+	}
+	states := make(map[pullRequestID]string)
+	for _, review := range st.Reviews {
+		var staleness string
+		if !*CLIOffline {
+			r, err := NewRepo(review.Repo, token, clientOptions...)
+			if err != nil {
+				return err
+			}
+			PR, err := r.GetPullRequest(review.Number)
+			if err != nil {
+				return err
+			}
+			review.State = PR.State
+			if PR.Merged {
+				review.State = "merged"
+			}
+			states[pullRequestID{review.Repo, review.Number}] = review.State
+			if review.State == "open" {
+				staleness, err = checkStaleness(r, review, policy, *CLIRefresh)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		fmt.Fprintf(output, "%s\t%s\t%s\t%s%s\n", review.Repo, review.State, review.CreatedAt.Format("2006-01-02"), review.URL, staleness)
+	}
+	if *CLIOffline {
+		return nil
+	}
+	return store.update(func(st *State) {
+		for i, review := range st.Reviews {
+			state, ok := states[pullRequestID{review.Repo, review.Number}]
+			if ok {
+				st.Reviews[i].State = state
+			}
+		}
+	})
+}
+
+// checkStaleness returns a note to display if the open review is stale
+// according to policy, refreshing the review if refresh is true.
+func checkStaleness(r *Repo, review ReviewRecord, policy StalenessPolicy, refresh bool) (string, error) {
+	s, err := r.ReviewStaleness(review.HeadBranch, review.FullRepoBranch)
+	if err != nil {
+		return "", err
+	}
+	if !policy.IsStale(*s, time.Now()) {
+		return "", nil
+	}
+	if !refresh || review.Filtered {
+		return fmt.Sprintf("\tstale, %d commits behind %s since %s", s.CommitsBehind, review.FullRepoBranch, s.Since.Format("2006-01-02")), nil
+	}
+	err = r.refreshRecordedReview(review)
+	if errors.Is(err, ErrMergeConflict) {
+		c, err := r.HandleRefreshConflict(review)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("\tconflicts with %s, resolve them in branch %s", review.FullRepoBranch, c.Branch), nil
+	}
+	if err != nil && !errors.Is(err, ErrAlreadyMerged) {
+		return "", fmt.Errorf("while refreshing %s: %w", review.URL, err)
+	}
+	return fmt.Sprintf("\trefreshed with %d commits from %s", s.CommitsBehind, review.FullRepoBranch), nil
+}
@@ -0,0 +1,165 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalizedText is the default title and body of full review pull requests
+// in a language, and the HowTo template of the comment explaining the review
+// workflow, as described by WithHowToComment.
+type LocalizedText struct {
+	Title, Body, HowTo string
+}
+
+// bundledText is the LocalizedText included with prme, by lower-case
+// language tag.
+var bundledText = map[string]LocalizedText{
+	"en": {
+		Title: defaultTitle,
+		Body:  defaultBody,
+		HowTo: defaultHowTo,
+	},
+	"de": {
+		Title: "Vollständiges Review",
+		Body:  "Ein vollständiges Review des gesamten Repositorys. Wenn dieser PR abgeschlossen ist, muss sein Head-Branch manuell in den Hauptbranch dieses Repositorys gemergt werden.",
+		HowTo: "Dieser Pull Request ist ein vollständiges Review des Branches `{{.FullRepoBranch}}` von {{.Repo}}, erstellt von [prme](https://github.com/ivanfetch/prme). " +
+			"Sein Base-Branch `{{.BaseBranch}}` ist leer, daher erscheint jede Datei des Repositorys als Änderung, die wie in jedem anderen Pull Request kommentiert werden kann.\n\n" +
+			"* Pushe Korrekturen aus dem Review in den Head-Branch `{{.HeadBranch}}`, oder öffne Pull Requests in diesen Branch.\n" +
+			"* Merge diesen Pull Request nicht, da dies das gesamte Repository in den leeren Base-Branch einfügen würde.\n" +
+			"* Wenn das Review abgeschlossen ist, wird dieser Pull Request geschlossen, und die Korrekturen werden von `{{.HeadBranch}}` in `{{.FullRepoBranch}}` gemergt.\n",
+	},
+	"es": {
+		Title: "Revisión completa",
+		Body:  "Una revisión completa de todo el repositorio. Cuando este PR esté completo, asegúrate de fusionar manualmente su rama head en la rama principal de este repositorio.",
+		HowTo: "Este pull request es una revisión completa de la rama `{{.FullRepoBranch}}` de {{.Repo}}, creada por [prme](https://github.com/ivanfetch/prme). " +
+			"Su rama base `{{.BaseBranch}}` está vacía, por lo que cada archivo del repositorio aparece como un cambio, que se puede comentar como en cualquier otro pull request.\n\n" +
+			"* Sube las correcciones encontradas durante la revisión a la rama head `{{.HeadBranch}}`, o abre pull requests hacia esa rama.\n" +
+			"* No fusiones este pull request, ya que añadiría todo el repositorio a la rama base vacía.\n" +
+			"* Cuando la revisión esté completa, se finaliza cerrando este pull request, y las correcciones se fusionan de `{{.HeadBranch}}` en `{{.FullRepoBranch}}`.\n",
+	},
+	"fr": {
+		Title: "Revue complète",
+		Body:  "Une revue complète de l'ensemble du dépôt. Lorsque cette PR est terminée, pensez à fusionner manuellement sa branche head dans la branche principale de ce dépôt.",
+		HowTo: "Cette pull request est une revue complète de la branche `{{.FullRepoBranch}}` de {{.Repo}}, créée par [prme](https://github.com/ivanfetch/prme). " +
+			"Sa branche de base `{{.BaseBranch}}` est vide, donc chaque fichier du dépôt apparaît comme une modification, que l'on peut commenter comme dans toute autre pull request.\n\n" +
+			"* Poussez les corrections trouvées pendant la revue sur la branche head `{{.HeadBranch}}`, ou ouvrez des pull requests vers cette branche.\n" +
+			"* Ne fusionnez pas cette pull request, ce qui ajouterait tout le dépôt à la branche de base vide.\n" +
+			"* Une fois la revue terminée, elle est finalisée en fermant cette pull request, et les corrections sont fusionnées de `{{.HeadBranch}}` dans `{{.FullRepoBranch}}`.\n",
+	},
+	"ja": {
+		Title: "全体レビュー",
+		Body:  "リポジトリ全体のレビューです。このPRが完了したら、必ずheadブランチをこのリポジトリのメインブランチに手動でマージしてください。",
+		HowTo: "このプルリクエストは、[prme](https://github.com/ivanfetch/prme)によって作成された{{.Repo}}の`{{.FullRepoBranch}}`ブランチの全体レビューです。" +
+			"ベースブランチ`{{.BaseBranch}}`は空のため、リポジトリのすべてのファイルが変更として表示され、通常のプルリクエストと同様にコメントできます。\n\n" +
+			"* レビュー中に見つかった修正は、headブランチ`{{.HeadBranch}}`にプッシュするか、そのブランチへのプルリクエストを作成してください。\n" +
+			"* このプルリクエストはマージしないでください。空のベースブランチにリポジトリ全体が追加されてしまいます。\n" +
+			"* レビューが完了すると、このプルリクエストはクローズされて終了し、修正は`{{.HeadBranch}}`から`{{.FullRepoBranch}}`にマージされます。\n",
+	},
+	"pt": {
+		Title: "Revisão completa",
+		Body:  "Uma revisão completa de todo o repositório. Quando este PR estiver concluído, lembre-se de fazer manualmente o merge da branch head na branch principal deste repositório.",
+		HowTo: "Este pull request é uma revisão completa da branch `{{.FullRepoBranch}}` de {{.Repo}}, criada pelo [prme](https://github.com/ivanfetch/prme). " +
+			"Sua branch base `{{.BaseBranch}}` está vazia, então cada arquivo do repositório aparece como uma alteração, que pode ser comentada como em qualquer outro pull request.\n\n" +
+			"* Envie as correções encontradas durante a revisão para a branch head `{{.HeadBranch}}`, ou abra pull requests para essa branch.\n" +
+			"* Não faça o merge deste pull request, o que adicionaria todo o repositório à branch base vazia.\n" +
+			"* Quando a revisão estiver concluída, ela é finalizada fechando este pull request, e as correções são mescladas de `{{.HeadBranch}}` em `{{.FullRepoBranch}}`.\n",
+	},
+}
+
+// Languages returns the language tags of the bundled LocalizedText.
+func Languages() []string {
+	langs := make([]string, 0, len(bundledText))
+	for lang := range bundledText {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Localize returns the LocalizedText for the language tag lang, such as fr
+// or pt-BR. If templateDir is not empty, a <lang>.yaml file in that
+// directory, with title and body keys as described by ParseOrgConfig, takes
+// precedence over the bundled text. A regional tag falls back to its base
+// language.
+func Localize(lang, templateDir string) (LocalizedText, error) {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	if lang == "" {
+		return LocalizedText{}, errors.New("the language cannot be empty")
+	}
+	candidates := []string{lang}
+	if i := strings.Index(lang, "-"); i > 0 {
+		candidates = append(candidates, lang[:i])
+	}
+	for _, candidate := range candidates {
+		if templateDir != "" {
+			text, err := readLocalizedText(filepath.Join(templateDir, candidate+".yaml"))
+			if err == nil {
+				return text, nil
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return LocalizedText{}, err
+			}
+		}
+		if text, ok := bundledText[candidate]; ok {
+			return text, nil
+		}
+	}
+	return LocalizedText{}, fmt.Errorf("no title and body are available for language %q, available languages are: %s", lang, strings.Join(Languages(), ", "))
+}
+
+// readLocalizedText reads a LocalizedText template file, falling back to
+// the English text for a missing title or body.
+func readLocalizedText(path string) (LocalizedText, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LocalizedText{}, err
+	}
+	cfg, err := ParseOrgConfig(data)
+	if err != nil {
+		return LocalizedText{}, fmt.Errorf("while parsing template %s: %w", path, err)
+	}
+	text := bundledText["en"]
+	if cfg.Title != "" {
+		text.Title = cfg.Title
+	}
+	if cfg.Body != "" {
+		text.Body = cfg.Body
+	}
+	if cfg.HowTo != "" {
+		text.HowTo = cfg.HowTo
+	}
+	return text, nil
+}
+
+// WithLanguage uses the title, body, and HowTo template for the language tag
+// lang, as returned by Localize, unless they have been changed from the
+// defaults.
+func WithLanguage(lang, templateDir string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		return f.localize(lang, templateDir)
+	}
+}
+
+// localize sets the title, body, and HowTo template of f for the language tag
+// lang, unless they have been changed from the defaults.
+func (f *FullPullRequestCreator) localize(lang, templateDir string) error {
+	text, err := Localize(lang, templateDir)
+	if err != nil {
+		return err
+	}
+	if f.Title == defaultTitle {
+		f.Title = text.Title
+	}
+	if f.Body == defaultBody {
+		f.Body = text.Body
+	}
+	if f.HowToTemplate == "" {
+		f.HowToTemplate = text.HowTo
+	}
+	return nil
+}
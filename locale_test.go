@@ -0,0 +1,73 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+
+	templateDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(templateDir, "fr.yaml"), []byte("title: Revue de sécurité\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		description, lang, templateDir, wantTitle string
+	}{
+		{description: "bundled language", lang: "es", wantTitle: "Revisión completa"},
+		{description: "regional tag falls back to base language", lang: "pt_BR", wantTitle: "Revisão completa"},
+		{description: "template directory takes precedence", lang: "fr", templateDir: templateDir, wantTitle: "Revue de sécurité"},
+		{description: "bundled language missing from template directory", lang: "de", templateDir: templateDir, wantTitle: "Vollständiges Review"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			got, err := prme.Localize(tc.lang, tc.templateDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantTitle != got.Title {
+				t.Errorf("want title %q, got %q", tc.wantTitle, got.Title)
+			}
+			if got.Body == "" {
+				t.Error("want a body, got an empty one")
+			}
+		})
+	}
+}
+
+func TestLocalizeUnknownLanguage(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.Localize("xx", "")
+	if err == nil {
+		t.Fatal("want an error for an unknown language, got nil")
+	}
+}
+
+func TestWithLanguageKeepsChangedTitle(t *testing.T) {
+	t.Parallel()
+
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithTitle("Custom Title"),
+		prme.WithLanguage("de", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Custom Title" {
+		t.Errorf("want the changed title to be kept, got %q", f.Title)
+	}
+	want, err := prme.Localize("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Body != want.Body {
+		t.Errorf("want the German body %q, got %q", want.Body, f.Body)
+	}
+}
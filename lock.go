@@ -0,0 +1,135 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LockRef is the git reference that prme creates while modifying a
+// repository, so that concurrent prme processes do not race to create the
+// same branches and pull request.
+const LockRef = "prme/lock"
+
+// lockTagName is the name of the tag object that LockRef points at, whose
+// message records which prme process holds the lock.
+const lockTagName = "prme-lock"
+
+// ErrRepoLocked is returned by Lock when another prme process holds the lock
+// for a repository.
+var ErrRepoLocked = errors.New("the repository is locked by another prme process")
+
+// LockHolder is the prme process holding the lock of a repository, as
+// recorded in the lock.
+type LockHolder struct {
+	Owner    string
+	LockedAt time.Time
+}
+
+// lockOwner returns the host and process ID of this process.
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("%s (pid %d)", host, os.Getpid())
+}
+
+// lockMessage returns the message of the lock tag object, recording the
+// owner and time of the lock.
+func lockMessage(owner string, lockedAt time.Time) string {
+	return fmt.Sprintf("Locked by prme\n\nOwner: %s\nLocked-At: %s\n", owner, lockedAt.UTC().Format(time.RFC3339))
+}
+
+// parseLockMessage returns the holder recorded in the message of a lock
+// tag object.
+func parseLockMessage(message string) (*LockHolder, error) {
+	var holder LockHolder
+	for _, line := range strings.Split(message, "\n") {
+		fields := strings.SplitN(line, ": ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "Owner":
+			holder.Owner = fields[1]
+		case "Locked-At":
+			lockedAt, err := time.Parse(time.RFC3339, fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("while parsing the lock time: %w", err)
+			}
+			holder.LockedAt = lockedAt
+		}
+	}
+	if holder.Owner == "" || holder.LockedAt.IsZero() {
+		return nil, errors.New("the lock does not record its owner and time")
+	}
+	return &holder, nil
+}
+
+// Lock creates LockRef in the repository, pointing at a tag object of the
+// commit sha that records the host and process holding the lock and when
+// it was locked, and returns a function that removes it. ErrRepoLocked is
+// returned if the lock is already held, including its holder when known.
+// Creating a git reference is atomic, so this works across processes and
+// machines. A lock left by a process that crashed is removed by BreakLock.
+func (r Repo) Lock(sha string) (unlock func() error, err error) {
+	tagSha, err := r.createTagObject(lockTagName, lockMessage(lockOwner(), r.Client.clock.Now()), sha)
+	if err != nil {
+		return nil, fmt.Errorf("while locking repository %q: %w", r, err)
+	}
+	err = r.CreateRef(LockRef, tagSha)
+	if errors.Is(err, ErrRefExists) {
+		var heldBy string
+		holder, holderErr := r.LockHolder()
+		if holderErr == nil {
+			heldBy = fmt.Sprintf(" %s since %s", holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("%w%s: if no other prme process is running, use the -break-lock flag, or delete the refs/%s reference in repository %q", ErrRepoLocked, heldBy, qualifiedRef(LockRef), r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while locking repository %q: %w", r, err)
+	}
+	return func() error {
+		err := r.DeleteRef(LockRef)
+		if err != nil {
+			return fmt.Errorf("while unlocking repository %q: %w", r, err)
+		}
+		return nil
+	}, nil
+}
+
+// LockHolder returns the holder recorded in the lock of the repository.
+// ErrRefNotFound is returned if the repository is not locked.
+func (r Repo) LockHolder() (*LockHolder, error) {
+	sha, err := r.GetRef(LockRef)
+	if err != nil {
+		return nil, err
+	}
+	message, err := r.getTagMessage(sha)
+	if err != nil {
+		return nil, err
+	}
+	return parseLockMessage(message)
+}
+
+// BreakLock removes the lock of the repository, such as one left by a prme
+// process that crashed, and returns its holder. Nothing is done, and nil is
+// returned, if the repository is not locked. An error is returned, without
+// removing the lock, if the lock does not record its holder.
+func (r Repo) BreakLock() (*LockHolder, error) {
+	holder, err := r.LockHolder()
+	if errors.Is(err, ErrRefNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while reading the lock of repository %q: %w", r, err)
+	}
+	err = r.DeleteRef(LockRef)
+	if err != nil {
+		return nil, fmt.Errorf("while breaking the lock of repository %q: %w", r, err)
+	}
+	return holder, nil
+}
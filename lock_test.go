@@ -0,0 +1,175 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeLockServer returns a test server supporting the API requests to
+// lock and unlock ivanfetch/ghapitest, and a function returning whether
+// the repository is locked.
+func newFakeLockServer(t *testing.T) (*httptest.Server, func() bool) {
+	var locked bool
+	var lockTagMessage string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.RequestURI == "/repos/ivanfetch/ghapitest/git/tags":
+			var tag struct{ Message string }
+			err := json.NewDecoder(r.Body).Decode(&tag)
+			if err != nil {
+				t.Error(err)
+			}
+			if !locked {
+				lockTagMessage = tag.Message
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha":"d2b8f97a27554711c1eb0d1bb0f8f623a2af2587"}`)
+		case r.Method == http.MethodPost && r.RequestURI == "/repos/ivanfetch/ghapitest/git/refs":
+			if locked {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"message":"Reference already exists"}`)
+				return
+			}
+			locked = true
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.RequestURI == "/repos/ivanfetch/ghapitest/git/ref/prme/lock":
+			if !locked {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, `{"ref":"refs/prme/lock","object":{"sha":"d2b8f97a27554711c1eb0d1bb0f8f623a2af2587"}}`)
+		case r.Method == http.MethodGet && r.RequestURI == "/repos/ivanfetch/ghapitest/git/tags/d2b8f97a27554711c1eb0d1bb0f8f623a2af2587":
+			json.NewEncoder(w).Encode(struct {
+				Message string `json:"message"`
+			}{lockTagMessage})
+		case r.Method == http.MethodDelete && r.RequestURI == "/repos/ivanfetch/ghapitest/git/refs/prme/lock":
+			locked = false
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request for Github URL %q", r.Method, r.RequestURI)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() bool { return locked }
+}
+
+func TestLockIsExclusive(t *testing.T) {
+	t.Parallel()
+
+	ts, locked := newFakeLockServer(t)
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := r.Lock("87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Lock("87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if !errors.Is(err, prme.ErrRepoLocked) {
+		t.Fatalf("want error %v while the lock is held, got %v", prme.ErrRepoLocked, err)
+	}
+	if !strings.Contains(err.Error(), "pid") || strings.Contains(err.Error(), "refs/refs/") {
+		t.Fatalf("want the error to include the lock holder and reference, got %v", err)
+	}
+	err = unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked() {
+		t.Fatal("want the lock reference to be deleted")
+	}
+}
+
+func TestBreakLockRemovesALeftoverLock(t *testing.T) {
+	t.Parallel()
+
+	lockedAt := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	ts, locked := newFakeLockServer(t)
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithClientClock(prme.NewManualClock(lockedAt)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock is left by a process that crashed.
+	_, err = r.Lock("87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder, err := r.BreakLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder == nil || !holder.LockedAt.Equal(lockedAt) || !strings.Contains(holder.Owner, "pid") {
+		t.Fatalf("want the holder of the broken lock, locked at %v, got %+v", lockedAt, holder)
+	}
+	if locked() {
+		t.Fatal("want the lock reference to be deleted")
+	}
+	holder, err = r.BreakLock()
+	if err != nil || holder != nil {
+		t.Fatalf("want nothing done without a lock, got holder %+v and error %v", holder, err)
+	}
+}
+
+func TestCreateRefOnlyReturnsErrRefExistsForExistingReferences(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/git/refs", prme.FakeResponse{
+		StatusCode: http.StatusUnprocessableEntity,
+		Body:       `{"message":"Object does not exist"}`,
+	})
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CreateRef("prme/lock", "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if err == nil || errors.Is(err, prme.ErrRefExists) {
+		t.Fatalf("want an error other than %v for a missing commit, got %v", prme.ErrRefExists, err)
+	}
+}
+
+func TestBreakLockRejectsALockWithoutAHolder(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock points at a commit rather than a tag object recording its
+	// holder.
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/prme/lock", `{"ref":"refs/prme/lock","object":{"sha":"87d2b8f97a27554711c1eb0d1bb0f8f623a2af25"}}`)
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/tags/87d2b8f97a27554711c1eb0d1bb0f8f623a2af25", prme.FakeResponse{StatusCode: http.StatusNotFound})
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.BreakLock()
+	if err == nil {
+		t.Fatal("want an error breaking a lock that does not record its holder, got none")
+	}
+	for _, req := range fc.Requests() {
+		if req.Method == http.MethodDelete {
+			t.Errorf("want the lock kept, got %s %s", req.Method, req.URI)
+		}
+	}
+}
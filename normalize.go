@@ -0,0 +1,99 @@
+package prme
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxNormalizeSize is the largest file, in bytes, whose text is normalized.
+// Larger files are left as they are, as each file normalized is another
+// Github API request.
+const maxNormalizeSize = 1 << 20
+
+// Byte order marks, which begin some text files.
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// WithNormalizedText normalizes text files in the review, converting CRLF
+// line endings to LF, removing UTF-8 byte order marks, and converting
+// UTF-16 to UTF-8, so the pull request diff is not dominated by those
+// differences. The full repository branch is not changed.
+func WithNormalizedText() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.NormalizeText = true
+		return nil
+	}
+}
+
+// normalizeText returns content as UTF-8 with LF line endings and without a
+// byte order mark, and whether it was changed. Content that is not text is
+// returned unchanged.
+func normalizeText(content []byte) ([]byte, bool) {
+	normalized := content
+	switch {
+	case bytes.HasPrefix(content, utf16LEBOM), bytes.HasPrefix(content, utf16BEBOM):
+		if len(content)%2 != 0 {
+			return content, false
+		}
+		var order binary.ByteOrder = binary.LittleEndian
+		if bytes.HasPrefix(content, utf16BEBOM) {
+			order = binary.BigEndian
+		}
+		units := make([]uint16, 0, len(content)/2-1)
+		for i := 2; i < len(content); i += 2 {
+			units = append(units, order.Uint16(content[i:]))
+		}
+		normalized = []byte(string(utf16.Decode(units)))
+	case bytes.IndexByte(content, 0) != -1 || !utf8.Valid(content):
+		// Binary files, or text in another encoding.
+		return content, false
+	}
+	normalized = bytes.TrimPrefix(normalized, utf8BOM)
+	normalized = bytes.ReplaceAll(normalized, []byte("\r\n"), []byte("\n"))
+	return normalized, !bytes.Equal(normalized, content)
+}
+
+// NormalizeText creates a tree from treeish, a tree or commit sha, whose text
+// files are normalized as described by WithNormalizedText, returning the sha
+// of the new tree and the paths of the normalized files. Files larger than
+// 1MiB are not normalized. If no files are normalized, the sha of the
+// existing tree is returned.
+func (r Repo) NormalizeText(treeish string) (treeSha string, normalized []string, err error) {
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return "", nil, err
+	}
+	if tree.Truncated {
+		return "", nil, fmt.Errorf("Github truncated the files of tree %q in repository %q, so text cannot be normalized", treeish, r)
+	}
+	var changes []interface{}
+	for _, entry := range tree.Entries {
+		if entry.Type != "blob" || entry.Mode == symlinkMode || entry.Size == 0 || entry.Size > maxNormalizeSize {
+			continue
+		}
+		blob, err := r.GetBlob(entry.Sha)
+		if err != nil {
+			return "", nil, err
+		}
+		content, changed := normalizeText(blob.Content)
+		if !changed {
+			continue
+		}
+		changes = append(changes, contentTreeEntry{Path: entry.Path, Mode: entry.Mode, Type: entry.Type, Content: string(content)})
+		normalized = append(normalized, entry.Path)
+	}
+	if len(changes) == 0 {
+		return tree.Sha, nil, nil
+	}
+	treeSha, err = r.changeTree(tree.Sha, changes)
+	if err != nil {
+		return "", nil, err
+	}
+	return treeSha, normalized, nil
+}
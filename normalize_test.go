@@ -0,0 +1,76 @@
+package prme_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	t.Parallel()
+
+	files := []struct {
+		path, content string
+	}{
+		{path: "crlf.txt", content: "one\r\ntwo\r\n"},
+		{path: "bom.txt", content: "\xef\xbb\xbfbom\n"},
+		{path: "utf16.txt", content: "\xff\xfeh\x00i\x00\r\x00\n\x00"},
+		{path: "image.png", content: "\x89PNG\r\n\x00\x00"},
+		{path: "lf.txt", content: "already\nnormalized\n"},
+	}
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]interface{}
+	for _, file := range files {
+		entries = append(entries, map[string]interface{}{"path": file.path, "mode": "100644", "type": "blob", "sha": file.path, "size": len(file.content)})
+		fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/blobs/"+file.path, fmt.Sprintf(`{"sha": %q, "encoding": "base64", "content": %q}`, file.path, base64.StdEncoding.EncodeToString([]byte(file.content))))
+	}
+	treeJSON, err := json.Marshal(map[string]interface{}{"sha": "roottree", "tree": entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", string(treeJSON))
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/git/trees", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha": "newtree"}`})
+
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeSha, normalized, err := r.NormalizeText("fullsha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if treeSha != "newtree" {
+		t.Errorf("want tree %q, got %q", "newtree", treeSha)
+	}
+	wantNormalized := []string{"crlf.txt", "bom.txt", "utf16.txt"}
+	if !cmp.Equal(wantNormalized, normalized) {
+		t.Errorf("got incorrect normalized files\ndiff reflects want vs. got: %s", cmp.Diff(wantNormalized, normalized))
+	}
+	var treeReq struct {
+		Tree []struct{ Path, Content string }
+	}
+	requests := fc.Requests()
+	err = json.Unmarshal(requests[len(requests)-1].Body, &treeReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, entry := range treeReq.Tree {
+		got[entry.Path] = entry.Content
+	}
+	want := map[string]string{
+		"crlf.txt":  "one\ntwo\n",
+		"bom.txt":   "bom\n",
+		"utf16.txt": "hi\n",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("got incorrect normalized content\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,134 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NotesRef is the git notes reference to which prme adds review notes. Fetch
+// it with: git fetch origin refs/notes/prme:refs/notes/prme
+const NotesRef = "notes/prme"
+
+// ReviewNote returns the git note recording a full review of a commit, in
+// the form of git trailers.
+func ReviewNote(PRURL string, reviewedAt time.Time) string {
+	return fmt.Sprintf("Full-Review: %s\nReviewed-At: %s\nCreated-By: prme %s\n", PRURL, reviewedAt.UTC().Format(time.RFC3339), Version)
+}
+
+// AddNote adds note to the commit sha, in the git notes reference notesRef,
+// such as NotesRef. An existing note for the commit is replaced.
+func (r Repo) AddNote(notesRef, sha, note string) error {
+	var parents []string
+	var baseTree string
+	parentSha, err := r.GetRef(notesRef)
+	if err != nil && !errors.Is(err, ErrRefNotFound) {
+		return err
+	}
+	if err == nil {
+		parent, err := r.GetCommit(parentSha)
+		if err != nil {
+			return err
+		}
+		parents = []string{parentSha}
+		baseTree = parent.Tree.Sha
+	}
+	// Notes are files named by the sha of the commit they annotate.
+	treeSha, err := r.createTree(baseTree, sha, note)
+	if err != nil {
+		return err
+	}
+	commitSha, err := r.createCommit("Notes added by prme", treeSha, parents)
+	if err != nil {
+		return err
+	}
+	if len(parents) == 0 {
+		return r.CreateRef(notesRef, commitSha)
+	}
+	return r.UpdateRef(notesRef, commitSha, false)
+}
+
+// createTree creates a git tree from baseTree, which can be empty, with the
+// file path containing content, and returns the sha of the new tree.
+func (r Repo) createTree(baseTree, path, content string) (sha string, err error) {
+	type treeEntry struct {
+		Path    string `json:"path"`
+		Mode    string `json:"mode"`
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	treeJSON, err := json.Marshal(struct {
+		BaseTree string      `json:"base_tree,omitempty"`
+		Tree     []treeEntry `json:"tree"`
+	}{
+		BaseTree: baseTree,
+		Tree:     []treeEntry{{Path: path, Mode: "100644", Type: "blob", Content: content}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return r.postTree(treeJSON)
+}
+
+// postTree creates the git tree described by treeJSON, the body of a Github
+// API request, and returns the sha of the new tree.
+func (r Repo) postTree(treeJSON []byte) (sha string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/trees", r)
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, treeJSON)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("HTTP %d for %s while creating a tree in repository %q", resp.StatusCode, apiURI, r)
+	}
+	var tree Tree
+	err = json.NewDecoder(resp.Body).Decode(&tree)
+	if err != nil {
+		return "", err
+	}
+	if tree.Sha == "" {
+		return "", fmt.Errorf("the Github API did not return a sha while creating a tree in repository %q", r)
+	}
+	return tree.Sha, nil
+}
+
+// createCommit creates a git commit of treeSha, with parents, without
+// updating any branch, and returns the sha of the new commit.
+func (r Repo) createCommit(message, treeSha string, parents []string) (sha string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/commits", r)
+	if parents == nil {
+		parents = []string{}
+	}
+	commitJSON, err := json.Marshal(struct {
+		Message string   `json:"message"`
+		Tree    string   `json:"tree"`
+		Parents []string `json:"parents"`
+	}{
+		Message: message,
+		Tree:    treeSha,
+		Parents: parents,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, commitJSON)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("HTTP %d for %s while creating a commit in repository %q", resp.StatusCode, apiURI, r)
+	}
+	var c Commit
+	err = json.NewDecoder(resp.Body).Decode(&c)
+	if err != nil {
+		return "", err
+	}
+	if c.Sha == "" {
+		return "", fmt.Errorf("the Github API did not return a sha while creating a commit in repository %q", r)
+	}
+	return c.Sha, nil
+}
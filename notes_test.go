@@ -0,0 +1,100 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddNoteToExistingNotes(t *testing.T) {
+	t.Parallel()
+
+	var gotRequests []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.RequestURI)
+		switch r.Method + " " + r.RequestURI {
+		case "GET /repos/ivanfetch/ghapitest/git/ref/notes/prme":
+			fmt.Fprint(w, `{"ref": "refs/notes/prme", "object": {"sha": "1111111111111111111111111111111111111111"}}`)
+		case "GET /repos/ivanfetch/ghapitest/git/commits/1111111111111111111111111111111111111111":
+			fmt.Fprint(w, `{"sha": "1111111111111111111111111111111111111111", "tree": {"sha": "2222222222222222222222222222222222222222"}}`)
+		case "POST /repos/ivanfetch/ghapitest/git/trees":
+			var got struct {
+				BaseTree string `json:"base_tree"`
+				Tree     []struct{ Path, Content string }
+			}
+			err := json.NewDecoder(r.Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.BaseTree != "2222222222222222222222222222222222222222" || len(got.Tree) != 1 ||
+				got.Tree[0].Path != "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25" || got.Tree[0].Content != "reviewed\n" {
+				t.Errorf("got incorrect notes tree %+v", got)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha": "3333333333333333333333333333333333333333"}`)
+		case "POST /repos/ivanfetch/ghapitest/git/commits":
+			var got struct {
+				Tree    string
+				Parents []string
+			}
+			err := json.NewDecoder(r.Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Tree != "3333333333333333333333333333333333333333" || !cmp.Equal([]string{"1111111111111111111111111111111111111111"}, got.Parents) {
+				t.Errorf("got incorrect notes commit %+v", got)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha": "4444444444444444444444444444444444444444"}`)
+		case "PATCH /repos/ivanfetch/ghapitest/git/refs/notes/prme":
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.AddNote(prme.NotesRef, "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25", "reviewed\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"GET /repos/ivanfetch/ghapitest/git/ref/notes/prme",
+		"GET /repos/ivanfetch/ghapitest/git/commits/1111111111111111111111111111111111111111",
+		"POST /repos/ivanfetch/ghapitest/git/trees",
+		"POST /repos/ivanfetch/ghapitest/git/commits",
+		"PATCH /repos/ivanfetch/ghapitest/git/refs/notes/prme",
+	}
+	if !cmp.Equal(wantRequests, gotRequests) {
+		t.Fatalf("got incorrect requests\ndiff reflects want vs. got: %s", cmp.Diff(wantRequests, gotRequests))
+	}
+}
+
+func TestReviewNote(t *testing.T) {
+	t.Parallel()
+
+	got := prme.ReviewNote("https://github.com/ivanfetch/ghapitest/pull/7", time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC))
+	for _, want := range []string{
+		"Full-Review: https://github.com/ivanfetch/ghapitest/pull/7\n",
+		"Reviewed-At: 2021-08-21T03:10:25Z\n",
+		"Created-By: prme " + prme.Version + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want note to contain %q, got %q", want, got)
+		}
+	}
+}
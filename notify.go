@@ -0,0 +1,200 @@
+package prme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier is notified of events during the lifetime of full reviews, such
+// as to post to chat, email, or paging systems. Register a Notifier using
+// WithNotifier.
+type Notifier interface {
+	// PRCreated is called after a full review pull request is created.
+	PRCreated(repo string, PR *PullRequest) error
+	// PRMerged is called when prme serve observes that a full review pull
+	// request has been merged.
+	PRMerged(repo string, PR *PullRequest) error
+	// RunFailed is called when creating a full review pull request fails.
+	RunFailed(repo string, err error) error
+}
+
+// Notification event names, as included in a NotificationEvent.
+const (
+	EventPRCreated = "pr_created"
+	EventPRMerged  = "pr_merged"
+	EventRunFailed = "run_failed"
+)
+
+// NotificationEvent is the JSON representation of an event, as sent by
+// WebhookNotifier and WriterNotifier.
+type NotificationEvent struct {
+	Event          string    `json:"event"`
+	Repo           string    `json:"repo"`
+	PullRequestURL string    `json:"pull_request_url,omitempty"`
+	Number         int       `json:"number,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	At             time.Time `json:"at"`
+}
+
+func newNotificationEvent(event, repo string, PR *PullRequest, err error) NotificationEvent {
+	e := NotificationEvent{
+		Event: event,
+		Repo:  repo,
+		At:    time.Now().UTC(),
+	}
+	if PR != nil {
+		e.PullRequestURL = PR.HTMLURL
+		e.Number = PR.Number
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// WithNotifier registers n to be notified of events, in addition to
+// notifiers already registered.
+func WithNotifier(n Notifier) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if n == nil {
+			return errors.New("the notifier cannot be nil")
+		}
+		f.notifiers = append(f.notifiers, n)
+		return nil
+	}
+}
+
+// notify calls event for each registered notifier. Notifications are best
+// effort, so failures are only displayed to errOutput, if set.
+func (f FullPullRequestCreator) notify(event func(Notifier) error) {
+	for _, n := range f.notifiers {
+		err := event(n)
+		if err != nil && f.errOutput != nil {
+			fmt.Fprintf(f.errOutput, "while sending a notification: %v\n", Redact(err.Error(), f.token))
+		}
+	}
+}
+
+// warn displays a warning to f.errOutput, if it is set.
+func (f FullPullRequestCreator) warn(format string, args ...interface{}) {
+	if f.errOutput != nil {
+		fmt.Fprintf(f.errOutput, "Warning: "+format+"\n", args...)
+	}
+}
+
+// progress displays a step of creating the review of f.Repo to f.output, if
+// it is set.
+func (f FullPullRequestCreator) progress(format string, args ...interface{}) {
+	if f.output != nil {
+		fmt.Fprintf(f.output, "%s: "+format+"\n", append([]interface{}{f.Repo}, args...)...)
+	}
+}
+
+// WithOutput displays each step of creating a review to w, such as creating
+// branches and labeling the pull request.
+func WithOutput(w io.Writer) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if w == nil {
+			return errors.New("the output cannot be nil")
+		}
+		f.output = w
+		return nil
+	}
+}
+
+// WithErrOutput displays warnings, and failures to send notifications, to
+// w.
+func WithErrOutput(w io.Writer) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if w == nil {
+			return errors.New("the error output cannot be nil")
+		}
+		f.errOutput = w
+		return nil
+	}
+}
+
+// SlackNotifier posts events to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s SlackNotifier) PRCreated(repo string, PR *PullRequest) error {
+	return PostSlackMessage(s.WebhookURL, fmt.Sprintf("A full review of %s has been created at %s", repo, PR.HTMLURL))
+}
+
+func (s SlackNotifier) PRMerged(repo string, PR *PullRequest) error {
+	return PostSlackMessage(s.WebhookURL, fmt.Sprintf("The full review %s of %s has been merged", PR.HTMLURL, repo))
+}
+
+func (s SlackNotifier) RunFailed(repo string, err error) error {
+	return PostSlackMessage(s.WebhookURL, fmt.Sprintf("Creating a full review of %s failed: %v", repo, err))
+}
+
+// WebhookNotifier POSTs each event as a JSON NotificationEvent to URL.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (wh WebhookNotifier) post(e NotificationEvent) error {
+	eventJSON, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	hc := &http.Client{Timeout: 10 * time.Second}
+	resp, err := hc.Post(wh.URL, "application/json", bytes.NewReader(eventJSON))
+	if err != nil {
+		// The webhook URL can include credentials, which errors from the HTTP
+		// client include.
+		return fmt.Errorf("while posting a %s notification: %s", e.Event, strings.ReplaceAll(err.Error(), wh.URL, redactedText))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d while posting a %s notification", resp.StatusCode, e.Event)
+	}
+	return nil
+}
+
+func (wh WebhookNotifier) PRCreated(repo string, PR *PullRequest) error {
+	return wh.post(newNotificationEvent(EventPRCreated, repo, PR, nil))
+}
+
+func (wh WebhookNotifier) PRMerged(repo string, PR *PullRequest) error {
+	return wh.post(newNotificationEvent(EventPRMerged, repo, PR, nil))
+}
+
+func (wh WebhookNotifier) RunFailed(repo string, err error) error {
+	return wh.post(newNotificationEvent(EventRunFailed, repo, nil, err))
+}
+
+// WriterNotifier writes each event as a line of JSON to W, such as standard
+// output. It is safe for concurrent use.
+type WriterNotifier struct {
+	mu sync.Mutex
+	W  io.Writer
+}
+
+func (wn *WriterNotifier) write(e NotificationEvent) error {
+	wn.mu.Lock()
+	defer wn.mu.Unlock()
+	return json.NewEncoder(wn.W).Encode(e)
+}
+
+func (wn *WriterNotifier) PRCreated(repo string, PR *PullRequest) error {
+	return wn.write(newNotificationEvent(EventPRCreated, repo, PR, nil))
+}
+
+func (wn *WriterNotifier) PRMerged(repo string, PR *PullRequest) error {
+	return wn.write(newNotificationEvent(EventPRMerged, repo, PR, nil))
+}
+
+func (wn *WriterNotifier) RunFailed(repo string, err error) error {
+	return wn.write(newNotificationEvent(EventRunFailed, repo, nil, err))
+}
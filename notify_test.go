@@ -0,0 +1,125 @@
+package prme_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingNotifier records the events it is notified of.
+type recordingNotifier struct {
+	events []string
+}
+
+func (rn *recordingNotifier) PRCreated(repo string, PR *prme.PullRequest) error {
+	rn.events = append(rn.events, "created "+repo)
+	return nil
+}
+
+func (rn *recordingNotifier) PRMerged(repo string, PR *prme.PullRequest) error {
+	rn.events = append(rn.events, "merged "+repo)
+	return nil
+}
+
+func (rn *recordingNotifier) RunFailed(repo string, err error) error {
+	rn.events = append(rn.events, "failed "+repo+": "+err.Error())
+	return errors.New("unable to notify")
+}
+
+func TestCreateNotifiesRunFailed(t *testing.T) {
+	t.Parallel()
+
+	rn := &recordingNotifier{}
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest", prme.WithNotifier(rn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Title = ""
+	_, err = f.CreateWithResult()
+	if err == nil {
+		t.Fatal("want an error creating a pull request with an empty title")
+	}
+	want := "failed ivanfetch/ghapitest: the title cannot be empty"
+	if len(rn.events) != 1 || rn.events[0] != want {
+		t.Fatalf("want event %q, got %q", want, rn.events)
+	}
+}
+
+func TestWithErrOutputDisplaysNotificationFailures(t *testing.T) {
+	t.Parallel()
+
+	var errOutput bytes.Buffer
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithNotifier(&recordingNotifier{}),
+		prme.WithErrOutput(&errOutput),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Title = ""
+	_, err = f.CreateWithResult()
+	if err == nil {
+		t.Fatal("want an error creating a pull request with an empty title")
+	}
+	want := "while sending a notification: unable to notify\n"
+	if want != errOutput.String() {
+		t.Fatalf("want error output %q, got %q", want, errOutput.String())
+	}
+}
+
+func TestWithOutputRejectsNil(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest", prme.WithOutput(nil))
+	if err == nil {
+		t.Fatal("want an error for a nil output")
+	}
+	_, err = prme.NewFullPullRequestCreator("ivanfetch/ghapitest", prme.WithErrOutput(nil))
+	if err == nil {
+		t.Fatal("want an error for a nil error output")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	var got prme.NotificationEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	wh := prme.WebhookNotifier{URL: ts.URL}
+	err := wh.PRCreated("ivanfetch/ghapitest", &prme.PullRequest{Number: 7, HTMLURL: "https://github.com/ivanfetch/ghapitest/pull/7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Event != prme.EventPRCreated || got.Repo != "ivanfetch/ghapitest" || got.Number != 7 || got.PullRequestURL != "https://github.com/ivanfetch/ghapitest/pull/7" {
+		t.Fatalf("got incorrect event %+v", got)
+	}
+}
+
+func TestWriterNotifier(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+	wn := &prme.WriterNotifier{W: &output}
+	err := wn.RunFailed("ivanfetch/ghapitest", errors.New("rate limited"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"event":"run_failed"`, `"repo":"ivanfetch/ghapitest"`, `"error":"rate limited"`} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("want output to contain %s, got %s", want, output.String())
+		}
+	}
+}
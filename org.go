@@ -0,0 +1,243 @@
+package prme
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrRepoExcluded is returned when creating a pull request for a repository
+// that is excluded, or not allowed, by the repository filters.
+var ErrRepoExcluded = errors.New("the repository is excluded by the repository filters")
+
+// ListOwnerRepositories returns all repositories of the organization or user
+// owner.
+func (c *Client) ListOwnerRepositories(owner string) ([]Repository, error) {
+	if owner == "" {
+		return nil, errors.New("the owner cannot be empty")
+	}
+	apiURI := fmt.Sprintf("/orgs/%s/repos", owner)
+	resp, err := c.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// The owner may be a user instead of an organization.
+		apiURI = fmt.Sprintf("/users/%s/repos", owner)
+	}
+	var repos []Repository
+	err = c.getAllPages(apiURI, func(body io.Reader) error {
+		var page []Repository
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing repositories of %q: %w", owner, err)
+	}
+	return repos, nil
+}
+
+// matchRepoPattern returns true if the shell pattern matches repo, of the
+// form OwnerName/RepositoryName, ignoring case. A pattern without a slash
+// only matches the repository name.
+func matchRepoPattern(pattern, repo string) bool {
+	pattern = strings.ToLower(pattern)
+	repo = strings.ToLower(repo)
+	if !strings.Contains(pattern, "/") {
+		repo = repo[strings.LastIndex(repo, "/")+1:]
+	}
+	ok, err := path.Match(pattern, repo)
+	return err == nil && ok
+}
+
+// repoAllowed returns true if repo is not excluded, and is allowed when an
+// allowlist is in use.
+func (f FullPullRequestCreator) repoAllowed(repo string) bool {
+	for _, pattern := range f.ExcludeRepos {
+		if matchRepoPattern(pattern, repo) {
+			return false
+		}
+	}
+	if len(f.AllowRepos) == 0 {
+		return true
+	}
+	for _, pattern := range f.AllowRepos {
+		if matchRepoPattern(pattern, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithExcludeRepos excludes repositories matching any of the shell patterns,
+// such as myorg/infra-* or archive-*.
+func WithExcludeRepos(patterns ...string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		for _, pattern := range patterns {
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+			}
+		}
+		f.ExcludeRepos = append(f.ExcludeRepos, patterns...)
+		return nil
+	}
+}
+
+// WithAllowRepos only allows repositories matching any of the shell
+// patterns.
+func WithAllowRepos(patterns ...string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		for _, pattern := range patterns {
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+			}
+		}
+		f.AllowRepos = append(f.AllowRepos, patterns...)
+		return nil
+	}
+}
+
+// readAllowlistFile returns the repository patterns in fileName, one per
+// line, ignoring blank lines and those beginning with #.
+func readAllowlistFile(fileName string) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, err := path.Match(line, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %q in allowlist file %s: %w", line, fileName, err)
+		}
+		patterns = append(patterns, line)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("the allowlist file %s does not contain any repositories", fileName)
+	}
+	return patterns, nil
+}
+
+// OwnerRepos returns the names of all repositories of the organization or
+// user owner, excluding archived repositories and those without the Topic
+// of f, if it is set.
+func (f FullPullRequestCreator) OwnerRepos(owner string) ([]string, error) {
+	c, err := NewClient(f.token, f.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := c.ListOwnerRepositories(owner)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, repo := range repos {
+		if repo.Archived {
+			continue
+		}
+		if f.Topic != "" && !stringsContain(repo.Topics, f.Topic) {
+			continue
+		}
+		names = append(names, repo.FullName)
+	}
+	return names, nil
+}
+
+// isRepoPattern returns true if repoName is a shell pattern, such as
+// myorg/service-*, instead of a single repository.
+func isRepoPattern(repoName string) bool {
+	return strings.ContainsAny(repoName, "*?[")
+}
+
+// ExpandRepoPatterns returns repoNames, with each shell pattern of the form
+// OwnerName/Pattern, such as myorg/service-*, replaced by the non-archived
+// repositories of the owner that match it. The repositories of each owner
+// are only listed once, and an error is returned if a pattern matches no
+// repositories.
+func (f FullPullRequestCreator) ExpandRepoPatterns(repoNames []string) ([]string, error) {
+	ownerRepos := make(map[string][]string)
+	var expanded []string
+	for _, repoName := range repoNames {
+		if !isRepoPattern(repoName) {
+			if !stringsContain(expanded, repoName) {
+				expanded = append(expanded, repoName)
+			}
+			continue
+		}
+		parts := strings.Split(repoName, "/")
+		if len(parts) != 2 || parts[0] == "" || isRepoPattern(parts[0]) {
+			return nil, fmt.Errorf("the repository pattern %q must be of the form OwnerName/Pattern, such as myorg/service-*", repoName)
+		}
+		_, err := path.Match(repoName, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %q: %w", repoName, err)
+		}
+		owner := strings.ToLower(parts[0])
+		repos, ok := ownerRepos[owner]
+		if !ok {
+			repos, err = f.OwnerRepos(parts[0])
+			if err != nil {
+				return nil, err
+			}
+			ownerRepos[owner] = repos
+		}
+		var matched bool
+		for _, repo := range repos {
+			if !matchRepoPattern(repoName, repo) {
+				continue
+			}
+			matched = true
+			if !stringsContain(expanded, repo) {
+				expanded = append(expanded, repo)
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no repositories of %q match the pattern %q", parts[0], repoName)
+		}
+	}
+	return expanded, nil
+}
+
+// stringsFlag is a command-line flag that can be specified multiple times,
+// or as a comma-separated list.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package prme_test
+
+import (
+	"encoding/pem"
+	"errors"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListOwnerRepositoriesFallsBackToUser(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestListOwnerRepositories.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/orgs/ivanfetch/repos":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/users/ivanfetch/repos?per_page=100":
+		default:
+			t.Errorf("unexpected Github URL %q", r.RequestURI)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := c.ListOwnerRepositories("ivanfetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, repo := range repos {
+		got = append(got, repo.FullName)
+	}
+	want := []string{"ivanfetch/ghapitest", "ivanfetch/old-project"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect repositories\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestCreateBatchSkipsFilteredRepos(t *testing.T) {
+	t.Parallel()
+
+	f, err := prme.NewFullPullRequestCreator("dummyRepo",
+		prme.WithToken("dummyToken"),
+		prme.WithExcludeRepos("myorg/infra-*", "archive-*"),
+		prme.WithAllowRepos("myorg/*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := []string{"myorg/infra-dns", "myorg/archive-2019", "otherorg/service"}
+	results := f.CreateBatch(repos, io.Discard)
+	if len(results) != len(repos) {
+		t.Fatalf("want %d results, got %d", len(repos), len(results))
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, prme.ErrRepoExcluded) {
+			t.Errorf("want repository %s to be excluded, got error %v", result.Repo, result.Err)
+		}
+	}
+}
+
+func TestCreateBatchReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	var connections int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	ts.StartTLS()
+	defer ts.Close()
+	// Trusting the test server certificate customizes the transport of
+	// each API client.
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caCertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := prme.NewFullPullRequestCreator("dummyRepo",
+		prme.WithToken("dummyToken"),
+		prme.WithClientOptions(
+			prme.WithBaseURL(ts.URL),
+			prme.WithCACertificate(caCertFile),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Preflight checks of each repository run concurrently, using up to 4
+	// connections, which are reused for the following repositories.
+	repos := []string{"myorg/one", "myorg/two", "myorg/three", "myorg/four", "myorg/five", "myorg/six"}
+	results := f.CreateBatch(repos, io.Discard)
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("want an error for repository %s, which does not exist", result.Repo)
+		}
+	}
+	got := atomic.LoadInt32(&connections)
+	if got > 4 {
+		t.Errorf("want at most 4 connections reused for all %d repositories, got %d", len(repos), got)
+	}
+}
+
+func TestExpandRepoPatterns(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/orgs/myorg/repos", `[
+		{"full_name": "myorg/service-api"},
+		{"full_name": "myorg/service-web"},
+		{"full_name": "myorg/service-old", "archived": true},
+		{"full_name": "myorg/infra"}
+	]`)
+	f, err := prme.NewFullPullRequestCreator("myorg/service-*", prme.WithToken("dummyToken"), prme.WithAPIMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.ExpandRepoPatterns([]string{"myorg/service-*", "myorg/service-api", "other/repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"myorg/service-api", "myorg/service-web", "other/repo"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect repositories\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+	_, err = f.ExpandRepoPatterns([]string{"myorg/nothing-*"})
+	if err == nil {
+		t.Fatal("want an error when a pattern matches no repositories")
+	}
+	_, err = f.ExpandRepoPatterns([]string{"my*/service-*"})
+	if err == nil {
+		t.Fatal("want an error for a pattern of the owner")
+	}
+}
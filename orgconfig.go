@@ -0,0 +1,305 @@
+package prme
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OrgConfigRepo and OrgConfigPath locate the defaults shared by an
+// organization or user, in the repository OrgConfigRepo of the owner.
+const (
+	OrgConfigRepo = ".prme"
+	OrgConfigPath = "config.yaml"
+)
+
+// OrgConfig is the default configuration shared by all repositories of an
+// organization or user. Empty fields do not change the prme defaults.
+type OrgConfig struct {
+	Title string
+	Body  string
+	// HowTo is the template of the comment explaining the review workflow,
+	// as described by WithHowToComment.
+	HowTo          string
+	FullRepoBranch string
+	Labels         []string
+	Reviewers      []string
+	// RemindAfterDays is the days without activity after which requested
+	// reviewers are reminded by prme serve.
+	RemindAfterDays int
+}
+
+// ParseOrgConfig parses an OrgConfigPath file. A subset of YAML is
+// supported: top-level keys with a scalar, a literal block (|) scalar, or a
+// list in either flow ([a, b]) or block (- a) style. Unknown keys are
+// ignored, so configuration for newer versions of prme can be shared.
+//
+// For example:
+//
+//	title: Full Review
+//	body: |
+//	  A full review of the entire repository.
+//	howto: |
+//	  Push review fixes to {{.HeadBranch}}.
+//	fbranch: main
+//	labels: [full-review]
+//	reviewers:
+//	  - octocat
+//	  - myorg/security-team
+//	remind_after_days: 7
+func ParseOrgConfig(data []byte) (*OrgConfig, error) {
+	values, err := parseYAMLSubset(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &OrgConfig{}
+	for key, value := range values {
+		switch key {
+		case "title":
+			cfg.Title, err = value.scalar(key)
+		case "body":
+			cfg.Body, err = value.scalar(key)
+		case "howto":
+			cfg.HowTo, err = value.scalar(key)
+		case "fbranch":
+			cfg.FullRepoBranch, err = value.scalar(key)
+		case "labels":
+			cfg.Labels = value.items()
+		case "reviewers":
+			cfg.Reviewers = value.items()
+		case "remind_after_days":
+			var days string
+			days, err = value.scalar(key)
+			if err == nil {
+				cfg.RemindAfterDays, err = strconv.Atoi(days)
+				if err != nil || cfg.RemindAfterDays < 0 {
+					err = fmt.Errorf("%s must be a number of days, not %q", key, days)
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// yamlValue is a scalar, a list if isList is true, or a mapping of nested
+// keys if mapping is not nil.
+type yamlValue struct {
+	text    string
+	list    []string
+	isList  bool
+	mapping map[string]yamlValue
+}
+
+func (v yamlValue) scalar(key string) (string, error) {
+	if v.isList {
+		return "", fmt.Errorf("%s must be a single value, not a list", key)
+	}
+	if v.mapping != nil {
+		return "", fmt.Errorf("%s must be a single value, not nested keys", key)
+	}
+	return v.text, nil
+}
+
+// items returns the list, or a list of the scalar if it is not empty.
+func (v yamlValue) items() []string {
+	if v.isList || v.text == "" {
+		return v.list
+	}
+	return []string{v.text}
+}
+
+// parseYAMLSubset parses the subset of YAML described by ParseOrgConfig.
+func parseYAMLSubset(data []byte) (map[string]yamlValue, error) {
+	values := make(map[string]yamlValue)
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line != trimmed {
+			return nil, fmt.Errorf("line %d: unexpected indentation", i+1)
+		}
+		colon := strings.Index(line, ":")
+		if colon < 1 {
+			return nil, fmt.Errorf("line %d: expected a key followed by a colon", i+1)
+		}
+		key := strings.TrimSpace(line[:colon])
+		rest := stripYAMLComment(strings.TrimSpace(line[colon+1:]))
+		// Gather the indented lines following the key.
+		var nested []string
+		for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || strings.HasPrefix(lines[i+1], " ") || strings.HasPrefix(lines[i+1], "\t")) {
+			i++
+			nested = append(nested, lines[i])
+		}
+		switch {
+		case rest == "|" || rest == "|-":
+			values[key] = yamlValue{text: literalBlock(nested, rest == "|")}
+		case strings.HasPrefix(rest, "["):
+			if !strings.HasSuffix(rest, "]") {
+				return nil, fmt.Errorf("line %d: the list for %s must end with ]", i+1, key)
+			}
+			v := yamlValue{isList: true}
+			for _, item := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(rest, "["), "]"), ",") {
+				item = unquoteYAML(strings.TrimSpace(item))
+				if item != "" {
+					v.list = append(v.list, item)
+				}
+			}
+			values[key] = v
+		case rest == "" && isYAMLMapping(nested):
+			mapping, err := parseYAMLSubset([]byte(literalBlock(nested, false)))
+			if err != nil {
+				return nil, fmt.Errorf("in %s: %w", key, err)
+			}
+			values[key] = yamlValue{mapping: mapping}
+		case rest == "":
+			v := yamlValue{isList: true}
+			for _, n := range nested {
+				n = stripYAMLComment(strings.TrimSpace(n))
+				if n == "" || strings.HasPrefix(n, "#") {
+					continue
+				}
+				if !strings.HasPrefix(n, "-") {
+					return nil, fmt.Errorf("the value of %s must be a list of items beginning with -", key)
+				}
+				v.list = append(v.list, unquoteYAML(strings.TrimSpace(strings.TrimPrefix(n, "-"))))
+			}
+			values[key] = v
+		default:
+			if len(strings.TrimSpace(strings.Join(nested, ""))) > 0 {
+				return nil, fmt.Errorf("unexpected indented lines following %s", key)
+			}
+			values[key] = yamlValue{text: unquoteYAML(rest)}
+		}
+	}
+	return values, nil
+}
+
+// isYAMLMapping returns true if the indented lines following a key are
+// nested keys, instead of list items.
+func isYAMLMapping(nested []string) bool {
+	for _, n := range nested {
+		n = strings.TrimSpace(n)
+		if n == "" || strings.HasPrefix(n, "#") {
+			continue
+		}
+		return !strings.HasPrefix(n, "-") && strings.Contains(n, ":")
+	}
+	return false
+}
+
+// literalBlock returns the indented lines of a YAML literal block scalar,
+// with their common indentation removed. A trailing newline is kept if
+// keepNewline is true.
+func literalBlock(lines []string, keepNewline bool) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	text := strings.TrimRight(b.String(), "\n")
+	if keepNewline && text != "" {
+		text += "\n"
+	}
+	return text
+}
+
+// stripYAMLComment removes a trailing comment from an unquoted value.
+func stripYAMLComment(s string) string {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		return s
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// unquoteYAML removes single or double quotes surrounding s.
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// orgConfig returns the OrgConfig of the owner of the repository, or nil if
+// the owner does not have one.
+func (f FullPullRequestCreator) orgConfig() (*OrgConfig, error) {
+	owner := repoOwner(f.Repo)
+	r, err := NewRepo(owner+"/"+OrgConfigRepo, f.token, f.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.GetFileContents(OrgConfigPath)
+	if errors.Is(err, ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while getting the %s configuration of %q: %w", OrgConfigRepo, owner, err)
+	}
+	cfg, err := ParseOrgConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s/%s/%s: %w", owner, OrgConfigRepo, OrgConfigPath, err)
+	}
+	return cfg, nil
+}
+
+// repoOwner returns the owner of the repository ownerAndName.
+func repoOwner(ownerAndName string) string {
+	return strings.SplitN(ownerAndName, "/", 2)[0]
+}
+
+// applyOrgConfig sets properties of f from cfg, unless they have been
+// changed from the prme defaults.
+func (f *FullPullRequestCreator) applyOrgConfig(cfg *OrgConfig) {
+	if cfg.Title != "" && f.Title == defaultTitle {
+		f.Title = cfg.Title
+	}
+	if cfg.Body != "" && f.Body == defaultBody {
+		f.Body = cfg.Body
+	}
+	if cfg.HowTo != "" && f.HowToTemplate == "" {
+		f.HowToTemplate = cfg.HowTo
+	}
+	if cfg.FullRepoBranch != "" && f.FullRepoBranch == defaultFullRepoBranch && f.workspaceBranch == "" {
+		f.FullRepoBranch = cfg.FullRepoBranch
+	}
+	if len(f.Labels) == 0 {
+		f.Labels = cfg.Labels
+	}
+	if len(f.Reviewers) == 0 {
+		f.Reviewers = cfg.Reviewers
+	}
+	if f.RemindAfterDays == 0 {
+		f.RemindAfterDays = cfg.RemindAfterDays
+	}
+}
@@ -0,0 +1,60 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseOrgConfig(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`# Defaults for all repositories of ivanfetch.
+title: "Security Review"
+body: |
+  A full review of the entire repository.
+
+  Please push fixes to the head branch.
+fbranch: trunk # Not main
+labels: [full-review, 'audit']
+reviewers:
+  - octocat
+  - ivanfetch/security-team
+remind_after_days: 7
+unknown: ignored
+`)
+	want := &prme.OrgConfig{
+		Title:           "Security Review",
+		Body:            "A full review of the entire repository.\n\nPlease push fixes to the head branch.\n",
+		FullRepoBranch:  "trunk",
+		Labels:          []string{"full-review", "audit"},
+		Reviewers:       []string{"octocat", "ivanfetch/security-team"},
+		RemindAfterDays: 7,
+	}
+	got, err := prme.ParseOrgConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect configuration\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestParseOrgConfigInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"list for a scalar": "title: [one, two]\n",
+		"missing colon":     "title\n",
+		"unclosed list":     "labels: [one, two\n",
+		"block list items":  "reviewers:\n  octocat\n",
+		"days not a number": "remind_after_days: weekly\n",
+	}
+	for description, data := range testCases {
+		_, err := prme.ParseOrgConfig([]byte(data))
+		if err == nil {
+			t.Errorf("%s: want an error, got nil", description)
+		}
+	}
+}
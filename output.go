@@ -0,0 +1,48 @@
+package prme
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of the standard output of prme when creating pull requests.
+const (
+	// outputFormatText is human-readable.
+	outputFormatText = "text"
+	// outputFormatEnv is shell variable assignments, which can be evaluated
+	// by a shell, such as: eval "$(prme owner/repo)"
+	outputFormatEnv = "env"
+)
+
+// shellQuote returns s quoted for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellJoin returns args as a POSIX shell command line, only quoting
+// arguments that need it.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}#~!") {
+			quoted[i] = shellQuote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// writeEnvOutput writes shell variable assignments describing the pull
+// request PR, created by f, to w.
+func writeEnvOutput(w io.Writer, f FullPullRequestCreator, PR *PullRequest) {
+	for _, variable := range []struct{ name, value string }{
+		{"PR_URL", PR.HTMLURL},
+		{"PR_NUMBER", strconv.Itoa(PR.Number)},
+		{"BASE_BRANCH", f.BaseBranch},
+		{"HEAD_BRANCH", f.HeadBranch},
+	} {
+		fmt.Fprintf(w, "%s=%s\n", variable.name, shellQuote(variable.value))
+	}
+}
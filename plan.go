@@ -0,0 +1,431 @@
+package prme
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// PlanStep is an API call or git command run while creating a review.
+type PlanStep struct {
+	Description string `json:"description"`
+	// Method and URI are set for API calls.
+	Method string `json:"method,omitempty"`
+	URI    string `json:"uri,omitempty"`
+	// Body is the JSON request body of API calls that change the
+	// repository.
+	Body json.RawMessage `json:"body,omitempty"`
+	// GitArgs are set for git commands.
+	GitArgs []string `json:"git_args,omitempty"`
+}
+
+// Plan describes the review that FullPullRequestCreator.CreateWithResult would create,
+// including the API calls and git commands it would run.
+type Plan struct {
+	Repo                    string     `json:"repo"`
+	FullRepoBranch          string     `json:"full_repo_branch"`
+	FullRepoSha             string     `json:"full_repo_sha"`
+	BaseBranch              string     `json:"base_branch"`
+	HeadBranch              string     `json:"head_branch"`
+	Title                   string     `json:"title"`
+	Body                    string     `json:"body"`
+	Labels                  []string   `json:"labels,omitempty"`
+	Reviewers               []string   `json:"reviewers,omitempty"`
+	Assignees               []string   `json:"assignees,omitempty"`
+	Milestone               string     `json:"milestone,omitempty"`
+	SetCommitStatus         bool       `json:"set_commit_status,omitempty"`
+	CommentOnFullRepoBranch bool       `json:"comment_on_full_repo_branch,omitempty"`
+	ForceDelete             bool       `json:"force_delete,omitempty"`
+	BreakLock               bool       `json:"break_lock,omitempty"`
+	RemindAfterDays         int        `json:"remind_after_days,omitempty"`
+	Topic                   string     `json:"topic,omitempty"`
+	RemoveTopic             bool       `json:"remove_topic,omitempty"`
+	AddTopic                string     `json:"add_topic,omitempty"`
+	Path                    string     `json:"path,omitempty"`
+	MaxFileSize             int64      `json:"max_file_size,omitempty"`
+	Symlinks                string     `json:"symlinks,omitempty"`
+	Submodules              string     `json:"submodules,omitempty"`
+	NormalizeText           bool       `json:"normalize_text,omitempty"`
+	TableOfContents         bool       `json:"table_of_contents,omitempty"`
+	Draft                   bool       `json:"draft,omitempty"`
+	HowTo                   bool       `json:"how_to,omitempty"`
+	HowToTemplate           string     `json:"how_to_template,omitempty"`
+	RetryProtectedBranches  bool       `json:"retry_protected_branches,omitempty"`
+	CheckReviewerPushAccess bool       `json:"check_reviewer_push_access,omitempty"`
+	IdempotencyKey          string     `json:"idempotency_key"`
+	Steps                   []PlanStep `json:"steps"`
+}
+
+func (p *Plan) addAPIStep(description, method, apiURI string) {
+	p.Steps = append(p.Steps, PlanStep{Description: description, Method: method, URI: apiURI})
+}
+
+// addAPIStepWithData adds an API call with the JSON encoding of body.
+func (p *Plan) addAPIStepWithData(description, method, apiURI string, body interface{}) {
+	// The request bodies are structs which always encode.
+	bodyJSON, _ := json.Marshal(body)
+	p.Steps = append(p.Steps, PlanStep{Description: description, Method: method, URI: apiURI, Body: bodyJSON})
+}
+
+func (p *Plan) addGitStep(description string, args ...string) {
+	p.Steps = append(p.Steps, PlanStep{Description: description, GitArgs: args})
+}
+
+// plannedPullRequestURL stands in for the URL of the pull request in
+// planned request bodies, as it is not known until the pull request is
+// created.
+const plannedPullRequestURL = "{pull request URL}"
+
+// Plan performs the verifications of Create, without changing anything, and
+// returns the plan of the review Create would create. Only read access to
+// the repository is required, so a read-only token can be used to review
+// the plan before running Create with a token that can write.
+func (f FullPullRequestCreator) Plan() (*Plan, error) {
+	prepared, _, err := f.prepare(false)
+	if err != nil {
+		return nil, err
+	}
+	r := prepared.repo
+	plan := &Plan{
+		Repo:                    r.String(),
+		FullRepoBranch:          f.FullRepoBranch,
+		FullRepoSha:             prepared.fullRepoSha,
+		BaseBranch:              f.BaseBranch,
+		HeadBranch:              f.HeadBranch,
+		Title:                   f.Title,
+		Body:                    f.Body,
+		Labels:                  f.Labels,
+		Reviewers:               f.Reviewers,
+		Assignees:               f.Assignees,
+		Milestone:               f.Milestone,
+		SetCommitStatus:         f.SetCommitStatus,
+		CommentOnFullRepoBranch: f.CommentOnFullRepoBranch,
+		ForceDelete:             f.ForceDelete,
+		BreakLock:               f.BreakLock,
+		RemindAfterDays:         f.RemindAfterDays,
+		Topic:                   f.Topic,
+		RemoveTopic:             f.RemoveTopic,
+		AddTopic:                f.AddTopic,
+		Path:                    f.Path,
+		MaxFileSize:             f.MaxFileSize,
+		Symlinks:                f.Symlinks,
+		Submodules:              f.Submodules,
+		NormalizeText:           f.NormalizeText,
+		TableOfContents:         f.TableOfContents,
+		Draft:                   f.Draft,
+		HowTo:                   f.HowTo,
+		RetryProtectedBranches:  f.RetryProtectedBranches,
+		CheckReviewerPushAccess: f.CheckReviewerPushAccess,
+		IdempotencyKey:          prepared.idempotencyKey,
+	}
+	// A plan describes a single pull request, so reviews that Create would
+	// split are not planned, rather than applying parts nobody approved.
+	plan.addAPIStep("List the files of the review, to determine whether it must be split", http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/{tree}?recursive=1", r))
+	files, err := f.reviewFiles(r, prepared.fullRepoSha)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > MaxPullRequestFiles {
+		return nil, fmt.Errorf("%w: repository %s has %d files, more than the %d Github displays in a pull request, use the path option to plan the review of part of the repository", ErrSplitNotPlanned, r, len(files), MaxPullRequestFiles)
+	}
+	if f.BreakLock {
+		plan.addAPIStep(fmt.Sprintf("Break any existing lock of the repository by deleting refs/%s", qualifiedRef(LockRef)), http.MethodDelete, fmt.Sprintf("/repos/%s/git/refs/%s", r, qualifiedRef(LockRef)))
+	}
+	plan.addAPIStep("Create a tag object recording which prme process holds the lock, and since when", http.MethodPost, fmt.Sprintf("/repos/%s/git/tags", r))
+	plan.addAPIStepWithData(fmt.Sprintf("Lock the repository by creating refs/%s, pointing at the tag object", qualifiedRef(LockRef)), http.MethodPost, fmt.Sprintf("/repos/%s/git/refs", r), newCreateRefRequest(LockRef, "{tag sha}"))
+	var existingBranches []string
+	branches := []struct{ kind, name string }{{"base", f.BaseBranch}, {"head", f.HeadBranch}}
+	if f.SkipPreflight && !f.ForceDelete {
+		branches = nil
+	}
+	for _, branch := range branches {
+		plan.addAPIStep(fmt.Sprintf("Determine whether the %s branch %q exists", branch.kind, branch.name), http.MethodGet, fmt.Sprintf("/repos/%s/branches/%s", r, branch.name))
+		ok, err := r.BranchExists(branch.name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if !f.ForceDelete {
+			return nil, fmt.Errorf("%s branch %q already exists in repository %q, use the force-delete option to delete and recreate it", branch.kind, branch.name, r)
+		}
+		err = r.VerifyPrmeBranch(branch.name)
+		if err != nil {
+			return nil, fmt.Errorf("refusing to force-delete existing branches: %w", err)
+		}
+		existingBranches = append(existingBranches, branch.name)
+	}
+	for _, branch := range existingBranches {
+		plan.addAPIStep(fmt.Sprintf("Verify the history of the existing branch %q begins with an empty commit created by prme", branch), http.MethodGet, fmt.Sprintf("/repos/%s/git/commits/{sha}", r))
+		plan.addAPIStep(fmt.Sprintf("Delete the existing branch %q", branch), http.MethodDelete, fmt.Sprintf("/repos/%s/git/refs/heads/%s", r, branch))
+	}
+	plan.addGitStep("Clone the repository", "clone", r.CloneURL(), r.String())
+	plan.addGitStep("Create a commit of the empty tree", "commit-tree", EmptyTreeSha, "-m", emptyTreeCommitMessage)
+	plan.addGitStep("Create the base branch at the empty-tree commit", "branch", f.BaseBranch, "{empty-tree commit}")
+	plan.addGitStep("Create the head branch at the empty-tree commit", "branch", f.HeadBranch, "{empty-tree commit}")
+	plan.addGitStep("Push the base and head branches", "push", "origin", f.BaseBranch, f.HeadBranch)
+	if f.filtersContent() {
+		if symlinks, submodules := f.linkHandling(); symlinks != LinkKeep || submodules != LinkKeep {
+			plan.addAPIStep("List the files of the full repository branch", http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/{tree}?recursive=1", r))
+			if symlinks == LinkMaterialize {
+				plan.addAPIStep("Get the target of each symlink", http.MethodGet, fmt.Sprintf("/repos/%s/git/blobs/{sha}", r))
+			}
+			plan.addAPIStep(fmt.Sprintf("Create a tree where symlinks are handled with %s, and submodules with %s", symlinks, submodules), http.MethodPost, fmt.Sprintf("/repos/%s/git/trees", r))
+		}
+		if f.MaxFileSize > 0 {
+			plan.addAPIStep("List the files of the full repository branch", http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/{tree}?recursive=1", r))
+			plan.addAPIStep(fmt.Sprintf("Create a tree without files larger than %d bytes, listing them in %s", f.MaxFileSize, OmittedFilesManifest), http.MethodPost, fmt.Sprintf("/repos/%s/git/trees", r))
+		}
+		if f.NormalizeText {
+			plan.addAPIStep("List the files to normalize", http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/{tree}?recursive=1", r))
+			plan.addAPIStep("Get the content of each file up to 1MiB", http.MethodGet, fmt.Sprintf("/repos/%s/git/blobs/{sha}", r))
+			plan.addAPIStep("Create a tree with normalized line endings and encoding", http.MethodPost, fmt.Sprintf("/repos/%s/git/trees", r))
+		}
+		if f.Path != "" {
+			plan.addAPIStep(fmt.Sprintf("Create a tree containing only %s of %s", f.Path, f.FullRepoBranch), http.MethodPost, fmt.Sprintf("/repos/%s/git/trees", r))
+		}
+		plan.addAPIStep(fmt.Sprintf("Get the head branch %q", f.HeadBranch), http.MethodGet, fmt.Sprintf("/repos/%s/git/ref/heads/%s", r, f.HeadBranch))
+		plan.addAPIStep(fmt.Sprintf("Create a commit of that tree, whose parents are %s and %s", f.HeadBranch, f.FullRepoBranch), http.MethodPost, fmt.Sprintf("/repos/%s/git/commits", r))
+		plan.addAPIStep(fmt.Sprintf("Update the head branch %q to that commit", f.HeadBranch), http.MethodPatch, fmt.Sprintf("/repos/%s/git/refs/heads/%s", r, f.HeadBranch))
+	} else {
+		plan.addAPIStepWithData(fmt.Sprintf("Merge %s into %s", f.FullRepoBranch, f.HeadBranch), http.MethodPost, fmt.Sprintf("/repos/%s/merges", r), mergeRequest{
+			Base:          f.HeadBranch,
+			Head:          f.FullRepoBranch,
+			CommitMessage: f.mergeCommitMessage(),
+		})
+	}
+	plan.addAPIStepWithData(fmt.Sprintf("Create the pull request %q", f.Title), http.MethodPost, fmt.Sprintf("/repos/%s/pulls", r), pullRequestRequest{
+		Title: f.Title,
+		Body:  f.Body,
+		Base:  f.BaseBranch,
+		Head:  f.HeadBranch,
+		Draft: f.Draft,
+	})
+	if f.TableOfContents {
+		plan.addAPIStep("List the files of the head branch", http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/%s?recursive=1", r, f.HeadBranch))
+		plan.addAPIStep("Add a table of contents of the files to the pull request body", http.MethodPatch, fmt.Sprintf("/repos/%s/pulls/{number}", r))
+	}
+	for _, label := range f.Labels {
+		plan.addAPIStep(fmt.Sprintf("Determine whether the label %q exists", label), http.MethodGet, fmt.Sprintf("/repos/%s/labels/%s", r, url.PathEscape(label)))
+		plan.addAPIStepWithData(fmt.Sprintf("Create the label %q, if it does not exist", label), http.MethodPost, fmt.Sprintf("/repos/%s/labels", r), newLabel(label, defaultLabelColor))
+	}
+	if len(f.Labels) > 0 {
+		plan.addAPIStepWithData(fmt.Sprintf("Add the labels %s to the pull request", strings.Join(f.Labels, ", ")), http.MethodPost, fmt.Sprintf("/repos/%s/issues/{number}/labels", r), addLabelsRequest{Labels: f.Labels})
+	}
+	if len(f.Reviewers) > 0 {
+		plan.addAPIStepWithData(fmt.Sprintf("Request reviews from %s", strings.Join(f.Reviewers, ", ")), http.MethodPost, fmt.Sprintf("/repos/%s/pulls/{number}/requested_reviewers", r), newReviewersRequest(f.Reviewers))
+	}
+	if len(f.Assignees) > 0 {
+		plan.addAPIStepWithData(fmt.Sprintf("Assign %s to the pull request", strings.Join(f.Assignees, ", ")), http.MethodPost, fmt.Sprintf("/repos/%s/issues/{number}/assignees", r), addAssigneesRequest{Assignees: f.Assignees})
+	}
+	if prepared.milestone != nil {
+		plan.addAPIStepWithData(fmt.Sprintf("Add the pull request to milestone %q", prepared.milestone.Title), http.MethodPatch, fmt.Sprintf("/repos/%s/issues/{number}", r), setMilestoneRequest{Milestone: prepared.milestone.Number})
+	}
+	if f.HowTo {
+		plan.HowToTemplate = f.HowToTemplate
+		body, err := f.howToComment(r)
+		if err != nil {
+			return nil, err
+		}
+		plan.addAPIStepWithData("Comment on the pull request, explaining how to contribute review fixes", http.MethodPost, fmt.Sprintf("/repos/%s/issues/{number}/comments", r), commitCommentRequest{Body: body})
+	}
+	if f.SetCommitStatus {
+		plan.addAPIStepWithData(fmt.Sprintf("Set a pending %s commit status on the head branch", FullReviewStatusContext), http.MethodPost, fmt.Sprintf("/repos/%s/statuses/{merge commit}", r), pendingReviewStatus(plannedPullRequestURL))
+	}
+	if f.CommentOnFullRepoBranch {
+		plan.addAPIStepWithData(fmt.Sprintf("Comment on the tip commit of %s, linking to the pull request", f.FullRepoBranch), http.MethodPost, fmt.Sprintf("/repos/%s/commits/%s/comments", r, prepared.fullRepoSha), commitCommentRequest{Body: reviewInProgressComment(plannedPullRequestURL)})
+	}
+	if f.RemoveTopic || f.AddTopic != "" {
+		plan.addAPIStep("Get the topics of the repository", http.MethodGet, fmt.Sprintf("/repos/%s/topics", r))
+		topics, err := r.GetTopics()
+		if err != nil {
+			return nil, err
+		}
+		plan.addAPIStepWithData("Replace the topics of the repository, to mark the review was created", http.MethodPut, fmt.Sprintf("/repos/%s/topics", r), topicsRequest{Names: f.updatedTopics(topics)})
+	}
+	plan.addAPIStep("Unlock the repository", http.MethodDelete, fmt.Sprintf("/repos/%s/git/refs/%s", r, LockRef))
+	return plan, nil
+}
+
+// Write displays the plan to w.
+func (p Plan) Write(w io.Writer) {
+	fmt.Fprintf(w, "Full review of %s:\n", p.Repo)
+	fmt.Fprintf(w, "  Full repository branch:\t%s at %s\n", p.FullRepoBranch, p.FullRepoSha)
+	fmt.Fprintf(w, "  Base branch:\t%s\n", p.BaseBranch)
+	fmt.Fprintf(w, "  Head branch:\t%s\n", p.HeadBranch)
+	fmt.Fprintf(w, "  Title:\t%s\n", p.Title)
+	if len(p.Labels) > 0 {
+		fmt.Fprintf(w, "  Labels:\t%s\n", strings.Join(p.Labels, ", "))
+	}
+	if len(p.Reviewers) > 0 {
+		fmt.Fprintf(w, "  Reviewers:\t%s\n", strings.Join(p.Reviewers, ", "))
+	}
+	if len(p.Assignees) > 0 {
+		fmt.Fprintf(w, "  Assignees:\t%s\n", strings.Join(p.Assignees, ", "))
+	}
+	if p.Milestone != "" {
+		fmt.Fprintf(w, "  Milestone:\t%s\n", p.Milestone)
+	}
+	fmt.Fprintln(w, "Steps:")
+	for i, step := range p.Steps {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, step.Description)
+		if len(step.GitArgs) > 0 {
+			fmt.Fprintf(w, "     git %s\n", shellJoin(step.GitArgs))
+			continue
+		}
+		fmt.Fprintf(w, "     %s %s\n", step.Method, step.URI)
+	}
+}
+
+// WritePlansJSON writes plans to w as indented JSON. The encoding is
+// canonical, so it can be compared with golden files in tests.
+func WritePlansJSON(w io.Writer, plans []Plan) error {
+	if plans == nil {
+		plans = []Plan{}
+	}
+	plansJSON, err := json.MarshalIndent(plans, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", plansJSON)
+	return err
+}
+
+// writeSignedPlans records the Github user of token as having made the
+// plans, signs them using key, and writes them to the file path.
+func writeSignedPlans(path string, sp *SignedPlans, token string, key ed25519.PrivateKey, clientOptions ...ClientOption) error {
+	c, err := NewClient(token, clientOptions...)
+	if err != nil {
+		return err
+	}
+	u, err := c.AuthenticatedUser()
+	if err != nil {
+		return err
+	}
+	sp.PlannedBy = u.Login
+	sp.PlannedAt = time.Now().UTC()
+	err = sp.Sign(key)
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(path, sp)
+}
+
+// runPlanCommand displays the plan of full reviews, using a token that only
+// needs read access.
+func runPlanCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme plan", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand performs every verification made while creating full reviews, without changing anything, and displays exactly which API calls and git commands would be run. A read-only token can be used, such as to review the plan as part of a change-review process, before running prme with a token that can write to the repositories. To require a second person to approve changes, use -o and -sign-key to write a signed plan file, which they create using prme apply.
+
+Usage: %s [flags] <repository> [<repository>...]
+
+The flags are the same as those used to create full reviews.
+
+Available command-line flags:
+`,
+			fs.Name())
+		printFlagDefaults(fs, errOutput)
+	}
+	CLIPlanFile := fs.String("o", "", "A file to which the plans are written, signed using -sign-key, so another user can create the reviews using prme apply.")
+	CLIJSON := fs.Bool("json", false, "Display the plans as JSON, including the request body of each API call that would change a repository. This is also set via the PRME_JSON environment variable.")
+	CLISignKey := fs.String("sign-key", "", "The PEM encoded Ed25519 private key with which to sign the plan file, such as created by: openssl genpkey -algorithm ed25519. This is also set via the PRME_SIGN_KEY environment variable.")
+	CLIOwner := addOwnerFlag(fs)
+	applyCreatorFlags, err := addCreatorFlags(fs, output)
+	if err != nil {
+		return err
+	}
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("please specify at least one repository, in the form OwnerName/RepositoryName")
+	}
+	token := os.Getenv("GH_TOKEN")
+	var signKey ed25519.PrivateKey
+	if *CLIPlanFile != "" {
+		if *CLISignKey == "" {
+			return errors.New("please specify -sign-key to sign the plan file")
+		}
+		signKey, err = ReadSigningKey(*CLISignKey)
+		if err != nil {
+			return err
+		}
+	}
+	// Standard output only contains the plans when displaying JSON.
+	messageOutput := output
+	if *CLIJSON {
+		messageOutput = errOutput
+	}
+	var signedPlans SignedPlans
+	var failed int
+	// The token and Github instance can be set by a host profile.
+	var clientOptions []ClientOption
+	for _, repoName := range fs.Args() {
+		f, err := NewFullPullRequestCreator(qualifyRepoName(repoName, *CLIOwner))
+		if err != nil {
+			return err
+		}
+		f.token = token
+		err = applyCreatorFlags(f)
+		if err != nil {
+			return err
+		}
+		if f.token == "" {
+			return errors.New("Please set the GH_TOKEN environment variable to a Github personal access token.")
+		}
+		token, clientOptions = f.token, f.clientOptions
+		plan, err := f.Plan()
+		if errors.Is(err, ErrAlreadyCreated) || errors.Is(err, ErrReviewExists) || errors.Is(err, ErrRepoExcluded) {
+			fmt.Fprintf(messageOutput, "%s: nothing to do, %v\n", f.Repo, err)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(errOutput, "%s: %v\n", f.Repo, err)
+			failed++
+			continue
+		}
+		if !*CLIJSON {
+			plan.Write(output)
+			fmt.Fprintln(output)
+		}
+		signedPlans.Plans = append(signedPlans.Plans, *plan)
+	}
+	if *CLIJSON {
+		err = WritePlansJSON(output, signedPlans.Plans)
+		if err != nil {
+			return err
+		}
+	}
+	if len(signedPlans.Plans) > 0 && *CLIPlanFile != "" {
+		err = writeSignedPlans(*CLIPlanFile, &signedPlans, token, signKey, clientOptions...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(messageOutput, `Nothing has been changed. The signed plan has been written to %s. To create the planned reviews, another person should set the GH_TOKEN environment variable to their token that can write to the repositories, with the repo scope, or the contents: write and pull requests: write permissions, then run:
+  prme apply -plan %s -trusted-keys <directory including your public key file, named after your Github login>
+`, *CLIPlanFile, shellQuote(*CLIPlanFile))
+	}
+	if len(signedPlans.Plans) > 0 && *CLIPlanFile == "" && !*CLIJSON {
+		fmt.Fprintf(output, `Nothing has been changed. To create the planned reviews, set the GH_TOKEN environment variable to a token that can write to the repositories, with the repo scope, or the contents: write and pull requests: write permissions, then run:
+  prme %s
+`, shellJoin(args))
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to plan reviews of %d repositories", failed)
+	}
+	return nil
+}
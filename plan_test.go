@@ -0,0 +1,199 @@
+package prme_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlanWrite(t *testing.T) {
+	t.Parallel()
+
+	plan := prme.Plan{
+		Repo:           "ivanfetch/ghapitest",
+		FullRepoBranch: "main",
+		FullRepoSha:    "c3",
+		BaseBranch:     "prme-full-review",
+		HeadBranch:     "prme-full-content",
+		Title:          "Full Review",
+		Labels:         []string{"audit"},
+		Steps: []prme.PlanStep{
+			{
+				Description: "Create a commit of the empty tree",
+				GitArgs:     []string{"commit-tree", prme.EmptyTreeSha, "-m", "empty-tree commit"},
+			},
+			{
+				Description: `Create the pull request "Full Review"`,
+				Method:      "POST",
+				URI:         "/repos/ivanfetch/ghapitest/pulls",
+			},
+		},
+	}
+	want := `Full review of ivanfetch/ghapitest:
+  Full repository branch:	main at c3
+  Base branch:	prme-full-review
+  Head branch:	prme-full-content
+  Title:	Full Review
+  Labels:	audit
+Steps:
+  1. Create a commit of the empty tree
+     git commit-tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904 -m 'empty-tree commit'
+  2. Create the pull request "Full Review"
+     POST /repos/ivanfetch/ghapitest/pulls
+`
+	var output bytes.Buffer
+	plan.Write(&output)
+	if output.String() != want {
+		t.Fatalf("got incorrect plan output\ndiff reflects want vs. got: %s", cmp.Diff(want, output.String()))
+	}
+}
+
+func TestPlanVerifiesOptions(t *testing.T) {
+	t.Parallel()
+
+	f, err := prme.NewFullPullRequestCreator("myorg/infra-dns",
+		prme.WithToken("dummyToken"),
+		prme.WithExcludeRepos("myorg/infra-*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Plan()
+	if !errors.Is(err, prme.ErrRepoExcluded) {
+		t.Fatalf("want %v planning an excluded repository, got %v", prme.ErrRepoExcluded, err)
+	}
+}
+
+func TestPlanGolden(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c", `{"sha":"t1","tree":[{"path":"README.md","mode":"100644","type":"blob","sha":"b1","size":10}]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	var plans []prme.Plan
+	for _, newCreator := range []func() (*prme.FullPullRequestCreator, error){
+		func() (*prme.FullPullRequestCreator, error) {
+			return prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+				prme.WithToken("dummyToken"),
+				prme.WithAPIMiddleware(fc.Middleware()),
+			)
+		},
+		func() (*prme.FullPullRequestCreator, error) {
+			f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+				prme.WithToken("dummyToken"),
+				prme.WithAPIMiddleware(fc.Middleware()),
+				prme.WithAllowRepos("ivanfetch/*"),
+				prme.WithLanguage("es", ""),
+				prme.WithBranchPrefix("audit-"),
+				prme.WithCommitStatus(),
+				prme.WithCommitComment(),
+			)
+			if err != nil {
+				return nil, err
+			}
+			f.Labels = []string{"audit", "security review"}
+			f.Reviewers = []string{"ivanfetch", "ivanfetch/reviewers"}
+			return f, nil
+		},
+	} {
+		f, err := newCreator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := f.Plan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		plans = append(plans, *plan)
+	}
+	var got bytes.Buffer
+	err = prme.WritePlansJSON(&got, plans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/TestPlanGolden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Fatalf("got incorrect plans\ndiff reflects want vs. got: %s", cmp.Diff(string(want), got.String()))
+	}
+}
+
+func TestPlanRefusesSplitReviews(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	entries := make([]string, prme.MaxPullRequestFiles+1)
+	for i := range entries {
+		entries[i] = fmt.Sprintf(`{"path":"file%d.txt","mode":"100644","type":"blob","sha":"b1","size":10}`, i)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c", `{"sha":"t1","tree":[`+strings.Join(entries, ",")+`]}`)
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Plan()
+	if !errors.Is(err, prme.ErrSplitNotPlanned) {
+		t.Fatalf("want error %v planning a review that would be split, got %v", prme.ErrSplitNotPlanned, err)
+	}
+}
+
+func TestCreateDryRun(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name":"ivanfetch/ghapitest","default_branch":"main"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/ref/heads/main", `{"ref":"refs/heads/main","object":{"sha":"c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c","type":"commit"}}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/git/trees/c3d0be41ecbe669545ee3e94d31ed9a4bc91ee3c", `{"sha":"t1","tree":[{"path":"README.md","mode":"100644","type":"blob","sha":"b1","size":10}]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", `[]`)
+	var output bytes.Buffer
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithAPIMiddleware(fc.Middleware()),
+		prme.WithDryRun(),
+		prme.WithOutput(&output),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	PR, err := f.CreateWithResult()
+	if !errors.Is(err, prme.ErrDryRun) {
+		t.Fatalf("want error %v, got %v", prme.ErrDryRun, err)
+	}
+	if PR != nil {
+		t.Errorf("want no pull request, got %+v", PR)
+	}
+	if !strings.HasPrefix(output.String(), "Full review of ivanfetch/ghapitest:\n") {
+		t.Errorf("want the plan displayed, got %q", output.String())
+	}
+	for _, req := range fc.Requests() {
+		if req.Method != http.MethodGet {
+			t.Errorf("want only read-only API requests, got %s %s", req.Method, req.URI)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentChecks is the maximum number of preflight checks that
+// runChecks runs at once, to shorten preflight without bursting API
+// requests.
+const maxConcurrentChecks = 4
+
+// runChecks runs checks concurrently, at most maxConcurrentChecks at once,
+// and returns the error of the first of checks that failed, so the error
+// does not depend on which check finished first. Checks must not depend on
+// each other.
+func runChecks(checks ...func() error) error {
+	errs := make([]error, len(checks))
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, check func() error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = check()
+		}(i, check)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Kinds of Github tokens, as returned by TokenKind.
+const (
+	TokenKindClassic     = "classic"
+	TokenKindFineGrained = "fine-grained"
+	TokenKindOther       = "other"
+)
+
+// TokenKind returns the kind of Github token, based on its prefix.
+func TokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "github_pat_"):
+		return TokenKindFineGrained
+	case strings.HasPrefix(token, "ghp_"):
+		return TokenKindClassic
+	default:
+		return TokenKindOther
+	}
+}
+
+// permissionProbe is a request that Github rejects with HTTP 422 when the
+// token has a repository permission, and with 403 or 404 when it does not.
+// Probes send an invalid body, so nothing is ever created.
+type permissionProbe struct {
+	permission string
+	method     string
+	// URIFormat is formatted with the owner and name of the repository.
+	URIFormat string
+}
+
+// fineGrainedPermissionProbes verify the repository permissions needed by
+// prme, for tokens whose permissions are not described by OAuth scopes.
+var fineGrainedPermissionProbes = []permissionProbe{
+	{permission: "contents: write", method: http.MethodPost, URIFormat: "/repos/%s/git/refs"},
+	{permission: "pull requests: write", method: http.MethodPost, URIFormat: "/repos/%s/pulls"},
+}
+
+// MissingPermissionsError is returned by CheckTokenPermissions when the
+// token lacks scopes or permissions needed to create a full review.
+type MissingPermissionsError struct {
+	Repo      string
+	TokenKind string
+	Missing   []string
+}
+
+func (e *MissingPermissionsError) Error() string {
+	if e.TokenKind == TokenKindFineGrained {
+		return fmt.Sprintf("the fine-grained token is missing these permissions for repository %q: %s. Edit the token at https://github.com/settings/tokens?type=beta", e.Repo, strings.Join(e.Missing, ", "))
+	}
+	return fmt.Sprintf("the token is missing one of these scopes needed for repository %q: %s. Edit the token at https://github.com/settings/tokens", e.Repo, strings.Join(e.Missing, ", "))
+}
+
+// ErrNoPushAccess is returned by CheckTokenPermissions when the Github user
+// of the token cannot push to the repository, regardless of the token
+// scopes or permissions.
+var ErrNoPushAccess = errors.New("the Github user of the token does not have push access")
+
+// Repository permissions of a Github user, as returned by
+// GetViewerPermission.
+const (
+	PermissionAdmin    = "ADMIN"
+	PermissionMaintain = "MAINTAIN"
+	PermissionWrite    = "WRITE"
+	PermissionTriage   = "TRIAGE"
+	PermissionRead     = "READ"
+)
+
+// repoPermissions are the permissions of the authenticated user, included
+// in repository API responses.
+type repoPermissions struct {
+	Admin    bool `json:"admin"`
+	Maintain bool `json:"maintain"`
+	Push     bool `json:"push"`
+}
+
+func (p repoPermissions) canPush() bool {
+	return p.Admin || p.Maintain || p.Push
+}
+
+// GetViewerPermission returns the permission of the Github user of the token
+// for the repository, one of PermissionAdmin, PermissionMaintain,
+// PermissionWrite, PermissionTriage, or PermissionRead. An empty string is
+// returned if the user has no permission.
+func (r Repo) GetViewerPermission() (string, error) {
+	owner, name := r.splitOwnerAndName()
+	var data struct {
+		Repository *struct {
+			ViewerPermission string `json:"viewerPermission"`
+		} `json:"repository"`
+	}
+	err := r.Client.graphQL(`query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    viewerPermission
+  }
+}`, map[string]interface{}{"owner": owner, "name": name}, &data)
+	if err != nil {
+		return "", fmt.Errorf("while getting the viewer permission for repository %q: %w", r, err)
+	}
+	if data.Repository == nil {
+		return "", fmt.Errorf("repository %q does not exist or the access token does not provide access", r)
+	}
+	return data.Repository.ViewerPermission, nil
+}
+
+// HasPushAccess returns true if the Github user of the token can push to the
+// repository, such as to verify access before queuing work. The permissions
+// of the user are independent of the scopes or permissions of the token,
+// which are verified by CheckTokenPermissions.
+func (r Repo) HasPushAccess() (bool, error) {
+	apiURI := fmt.Sprintf("/repos/%s", r)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d for %s while checking push access for repository %q", resp.StatusCode, apiURI, r)
+	}
+	var repoAPIResp struct {
+		Permissions *repoPermissions `json:"permissions"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&repoAPIResp)
+	if err != nil {
+		return false, err
+	}
+	if repoAPIResp.Permissions != nil {
+		return repoAPIResp.Permissions.canPush(), nil
+	}
+	// Some tokens, such as those of Github Apps, do not receive permissions
+	// with the repository.
+	permission, err := r.GetViewerPermission()
+	if err != nil {
+		return false, err
+	}
+	return permission == PermissionAdmin || permission == PermissionMaintain || permission == PermissionWrite, nil
+}
+
+// CheckTokenPermissions verifies that the token can create a full review in
+// the repository, before anything is changed. Classic tokens are checked
+// using their OAuth scopes. Fine-grained tokens do not have scopes, so each
+// needed repository permission is verified using a probe request. A
+// MissingPermissionsError lists what is missing. ErrNoPushAccess is returned
+// if the Github user of the token cannot push to the repository.
+func (r Repo) CheckTokenPermissions() error {
+	apiURI := fmt.Sprintf("/repos/%s", r)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while checking token permissions for repository %q", resp.StatusCode, apiURI, r)
+	}
+	var repoAPIResp struct {
+		Private     bool             `json:"private"`
+		Permissions *repoPermissions `json:"permissions"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&repoAPIResp)
+	if err != nil {
+		return err
+	}
+	// The permissions of the user are included in the response, so
+	// HasPushAccess would repeat the request.
+	if repoAPIResp.Permissions != nil && !repoAPIResp.Permissions.canPush() {
+		return fmt.Errorf("%w to repository %q, ask an owner of the repository for write access", ErrNoPushAccess, r)
+	}
+	kind := TokenKind(r.Client.token)
+	scopesHeader, hasScopes := resp.Header["X-Oauth-Scopes"]
+	if hasScopes && kind != TokenKindFineGrained {
+		for _, scope := range strings.Split(strings.Join(scopesHeader, ","), ",") {
+			scope = strings.TrimSpace(scope)
+			if scope == "repo" || (scope == "public_repo" && !repoAPIResp.Private) {
+				return nil
+			}
+		}
+		missing := []string{"repo"}
+		if !repoAPIResp.Private {
+			missing = append(missing, "public_repo")
+		}
+		return &MissingPermissionsError{Repo: r.String(), TokenKind: kind, Missing: missing}
+	}
+	var missing []string
+	for _, probe := range fineGrainedPermissionProbes {
+		probeURI := fmt.Sprintf(probe.URIFormat, r)
+		probeResp, err := r.Client.MakeAPIRequestWithData(probe.method, probeURI, []byte("{}"))
+		if err != nil {
+			return err
+		}
+		probeResp.Body.Close()
+		switch probeResp.StatusCode {
+		case http.StatusUnprocessableEntity:
+		case http.StatusForbidden, http.StatusNotFound:
+			missing = append(missing, probe.permission)
+		default:
+			return fmt.Errorf("unexpected HTTP %d for %s while checking the %q permission for repository %q", probeResp.StatusCode, probeURI, probe.permission, r)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Repo: r.String(), TokenKind: TokenKindFineGrained, Missing: missing}
+	}
+	return nil
+}
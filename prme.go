@@ -2,51 +2,389 @@ package prme
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Client struct {
 	token, apiHost string
 	httpClient     *http.Client
+	perPage        int
+	middleware     []Middleware
+	// socksProxy is also used by git commands, when set by WithSOCKSProxy.
+	socksProxy *url.URL
+	clock      Clock
+	rateMu     sync.Mutex
+	rate       RateLimit
+	// rateLimitOverrides are keyed by API host name, set by
+	// WithRateLimitOverride.
+	rateLimitOverrides map[string]RateLimitOverride
+	// gitProtocol is set by WithGitProtocol, and empty means
+	// GitProtocolSSH.
+	gitProtocol string
+	// gitHostOverride is set by WithGitHost, and empty means the git host
+	// is derived from the API host.
+	gitHostOverride string
+	// maxResponseSize is set by WithMaxResponseSize.
+	maxResponseSize int64
 }
 
-// clientOption specifies prme client options as functions.
-type clientOption func(*Client) error
+// Doer sends an HTTP request and returns its response, like
+// net/http.Client.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to the Doer interface.
+type DoerFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer, such as to log, retry, or alter API requests. The
+// returned Doer should call next to continue sending the request.
+type Middleware func(next Doer) Doer
+
+// ClientOption specifies prme client options as functions.
+type ClientOption func(*Client) error
 
 // WithAPIHost sets the Github API hostname for an instance of the client.
-func WithAPIHost(host string) clientOption {
+func WithAPIHost(host string) ClientOption {
 	return func(c *Client) error {
 		c.apiHost = host
 		return nil
 	}
 }
 
+// WithBaseURL sets the full URL of the Github API, including any path, such
+// as https://github.example.com/api/v3 for a Github Enterprise Server. API
+// requests are made below its path, and the host of git remotes is derived
+// from it, as described by WithGitHost.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("the Github API base URL must be an http or https URL, not %q", baseURL)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("the Github API base URL cannot include a query or fragment, %q", baseURL)
+		}
+		c.apiHost = strings.TrimSuffix(baseURL, "/")
+		return nil
+	}
+}
+
 // WithHTTPClient sets a custom net/http.Client for an instance of the client.
-func WithHTTPClient(hc *http.Client) clientOption {
+func WithHTTPClient(hc *http.Client) ClientOption {
 	return func(c *Client) error {
 		c.httpClient = hc
 		return nil
 	}
 }
 
-func NewClient(token string, options ...clientOption) (*Client, error) {
+// WithDialContext sets the function used to open network connections for
+// API requests, such as to route requests through a custom dialer.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(c *Client) error {
+		if dial == nil {
+			return errors.New("the dial function cannot be nil")
+		}
+		t := c.transport()
+		t.DialContext = dial
+		c.setTransport(t)
+		return nil
+	}
+}
+
+// WithUnixSocket sends API requests over the unix socket path, such as to a
+// local API gateway, instead of connecting to the API host.
+func WithUnixSocket(path string) ClientOption {
+	return func(c *Client) error {
+		if path == "" {
+			return errors.New("the unix socket path cannot be empty")
+		}
+		var d net.Dialer
+		return WithDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", path)
+		})(c)
+	}
+}
+
+// WithIPVersion connects to the API using only IPv4 or IPv6, specified as
+// 4 or 6, such as on networks where one is broken.
+func WithIPVersion(version int) ClientOption {
+	return func(c *Client) error {
+		var forcedNetwork string
+		switch version {
+		case 4:
+			forcedNetwork = "tcp4"
+		case 6:
+			forcedNetwork = "tcp6"
+		default:
+			return fmt.Errorf("the IP version must be 4 or 6, not %d", version)
+		}
+		t := c.transport()
+		dial := transportDialer(t)
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if network == "tcp" {
+				network = forcedNetwork
+			}
+			return dial(ctx, network, addr)
+		}
+		c.setTransport(t)
+		return nil
+	}
+}
+
+// WithAPIAddresses connects to the API host using the IP addresses addrs,
+// tried in order, instead of resolving the host name, such as on networks
+// where its resolution is broken or intercepted. TLS certificates are still
+// verified for the API host name.
+func WithAPIAddresses(addrs ...string) ClientOption {
+	return func(c *Client) error {
+		if len(addrs) == 0 {
+			return errors.New("at least one API address is required")
+		}
+		for _, addr := range addrs {
+			if net.ParseIP(addr) == nil {
+				return fmt.Errorf("the API address %q is not an IP address", addr)
+			}
+		}
+		t := c.transport()
+		dial := transportDialer(t)
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			// The API host is read when dialing, as WithAPIHost can be applied
+			// after this option.
+			apiURL, err := url.Parse(c.apiHost)
+			if err != nil || !strings.EqualFold(host, apiURL.Hostname()) {
+				return dial(ctx, network, addr)
+			}
+			var conn net.Conn
+			for _, pinned := range addrs {
+				conn, err = dial(ctx, network, net.JoinHostPort(pinned, port))
+				if err == nil {
+					return conn, nil
+				}
+			}
+			return nil, err
+		}
+		c.setTransport(t)
+		return nil
+	}
+}
+
+// WithClientCertificate presents the client certificate and private key,
+// read from the PEM encoded certFile and keyFile, when connecting to the
+// API, such as for enterprise gateways that require mutual TLS.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(c *Client) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("both a client certificate and key file are required")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("while loading the client certificate: %w", err)
+		}
+		t := c.transport()
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, cert)
+		c.setTransport(t)
+		return nil
+	}
+}
+
+// transportDialer returns the dial function of t, or the default dialer of
+// net/http if it has none.
+func transportDialer(t *http.Transport) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t.DialContext != nil {
+		return t.DialContext
+	}
+	d := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return d.DialContext
+}
+
+// transport returns a copy of the HTTP transport of c, or of the default
+// transport if c does not use an *http.Transport, for options to modify.
+func (c *Client) transport() *http.Transport {
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
+
+// setTransport sets the HTTP transport of c, copying its HTTP client, which
+// may have been supplied by WithHTTPClient.
+func (c *Client) setTransport(t *http.Transport) {
+	hc := *c.httpClient
+	hc.Transport = t
+	c.httpClient = &hc
+}
+
+// WithPerPage sets the number of results per page, between 1 and 100, used
+// by methods that list multiple pages of results. The default is 100.
+func WithPerPage(perPage int) ClientOption {
+	return func(c *Client) error {
+		if perPage < 1 || perPage > 100 {
+			return fmt.Errorf("the number of results per page must be between 1 and 100, not %d", perPage)
+		}
+		c.perPage = perPage
+		return nil
+	}
+}
+
+// WithMiddleware adds middleware to the chain that sends API requests for an
+// instance of the client. The first middleware is the outermost, seeing
+// each request first and each response last.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *Client) error {
+		for _, m := range middleware {
+			if m == nil {
+				return errors.New("middleware cannot be nil")
+			}
+		}
+		c.middleware = append(c.middleware, middleware...)
+		return nil
+	}
+}
+
+// DefaultMaxResponseSize is the largest Github API response read by a
+// client, unless changed by WithMaxResponseSize. It allows the largest
+// blobs returned by the API, whose content is base64 encoded.
+const DefaultMaxResponseSize = 256 << 20
+
+// maxErrorResponseSize is the largest Github API response read for an
+// unsuccessful request, whose body only describes the error.
+const maxErrorResponseSize = 1 << 20
+
+// WithMaxResponseSize sets the largest Github API response, in bytes, that
+// the client reads. Reading a larger response returns a
+// ResponseTooLargeError, instead of growing memory without bound.
+func WithMaxResponseSize(size int64) ClientOption {
+	return func(c *Client) error {
+		if size < 1 {
+			return fmt.Errorf("the maximum response size must be at least 1 byte, not %d", size)
+		}
+		c.maxResponseSize = size
+		return nil
+	}
+}
+
+// limitResponseSize returns a Doer that sends requests using next, whose
+// response bodies return a ResponseTooLargeError once more than limit bytes
+// are read, or maxErrorResponseSize for unsuccessful requests. Responses
+// declaring a larger Content-Length are rejected before they are read.
+func limitResponseSize(next Doer, limit int64) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		size := limit
+		if resp.StatusCode >= http.StatusBadRequest && size > maxErrorResponseSize {
+			size = maxErrorResponseSize
+		}
+		tooLarge := &ResponseTooLargeError{URI: req.URL.RequestURI(), Limit: size}
+		if resp.ContentLength > size {
+			resp.Body.Close()
+			return nil, tooLarge
+		}
+		resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: size, tooLarge: tooLarge}
+		return resp, nil
+	})
+}
+
+// limitedBody is a response body that returns tooLarge once more than
+// remaining bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	tooLarge  error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if b.remaining == 0 {
+		// Only an attempt to read past the limit is an error.
+		var extra [1]byte
+		n, err := b.ReadCloser.Read(extra[:])
+		if n > 0 {
+			return 0, b.tooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// doer returns the HTTP client of c, wrapped by its middleware. The size of
+// responses is limited before any middleware reads them.
+func (c *Client) doer() Doer {
+	d := limitResponseSize(c.httpClient, c.maxResponseSize)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		d = c.middleware[i](d)
+	}
+	return d
+}
+
+// apiTransport is the HTTP transport of clients that do not customize it,
+// shared so clients created for each repository of a batch reuse
+// connections to the API host. More connections per host are kept idle
+// than by default, and HTTP/2 is used when the API host supports it.
+var apiTransport = newAPITransport()
+
+func newAPITransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	t.MaxIdleConnsPerHost = 10
+	return t
+}
+
+func NewClient(token string, options ...ClientOption) (*Client, error) {
 	if token == "" {
 		return nil, errors.New("the Github token cannot be empty, please specify a personal access token")
 	}
 
 	c := &Client{
-		token:      token,
-		apiHost:    "https://api.github.com",
-		httpClient: &http.Client{Timeout: time.Second * 10},
+		token:           token,
+		apiHost:         "https://api.github.com",
+		httpClient:      &http.Client{Timeout: time.Second * 10, Transport: apiTransport},
+		perPage:         100,
+		clock:           systemClock{},
+		maxResponseSize: DefaultMaxResponseSize,
 	}
 
 	for _, o := range options {
@@ -58,80 +396,308 @@ func NewClient(token string, options ...clientOption) (*Client, error) {
 	return c, nil
 }
 
-func (c *Client) MakeAPIRequest(method, URI string) (*http.Response, error) {
+// RateLimit is the Github API rate limit, as of the most recent response.
+type RateLimit struct {
+	Limit, Remaining int
+	Reset            time.Time
+}
+
+// newRateLimit returns the rate limit described by the headers of resp, and
+// whether those headers were present.
+func newRateLimit(resp *http.Response) (RateLimit, bool) {
+	var rl RateLimit
+	var err error
+	rl.Limit, err = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl.Remaining, err = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	resetEpoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl.Reset = time.Unix(resetEpoch, 0)
+	return rl, true
+}
+
+// Response wraps a Github API HTTP response, adding the rate limit and the
+// URI of the next page of results.
+type Response struct {
+	*http.Response
+	Rate RateLimit
+	// NextPageURI is relative to the API host, and empty when there are no
+	// more pages.
+	NextPageURI string
+}
+
+// Do makes an API request to URI, which is relative to the API host,
+// including body if it is not nil. The Response includes rate limit and
+// pagination information, which is also tracked by the client.
+func (c *Client) Do(method, URI string, body []byte) (*Response, error) {
 	if !strings.HasPrefix(URI, "/") {
 		URI = "/" + URI
 	}
 	URL := c.apiHost + URI
-	req, err := http.NewRequest(method, URL, nil)
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, URL, bodyReader)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("token %s", c.token))
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doer().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	err = serviceError(URI, resp)
 	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+	r := &Response{
+		Response:    resp,
+		NextPageURI: c.nextPageURI(resp),
+	}
+	// Github Enterprise Server omits rate limit headers when rate limiting
+	// is disabled.
+	rl, ok := newRateLimit(resp)
+	if ok && !c.rateLimitOverride().Disabled {
+		r.Rate = rl
+		c.rateMu.Lock()
+		c.rate = rl
+		c.rateMu.Unlock()
+	}
+	return r, nil
 }
 
-func (c *Client) MakeAPIRequestWithData(method, URI string, body []byte) (*http.Response, error) {
-	if strings.HasPrefix(URI, "/") == false {
-		URI = "/" + URI
+// RateLimit returns the Github API rate limit as of the most recent response
+// received by the client. The zero value is returned if no response included
+// a rate limit, such as from a Github Enterprise Server instance with rate
+// limiting disabled, or if the rate limit of the API host is disabled by
+// WithRateLimitOverride.
+func (c *Client) RateLimit() RateLimit {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	return c.rate
+}
+
+// CheckToken verifies that the Github API is reachable and accepts the
+// token of the client. The rate limit endpoint is used, which does not
+// count against the rate limit.
+func (c *Client) CheckToken() error {
+	resp, err := c.Do(http.MethodGet, "/rate_limit", nil)
+	if err != nil {
+		return fmt.Errorf("while reaching the Github API: %w", err)
 	}
-	URL := c.apiHost + URI
-	req, err := http.NewRequest(method, URL, bytes.NewReader(body))
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("the Github token is invalid or expired")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status %d from the Github API", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) MakeAPIRequest(method, URI string) (*http.Response, error) {
+	resp, err := c.Do(method, URI, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("token %s", c.token))
-	resp, err := c.httpClient.Do(req)
+	return resp.Response, nil
+}
+
+func (c *Client) MakeAPIRequestWithData(method, URI string, body []byte) (*http.Response, error) {
+	resp, err := c.Do(method, URI, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Response, nil
+}
+
+// defaultMaxRateLimitWait is the longest MakeAPIRequestWithRateLimit will
+// wait for a Github rate limit to reset, before returning an error, unless
+// changed by WithRateLimitOverride.
+const defaultMaxRateLimitWait = 2 * time.Minute
+
+// rateLimitWait returns how long to wait before retrying the request that
+// returned resp, and whether resp indicates that a rate limit was exceeded.
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	rl, ok := newRateLimit(resp)
+	if !ok || rl.Remaining != 0 {
+		return 0, false
+	}
+	wait := rl.Reset.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// MakeAPIRequestWithRateLimit makes an API request like MakeAPIRequest, but
+// waits for a Github rate limit to reset and retries the request once, if
+// the primary or abuse (secondary) rate limit has been exceeded.
+func (c *Client) MakeAPIRequestWithRateLimit(method, URI string) (*http.Response, error) {
+	resp, err := c.MakeAPIRequest(method, URI)
+	var abuseErr *AbuseRateLimitError
+	if errors.As(err, &abuseErr) && abuseErr.RetryAfter <= c.maxRateLimitWait() {
+		c.clock.Sleep(abuseErr.RetryAfter)
+		return c.MakeAPIRequest(method, URI)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+	if c.rateLimitOverride().Disabled {
+		return resp, nil
+	}
+	wait, limited := rateLimitWait(resp, c.clock.Now())
+	if !limited {
+		return resp, nil
+	}
+	resp.Body.Close()
+	if wait > c.maxRateLimitWait() {
+		return nil, &RateLimitError{URI: URI, RetryAfter: wait}
+	}
+	c.clock.Sleep(wait)
+	return c.MakeAPIRequest(method, URI)
+}
+
+// nextPageURI returns the URI of the next page of results from the Link
+// header of resp, relative to the API host of the client. An empty string
+// is returned if there are no more pages.
+func (c *Client) nextPageURI(resp *http.Response) string {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		segments := strings.Split(link, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		isNext := false
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+		nextURL := strings.Trim(strings.TrimSpace(segments[0]), "<>")
+		URI, err := c.relativeURI(nextURL)
+		if err != nil {
+			return ""
+		}
+		return URI
+	}
+	return ""
+}
+
+// apiBasePath returns the path component of the API host, which is empty
+// for api.github.com.
+func (c *Client) apiBasePath() string {
+	u, err := url.Parse(c.apiHost)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// getAllPages makes GET API requests for URI and each subsequent page of
+// results, calling decodePage with the body of each page.
+func (c *Client) getAllPages(URI string, decodePage func(io.Reader) error) error {
+	separator := "?"
+	if strings.Contains(URI, "?") {
+		separator = "&"
+	}
+	URI = fmt.Sprintf("%s%sper_page=%d", URI, separator, c.perPage)
+	for URI != "" {
+		resp, err := c.MakeAPIRequestWithRateLimit(http.MethodGet, URI)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("HTTP %d for %s", resp.StatusCode, URI)
+		}
+		err = decodePage(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		URI = c.nextPageURI(resp)
+	}
+	return nil
 }
 
 func RunGitCommand(workingDir string, arg string, extraArgs ...string) (string, error) {
-	args := append([]string{arg}, extraArgs...)
+	return runGit(workingDir, nil, append([]string{arg}, extraArgs...)...)
+}
+
+// runGit runs git with args in workingDir, adding env to the environment.
+func runGit(workingDir string, env []string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = workingDir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("command %q returned error %w and output: %s", cmd, err, output)
+		// The command and output can include credentials of a git remote.
+		return "", &GitCommandError{Command: Redact(cmd.String()), Output: Redact(string(output)), Err: err}
 	}
 	return strings.TrimSuffix(string(output), "\n"), nil
 }
 
-type repo struct {
+// Repo is a Github repository, whose methods use the Github API. Create it
+// with NewRepo.
+type Repo struct {
 	Client       *Client
 	ownerAndName string
 }
 
-func (r repo) String() string {
+func (r Repo) String() string {
 	return r.ownerAndName
 }
 
-func NewRepo(ownerAndName, token string, clientOptions ...clientOption) (*repo, error) {
+// NewRepo returns the repository ownerAndName, of the form
+// OwnerName/RepositoryName, whose API requests are authenticated by token.
+func NewRepo(ownerAndName, token string, clientOptions ...ClientOption) (*Repo, error) {
 	if ownerAndName == "" {
 		return nil, errors.New("the repository cannot be empty, please specify a repository of the form OwnerName/RepositoryName")
 	}
-	if !strings.Contains(ownerAndName, "/") {
-		return nil, errors.New("the repository must be of the form OwnerName/RepositoryName")
+	err := ValidateRepoName(ownerAndName)
+	if err != nil {
+		return nil, err
 	}
 	c, err := NewClient(token, clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("while constructing client for repository: %w", err)
 	}
-	return &repo{
+	return &Repo{
 		Client:       c,
 		ownerAndName: ownerAndName,
 	}, nil
 }
 
-func (r repo) Exists() (bool, error) {
+// Exists returns true if the repository exists. If the Github API redirects,
+// as it does for a repository that was renamed or transferred, such as to an
+// organization that forked it privately, the redirect is followed and r is
+// updated to the owner and name of the canonical repository, which are used
+// for subsequent calls.
+func (r *Repo) Exists() (bool, error) {
 	apiURI := fmt.Sprintf("/repos/%s", r)
-	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	resp, redirected, err := r.getRepoFollowingRedirect()
 	if err != nil {
 		return false, err
 	}
@@ -149,13 +715,36 @@ func (r repo) Exists() (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	// Use the case of the canonical name in subsequent calls and messages.
+	if (redirected && repoAPIResp.FullName != "") || strings.EqualFold(repoAPIResp.FullName, r.String()) {
+		r.ownerAndName = repoAPIResp.FullName
+	}
 	if strings.ToLower(repoAPIResp.FullName) != strings.ToLower(r.String()) {
 		return false, fmt.Errorf("incorrect repository name %q returned while checking if repository %q exists", repoAPIResp.FullName, r)
 	}
 	return true, nil
 }
 
-func (r repo) CommitExists(ref string) (bool, error) {
+// GetRepository returns the properties of the repository.
+func (r Repo) GetRepository() (*Repository, error) {
+	apiURI := fmt.Sprintf("/repos/%s", r)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting repository %q", resp.StatusCode, apiURI, r)
+	}
+	var repository Repository
+	err = json.NewDecoder(resp.Body).Decode(&repository)
+	if err != nil {
+		return nil, err
+	}
+	return &repository, nil
+}
+
+func (r Repo) CommitExists(ref string) (bool, error) {
 	apiURI := fmt.Sprintf("/repos/%s/git/commits/%s", r, ref)
 	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
 	if err != nil {
@@ -179,7 +768,16 @@ func (r repo) CommitExists(ref string) (bool, error) {
 	return true, nil
 }
 
-func (r repo) CreateOrphanBranches(branchNames ...string) error {
+// CloneURL returns the URL used by git commands to clone the repository,
+// using the git host and protocol of its client.
+func (r Repo) CloneURL() string {
+	if r.Client.gitProtocol == GitProtocolHTTPS {
+		return fmt.Sprintf("https://%s/%s.git", r.Client.gitHost(), r)
+	}
+	return fmt.Sprintf("ssh://git@%s/%s", r.Client.gitHost(), r)
+}
+
+func (r Repo) CreateOrphanBranches(branchNames ...string) error {
 	if len(branchNames) == 0 {
 		return errors.New("please supply at least one branch name")
 	}
@@ -188,18 +786,18 @@ func (r repo) CreateOrphanBranches(branchNames ...string) error {
 			return fmt.Errorf("branchName[%d] cannot be empty", i)
 		}
 	}
-	repoURL := fmt.Sprintf("ssh://git@github.com/%s", r)
+	repoURL := r.CloneURL()
 	tempDir, err := os.MkdirTemp("", "pr-me-")
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tempDir)
 	tempDirWithRepo := tempDir + "/" + r.String()
-	_, err = RunGitCommand(tempDir, "clone", repoURL, r.String())
+	_, err = r.runGitCommand(tempDir, "clone", repoURL, r.String())
 	if err != nil {
 		return err
 	}
-	commitSha, err := RunGitCommand(tempDirWithRepo, "commit-tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", "-m", "empty-tree commit")
+	commitSha, err := RunGitCommand(tempDirWithRepo, "commit-tree", EmptyTreeSha, "-m", emptyTreeCommitMessage)
 	if err != nil {
 		return err
 	}
@@ -213,14 +811,14 @@ func (r repo) CreateOrphanBranches(branchNames ...string) error {
 		}
 	}
 	gitPushArgs := append([]string{"origin"}, branchNames...)
-	_, err = RunGitCommand(tempDirWithRepo, "push", gitPushArgs...)
+	_, err = r.runGitCommand(tempDirWithRepo, "push", gitPushArgs...)
 	if err != nil {
-		return err
+		return explainPushError(r.String(), err)
 	}
 	return nil
 }
 
-func (r repo) BranchExists(branch string) (bool, error) {
+func (r Repo) BranchExists(branch string) (bool, error) {
 	apiURI := fmt.Sprintf("/repos/%s/branches/%s", r, branch)
 	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
 	if err != nil {
@@ -244,94 +842,932 @@ func (r repo) BranchExists(branch string) (bool, error) {
 	return true, nil
 }
 
-// MergeBranch merges headBranch into baseBranch in the given repository.
-func (r repo) MergeBranch(baseBranch, headBranch string) error {
-	apiURI := fmt.Sprintf("/repos/%s/merges", r)
-	mergeJSON := fmt.Sprintf(`{"base":"%s","head":"%s"}`, baseBranch, headBranch)
-	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, []byte(mergeJSON))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("HTTP %d for %s while merging branch %q into %q in repository %q", resp.StatusCode, apiURI, headBranch, baseBranch, r)
-	}
-	return nil
+// qualifiedRef returns ref without a leading "refs/", such as heads/main, which
+// is the form the Github git refs API expects in its URIs.
+func qualifiedRef(ref string) string {
+	return strings.TrimPrefix(ref, "refs/")
 }
 
-// CreatePullRequest creates a pull request using the specified properties.
-// returning the PR URL.
-func (r repo) CreatePullRequest(title, body, baseBranch, headBranch string) (PRURL string, err error) {
-	apiURI := fmt.Sprintf("/repos/%s/pulls", r)
-	PRJSON := fmt.Sprintf(`{"title":"%s","body":"%s","base":"%s","head":"%s"}`, title, body, baseBranch, headBranch)
-	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, []byte(PRJSON))
+// ErrRefNotFound is returned by GetRef when a git reference does not exist.
+var ErrRefNotFound = errors.New("the git reference does not exist")
+
+// ErrRefExists is returned by CreateRef when a git reference already exists.
+var ErrRefExists = errors.New("the git reference already exists")
+
+// GetRef returns the commit sha that the git reference ref, such as
+// heads/branchName, points at.
+func (r Repo) GetRef(ref string) (sha string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/ref/%s", r, qualifiedRef(ref))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
 	if err != nil {
 		return "", err
 	}
-	if resp.StatusCode != http.StatusCreated {
-		return "", fmt.Errorf("HTTP %d for %s while creating pull request in repository %q, base branch %q, and head branch %q", resp.StatusCode, apiURI, r, baseBranch, headBranch)
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s in repository %q", ErrRefNotFound, ref, r)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d for %s while getting reference %q in repository %q", resp.StatusCode, apiURI, ref, r)
 	}
-	var PRAPIResp struct {
-		HTMLURL *string `json:"html_url"`
+	var refAPIResp struct {
+		Ref    string
+		Object struct{ Sha string }
 	}
-	err = json.NewDecoder(resp.Body).Decode(&PRAPIResp)
+	err = json.NewDecoder(resp.Body).Decode(&refAPIResp)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if PRAPIResp.HTMLURL == nil {
-		return "", errors.New("the Github API did not return a pull request HTML URL")
+	if refAPIResp.Ref != "refs/"+qualifiedRef(ref) {
+		return "", fmt.Errorf("incorrect reference %q returned while getting reference %q", refAPIResp.Ref, ref)
 	}
-	return *PRAPIResp.HTMLURL, nil
+	return refAPIResp.Object.Sha, nil
 }
 
-type FullPullRequestCreator struct {
-	Token, Repo, FullRepoBranch, Title, Body, BaseBranch, HeadBranch string
+// createRefRequest is the request body of CreateRef.
+type createRefRequest struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
 }
 
-type fullPullRequestCreatorOption func(*FullPullRequestCreator) error
-
-func WithToken(token string) fullPullRequestCreatorOption {
-	return func(f *FullPullRequestCreator) error {
-		if token == "" {
-			return errors.New("token cannot be empty, please specify a Github personal access token")
-		}
-		f.Token = token
-		return nil
+func newCreateRefRequest(ref, sha string) createRefRequest {
+	return createRefRequest{
+		Ref: "refs/" + qualifiedRef(ref),
+		Sha: sha,
 	}
 }
 
-func WithFullRepoBranch(branch string) fullPullRequestCreatorOption {
-	return func(f *FullPullRequestCreator) error {
-		if branch == "" {
-			return errors.New("the full repo branch cannot be empty")
+// CreateRef creates the git reference ref, such as heads/branchName, pointing
+// at the commit sha. ErrRefExists is returned if ref already exists.
+func (r Repo) CreateRef(ref, sha string) error {
+	apiURI := fmt.Sprintf("/repos/%s/git/refs", r)
+	refJSON, err := json.Marshal(newCreateRefRequest(ref, sha))
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, refJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		// Github also returns 422 for other invalid references, such as
+		// when the commit does not exist.
+		var errorAPIResp struct{ Message string }
+		_ = json.NewDecoder(resp.Body).Decode(&errorAPIResp)
+		if strings.Contains(errorAPIResp.Message, "Reference already exists") {
+			return fmt.Errorf("%w: %s in repository %q", ErrRefExists, ref, r)
 		}
-		f.FullRepoBranch = branch
-		return nil
+		return fmt.Errorf("HTTP %d for %s while creating reference %q at commit %q in repository %q: %s", resp.StatusCode, apiURI, ref, sha, r, errorAPIResp.Message)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP %d for %s while creating reference %q at commit %q in repository %q", resp.StatusCode, apiURI, ref, sha, r)
 	}
+	return nil
 }
 
-func WithTitle(title string) fullPullRequestCreatorOption {
-	return func(f *FullPullRequestCreator) error {
-		if title == "" {
-			return errors.New("the title cannot be empty")
-		}
-		f.Title = title
-		return nil
+// UpdateRef points the existing git reference ref at the commit sha. Unless
+// force is true, the update must be a fast-forward.
+func (r Repo) UpdateRef(ref, sha string, force bool) error {
+	apiURI := fmt.Sprintf("/repos/%s/git/refs/%s", r, qualifiedRef(ref))
+	refJSON, err := json.Marshal(struct {
+		Sha   string `json:"sha"`
+		Force bool   `json:"force"`
+	}{
+		Sha:   sha,
+		Force: force,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPatch, apiURI, refJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while updating reference %q to commit %q in repository %q", resp.StatusCode, apiURI, ref, sha, r)
 	}
+	return nil
 }
 
-func WithBody(body string) fullPullRequestCreatorOption {
-	return func(f *FullPullRequestCreator) error {
-		if body == "" {
-			return errors.New("the body cannot be empty")
+// DeleteRef deletes the git reference ref, such as heads/branchName.
+func (r Repo) DeleteRef(ref string) error {
+	apiURI := fmt.Sprintf("/repos/%s/git/refs/%s", r, qualifiedRef(ref))
+	resp, err := r.Client.MakeAPIRequest(http.MethodDelete, apiURI)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("HTTP %d for %s while deleting reference %q in repository %q", resp.StatusCode, apiURI, ref, r)
+	}
+	return nil
+}
+
+// TreeEntry is a file, directory, symlink, or submodule within a git tree.
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	// Type is one of blob, tree, or commit (a submodule).
+	Type string `json:"type"`
+	Sha  string `json:"sha"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// Tree is a git tree object. Truncated is true when Github limited the
+// number of returned entries.
+type Tree struct {
+	Sha       string      `json:"sha"`
+	Truncated bool        `json:"truncated"`
+	Entries   []TreeEntry `json:"tree"`
+}
+
+// GetTree returns the git tree for treeish, which can be a tree sha, commit
+// sha, or branch name. If recursive is true, the entries of all
+// sub-directories are included.
+func (r Repo) GetTree(treeish string, recursive bool) (*Tree, error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/trees/%s", r, treeish)
+	if recursive {
+		apiURI += "?recursive=1"
+	}
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting tree %q in repository %q", resp.StatusCode, apiURI, treeish, r)
+	}
+	var tree Tree
+	err = json.NewDecoder(resp.Body).Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Sha == "" {
+		return nil, fmt.Errorf("the Github API did not return a sha while getting tree %q in repository %q", treeish, r)
+	}
+	return &tree, nil
+}
+
+// ListFiles returns the paths of the files of treeish, a tree or commit sha.
+// If Github truncates the recursive tree, such as for repositories with
+// many files, each directory is listed separately so no files are missed.
+func (r Repo) ListFiles(treeish string) ([]string, error) {
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return r.listDirectoryFiles(tree.Sha, "")
+	}
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.Type == "blob" {
+			files = append(files, entry.Path)
+		}
+	}
+	return files, nil
+}
+
+// listDirectoryFiles returns the paths of the files of the tree treeSha and
+// its sub-directories, beginning with prefix.
+func (r Repo) listDirectoryFiles(treeSha, prefix string) ([]string, error) {
+	tree, err := r.GetTree(treeSha, false)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return nil, fmt.Errorf("Github truncated the entries of directory %q in repository %q", prefix, r)
+	}
+	var files []string
+	for _, entry := range tree.Entries {
+		switch entry.Type {
+		case "blob":
+			files = append(files, prefix+entry.Path)
+		case "tree":
+			dirFiles, err := r.listDirectoryFiles(entry.Sha, prefix+entry.Path+"/")
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, dirFiles...)
+		}
+	}
+	return files, nil
+}
+
+// Blob is the decoded content of a git blob, typically a file.
+type Blob struct {
+	Sha     string
+	Size    int64
+	Content []byte
+}
+
+// GetBlob returns the git blob with the given sha.
+func (r Repo) GetBlob(sha string) (*Blob, error) {
+	apiURI := fmt.Sprintf("/repos/%s/git/blobs/%s", r, sha)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting blob %q in repository %q", resp.StatusCode, apiURI, sha, r)
+	}
+	var blobAPIResp struct {
+		Sha, Content, Encoding string
+		Size                   int64
+	}
+	err = json.NewDecoder(resp.Body).Decode(&blobAPIResp)
+	if err != nil {
+		return nil, err
+	}
+	if blobAPIResp.Sha != sha {
+		return nil, fmt.Errorf("incorrect blob sha %q returned while getting blob %q", blobAPIResp.Sha, sha)
+	}
+	b := &Blob{
+		Sha:  blobAPIResp.Sha,
+		Size: blobAPIResp.Size,
+	}
+	switch blobAPIResp.Encoding {
+	case "base64":
+		// Github wraps base64 content across multiple lines.
+		b.Content, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(blobAPIResp.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("while decoding blob %q: %w", sha, err)
+		}
+	case "utf-8", "":
+		b.Content = []byte(blobAPIResp.Content)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q for blob %q", blobAPIResp.Encoding, sha)
+	}
+	return b, nil
+}
+
+// ErrFileNotFound is returned by GetFileContents when a file does not exist.
+var ErrFileNotFound = errors.New("file not found")
+
+// GetFileContents returns the contents of the file path on the default
+// branch of the repository.
+func (r Repo) GetFileContents(path string) ([]byte, error) {
+	apiURI := fmt.Sprintf("/repos/%s/contents/%s", r, strings.TrimPrefix(path, "/"))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s in repository %q", ErrFileNotFound, path, r)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting file %q in repository %q", resp.StatusCode, apiURI, path, r)
+	}
+	var contentsAPIResp struct {
+		Type, Content, Encoding string
+	}
+	err = json.NewDecoder(resp.Body).Decode(&contentsAPIResp)
+	if err != nil {
+		return nil, err
+	}
+	if contentsAPIResp.Type != "file" || contentsAPIResp.Encoding != "base64" {
+		return nil, fmt.Errorf("%q in repository %q is not a file with base64 content", path, r)
+	}
+	// Github wraps base64 content across multiple lines.
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(contentsAPIResp.Content, "\n", ""))
+}
+
+// CommitStatus is the state of a commit, as displayed by Github alongside
+// pull requests and branches.
+type CommitStatus struct {
+	// State is one of error, failure, pending, or success.
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Context differentiates this status from those of other systems.
+	Context string `json:"context,omitempty"`
+}
+
+// FullReviewStatusContext is the commit status context used by prme.
+const FullReviewStatusContext = "full-review"
+
+// CreateCommitStatus sets the status of the commit sha.
+func (r Repo) CreateCommitStatus(sha string, status CommitStatus) error {
+	apiURI := fmt.Sprintf("/repos/%s/statuses/%s", r, sha)
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, statusJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP %d for %s while creating %s status %q for commit %q in repository %q", resp.StatusCode, apiURI, status.State, status.Context, sha, r)
+	}
+	return nil
+}
+
+// pendingReviewStatus is the commit status set by Create, linking to the
+// pull request at PRURL.
+func pendingReviewStatus(PRURL string) CommitStatus {
+	return CommitStatus{
+		State:       "pending",
+		TargetURL:   PRURL,
+		Description: "Full repository review in progress",
+		Context:     FullReviewStatusContext,
+	}
+}
+
+// commitCommentRequest is the request body of CreateCommitComment.
+type commitCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// reviewInProgressComment is the commit comment made by Create, linking to
+// the pull request at PRURL.
+func reviewInProgressComment(PRURL string) string {
+	return fmt.Sprintf("A full review of this repository is in progress at %s", PRURL)
+}
+
+// CreateCommitComment comments on the commit sha.
+func (r Repo) CreateCommitComment(sha, body string) error {
+	apiURI := fmt.Sprintf("/repos/%s/commits/%s/comments", r, sha)
+	commentJSON, err := json.Marshal(commitCommentRequest{Body: body})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, commentJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP %d for %s while commenting on commit %q in repository %q", resp.StatusCode, apiURI, sha, r)
+	}
+	return nil
+}
+
+// ListBranches returns the names of branches in the repository that begin
+// with prefix, such as the branches created by prme.
+func (r Repo) ListBranches(prefix string) ([]string, error) {
+	var branches []string
+	apiURI := fmt.Sprintf("/repos/%s/git/matching-refs/heads/%s", r, prefix)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []struct{ Ref string }
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		for _, ref := range page {
+			branches = append(branches, strings.TrimPrefix(ref.Ref, "refs/heads/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing branches beginning with %q in repository %q: %w", prefix, r, err)
+	}
+	return branches, nil
+}
+
+// ErrAlreadyMerged is returned by MergeBranch when the head branch has
+// already been merged into the base branch, and there is nothing to merge.
+var ErrAlreadyMerged = errors.New("the head branch is already merged into the base branch")
+
+// mergeRequest is the request body of MergeBranch.
+type mergeRequest struct {
+	Base          string `json:"base"`
+	Head          string `json:"head"`
+	CommitMessage string `json:"commit_message,omitempty"`
+}
+
+// MergeBranch merges headBranch into baseBranch in the given repository,
+// returning the SHA of the created merge commit. If commitMessage is empty,
+// Github generates a default merge commit message.
+// ErrAlreadyMerged is returned if there is nothing to merge, and
+// ErrMergeConflict if the branches have conflicts.
+func (r Repo) MergeBranch(baseBranch, headBranch, commitMessage string) (mergeSha string, err error) {
+	apiURI := fmt.Sprintf("/repos/%s/merges", r)
+	mergeJSON, err := json.Marshal(mergeRequest{
+		Base:          baseBranch,
+		Head:          headBranch,
+		CommitMessage: commitMessage,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, mergeJSON)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return "", ErrAlreadyMerged
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusUnprocessableEntity {
+		var errorAPIResp struct{ Message string }
+		_ = json.NewDecoder(resp.Body).Decode(&errorAPIResp)
+		if isProtectionMessage(errorAPIResp.Message) {
+			return "", fmt.Errorf("%w: HTTP %d for %s while merging branch %q into %q in repository %q: %s", ErrBranchProtected, resp.StatusCode, apiURI, headBranch, baseBranch, r, errorAPIResp.Message)
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return "", fmt.Errorf("%w: HTTP %d for %s while merging branch %q into %q in repository %q", ErrMergeConflict, resp.StatusCode, apiURI, headBranch, baseBranch, r)
+		}
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("HTTP %d for %s while merging branch %q into %q in repository %q", resp.StatusCode, apiURI, headBranch, baseBranch, r)
+	}
+	var mergeAPIResp struct{ Sha string }
+	err = json.NewDecoder(resp.Body).Decode(&mergeAPIResp)
+	if err != nil {
+		return "", err
+	}
+	if mergeAPIResp.Sha == "" {
+		return "", fmt.Errorf("the Github API did not return a commit sha while merging branch %q into %q in repository %q", headBranch, baseBranch, r)
+	}
+	return mergeAPIResp.Sha, nil
+}
+
+// PullRequestBranch is the head or base branch of a pull request.
+type PullRequestBranch struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+// PullRequest is a Github pull request.
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	// URL is the Github API URL, and HTMLURL is the URL to view the pull
+	// request in a browser.
+	URL     string            `json:"url"`
+	HTMLURL string            `json:"html_url"`
+	State   string            `json:"state"`
+	Draft   bool              `json:"draft"`
+	Merged  bool              `json:"merged"`
+	User    User              `json:"user"`
+	Head    PullRequestBranch `json:"head"`
+	Base    PullRequestBranch `json:"base"`
+	// UpdatedAt is the most recent activity on the pull request, and
+	// ClosedAt and MergedAt are zero while the pull request is open.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ClosedAt  time.Time `json:"closed_at"`
+	MergedAt  time.Time `json:"merged_at"`
+	// ChangedFiles, Comments, and ReviewComments are only returned when
+	// getting a single pull request.
+	ChangedFiles   int `json:"changed_files"`
+	Comments       int `json:"comments"`
+	ReviewComments int `json:"review_comments"`
+}
+
+// decodePullRequest decodes a pull request from a Github API response body,
+// verifying it includes a number and HTML URL.
+func decodePullRequest(body io.Reader) (*PullRequest, error) {
+	var PR PullRequest
+	err := json.NewDecoder(body).Decode(&PR)
+	if err != nil {
+		return nil, err
+	}
+	if PR.HTMLURL == "" {
+		return nil, errors.New("the Github API did not return a pull request HTML URL")
+	}
+	if PR.Number == 0 {
+		return nil, errors.New("the Github API did not return a pull request number")
+	}
+	return &PR, nil
+}
+
+// MaxTitleLength and MaxBodyLength are the most characters Github accepts
+// in the title and body of a pull request.
+const (
+	MaxTitleLength = 256
+	MaxBodyLength  = 65536
+)
+
+// SanitizePullRequestText returns title and body with control characters
+// and invalid UTF-8 removed, so Github does not reject or mangle them.
+// Newlines and tabs are kept in the body, and replaced by spaces in the
+// title. An error is returned if either is empty, or longer than Github
+// allows, so the problem is reported before anything is created.
+func SanitizePullRequestText(title, body string) (string, string, error) {
+	clean := func(s string, keepNewlines bool) string {
+		s = strings.ToValidUTF8(s, "")
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r == '\n' || r == '\t':
+				if keepNewlines {
+					return r
+				}
+				return ' '
+			case unicode.IsControl(r):
+				return -1
+			}
+			return r
+		}, s)
+	}
+	title = strings.TrimSpace(clean(title, false))
+	body = clean(body, true)
+	if title == "" {
+		return "", "", errors.New("the title cannot be empty")
+	}
+	if strings.TrimSpace(body) == "" {
+		return "", "", errors.New("the body cannot be empty")
+	}
+	if n := utf8.RuneCountInString(title); n > MaxTitleLength {
+		return "", "", fmt.Errorf("the title is %d characters, which is more than the %d characters Github allows", n, MaxTitleLength)
+	}
+	if n := utf8.RuneCountInString(body); n > MaxBodyLength {
+		return "", "", fmt.Errorf("the body is %d characters, which is more than the %d characters Github allows", n, MaxBodyLength)
+	}
+	return title, body, nil
+}
+
+// pullRequestRequest is the request body of CreatePullRequestWithResult.
+type pullRequestRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Base  string `json:"base"`
+	Head  string `json:"head"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// CreatePullRequest creates a pull request using the specified properties,
+// returning the PR URL.
+//
+// Deprecated: Use CreatePullRequestWithResult, which returns the created
+// PullRequest.
+func (r Repo) CreatePullRequest(title, body, baseBranch, headBranch string) (PRURL string, err error) {
+	PR, err := r.CreatePullRequestWithResult(title, body, baseBranch, headBranch)
+	if err != nil {
+		return "", err
+	}
+	return PR.HTMLURL, nil
+}
+
+// CreatePullRequestWithResult creates a pull request using the specified
+// properties, returning the created pull request.
+func (r Repo) CreatePullRequestWithResult(title, body, baseBranch, headBranch string) (*PullRequest, error) {
+	return r.createPullRequest(pullRequestRequest{
+		Title: title,
+		Body:  body,
+		Base:  baseBranch,
+		Head:  headBranch,
+	})
+}
+
+// CreateDraftPullRequest creates a draft pull request using the specified
+// properties.
+func (r Repo) CreateDraftPullRequest(title, body, baseBranch, headBranch string) (*PullRequest, error) {
+	return r.createPullRequest(pullRequestRequest{
+		Title: title,
+		Body:  body,
+		Base:  baseBranch,
+		Head:  headBranch,
+		Draft: true,
+	})
+}
+
+func (r Repo) createPullRequest(PRRequest pullRequestRequest) (*PullRequest, error) {
+	baseBranch, headBranch := PRRequest.Base, PRRequest.Head
+	apiURI := fmt.Sprintf("/repos/%s/pulls", r)
+	PRJSON, err := json.Marshal(PRRequest)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, PRJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("HTTP %d for %s while creating pull request in repository %q, base branch %q, and head branch %q", resp.StatusCode, apiURI, r, baseBranch, headBranch)
+	}
+	return decodePullRequest(resp.Body)
+}
+
+// GetPullRequest returns the pull request number.
+func (r Repo) GetPullRequest(number int) (*PullRequest, error) {
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d", r, number)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting pull request %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	return decodePullRequest(resp.Body)
+}
+
+// FindOpenPullRequest returns the open pull request from headBranch into
+// baseBranch, or nil if there is none.
+func (r Repo) FindOpenPullRequest(baseBranch, headBranch string) (*PullRequest, error) {
+	owner := strings.SplitN(r.String(), "/", 2)[0]
+	apiURI := fmt.Sprintf("/repos/%s/pulls?state=open&base=%s&head=%s", r, url.QueryEscape(baseBranch), url.QueryEscape(owner+":"+headBranch))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while finding pull requests in repository %q", resp.StatusCode, apiURI, r)
+	}
+	var PRs []PullRequest
+	err = json.NewDecoder(resp.Body).Decode(&PRs)
+	if err != nil {
+		return nil, err
+	}
+	if len(PRs) == 0 {
+		return nil, nil
+	}
+	return &PRs[0], nil
+}
+
+// reviewersRequest is the request body of RequestReviewers.
+type reviewersRequest struct {
+	Reviewers     []string `json:"reviewers,omitempty"`
+	TeamReviewers []string `json:"team_reviewers,omitempty"`
+}
+
+// newReviewersRequest separates reviewers into user logins and the slugs
+// of teams, specified as organization/team.
+func newReviewersRequest(reviewers []string) reviewersRequest {
+	var request reviewersRequest
+	for _, reviewer := range reviewers {
+		if i := strings.Index(reviewer, "/"); i >= 0 {
+			request.TeamReviewers = append(request.TeamReviewers, reviewer[i+1:])
+			continue
+		}
+		request.Reviewers = append(request.Reviewers, reviewer)
+	}
+	return request
+}
+
+// RequestReviewers requests reviews of the pull request number from
+// reviewers, which are user logins, or team slugs of the form
+// organization/team.
+func (r Repo) RequestReviewers(number int, reviewers []string) error {
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", r, number)
+	requestJSON, err := json.Marshal(newReviewersRequest(reviewers))
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPost, apiURI, requestJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP %d for %s while requesting reviewers %v for pull request %d in repository %q", resp.StatusCode, apiURI, reviewers, number, r)
+	}
+	return nil
+}
+
+// ErrReviewExists is returned by Create, along with the existing pull
+// request, when a full review pull request is already open for the base and
+// head branches.
+var ErrReviewExists = errors.New("a full review pull request is already open")
+
+// ExitCodeReviewExists is the exit code of prme when a full review pull
+// request is already open for a single repository. The URL of the pull
+// request is displayed to standard output, so wrappers can treat this as
+// success.
+const ExitCodeReviewExists = 3
+
+// DefaultBranchPrefix begins the names of branches created by prme, unless
+// another prefix is specified.
+const DefaultBranchPrefix = "prme-"
+
+// baseBranchSuffix and headBranchSuffix follow the branch prefix in the
+// default base and head branch names.
+const (
+	baseBranchSuffix = "full-review"
+	headBranchSuffix = "full-content"
+)
+
+// Defaults of FullPullRequestCreator, which can be replaced by an OrgConfig.
+const (
+	defaultTitle          = "Full Review"
+	defaultBody           = "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository."
+	defaultFullRepoBranch = "main"
+)
+
+// defaultLabelColor is the color of labels created by prme.
+const defaultLabelColor = "ededed"
+
+type FullPullRequestCreator struct {
+	Repo, FullRepoBranch, Title, Body, BaseBranch, HeadBranch string
+	// token authenticates Github API requests. It is set by WithToken, the
+	// GH_TOKEN environment variable, or a host profile.
+	token string
+	// BranchPrefix begins the default base and head branch names, and
+	// identifies branches created by prme.
+	BranchPrefix string
+	// SetCommitStatus marks the head branch with a pending
+	// FullReviewStatusContext commit status, linking to the pull request.
+	SetCommitStatus bool
+	// RemindAfterDays is recorded with the review in the state store, so
+	// prme serve reminds requested reviewers once the pull request has had
+	// no activity for this many days. Zero disables reminders.
+	RemindAfterDays int
+	// CommentOnFullRepoBranch comments on the tip commit of FullRepoBranch,
+	// linking to the pull request, so people browsing the repository
+	// discover the review.
+	CommentOnFullRepoBranch bool
+	// ExcludeRepos are shell patterns of repositories for which pull requests
+	// will not be created. If AllowRepos is not empty, pull requests are only
+	// created for repositories matching one of its patterns.
+	ExcludeRepos, AllowRepos []string
+	// StateFile is the state store in which created reviews are recorded. No
+	// state is recorded if this is empty.
+	StateFile string
+	// Labels are added to the pull request, and created if they do not
+	// exist.
+	Labels []string
+	// Reviewers are requested to review the pull request. Teams are
+	// specified as organization/team.
+	Reviewers []string
+	// CheckReviewerPushAccess warns about Reviewers who cannot push to the
+	// repository, and so cannot push review fixes to the head branch.
+	CheckReviewerPushAccess bool
+	// Assignees are users assigned to the pull request, such as the owner
+	// of the review.
+	Assignees []string
+	// Milestone is the title, or otherwise number, of the milestone to which
+	// the pull request is added.
+	Milestone string
+	// BranchPicker chooses the full repository branch if FullRepoBranch does
+	// not exist. If BranchPicker is nil, the default branch of the
+	// repository is used.
+	BranchPicker BranchPicker
+	// ForceDelete deletes and recreates base and head branches that
+	// already exist, if they were created by prme.
+	ForceDelete bool
+	// BreakLock removes the lock of the repository before locking it, such
+	// as one left by a prme process that crashed. See Repo.BreakLock.
+	BreakLock bool
+	// Topic limits pull requests to repositories having this topic, such as
+	// needs-audit. RemoveTopic removes it from the repository once the pull
+	// request is created, and AddTopic is added, to mark progress.
+	Topic       string
+	RemoveTopic bool
+	AddTopic    string
+	// RetryProtectedBranches retries with alternate base and head branch
+	// names, if a branch protection rule or ruleset rejects updating them
+	// after they were created.
+	RetryProtectedBranches bool
+	// Path limits the review to a directory of the repository, such as one
+	// service of a monorepo, instead of merging the entire full repository
+	// branch into the head branch.
+	Path string
+	// MaxFileSize omits files larger than this many bytes from the head
+	// branch, listing them in OmittedFilesManifest instead, so the pull
+	// request diff can be displayed. Zero includes all files.
+	MaxFileSize int64
+	// Symlinks and Submodules are how symlinks and submodules are included
+	// in the review: LinkKeep, LinkSkip, or LinkMaterialize. Empty means
+	// LinkKeep.
+	Symlinks, Submodules string
+	// NormalizeText converts CRLF line endings to LF, removes UTF-8 byte
+	// order marks, and converts UTF-16 to UTF-8, in text files of the head
+	// branch.
+	NormalizeText bool
+	// TableOfContents adds a table of contents of the files in the review to
+	// the pull request body, once it is created.
+	TableOfContents bool
+	// Draft creates the pull request as a draft, which does not request
+	// review from code owners until it is marked ready for review.
+	Draft bool
+	// DryRun performs the verifications of Create, displays its Plan to the
+	// output set by WithOutput, and returns ErrDryRun, without changing
+	// anything.
+	DryRun bool
+	// HowTo comments on the pull request once it is created, explaining the
+	// review workflow using HowToTemplate, or the English template if it is
+	// empty.
+	HowTo         bool
+	HowToTemplate string
+	// SkipOrgConfig ignores the OrgConfig of the repository owner. Otherwise
+	// the OrgConfig replaces defaults that have not been changed.
+	SkipOrgConfig bool
+	// SkipPreflight skips verifying that neither the base and head branches
+	// nor an open pull request between them exist, for callers that already
+	// verified them, such as by listing the repositories of an
+	// organization. Existing branches then fail pushing the new ones,
+	// instead of being reported first. Branches are still verified when
+	// ForceDelete is set. The repository is still verified to exist, which
+	// finds its current name if it was renamed or transferred.
+	SkipPreflight bool
+	// clientOptions are used to construct the Github API client.
+	clientOptions []ClientOption
+	// batchRepos are multiple repositories specified on the command-line.
+	batchRepos []string
+	// batchOwner is an organization or user specified on the command-line,
+	// for whose repositories pull requests will be created.
+	batchOwner string
+	// reportFile is where RunCLI writes a RunReport, if set.
+	reportFile string
+	// profileFile is where RunCLI writes a CPU profile, if set.
+	profileFile string
+	// outputFormat is how RunCLI displays created pull requests. Empty
+	// means outputFormatText.
+	outputFormat string
+	// notifiers are notified of events. Their failures, and warnings, are
+	// displayed to errOutput if it is set. Each step of creating a review is
+	// displayed to output if it is set.
+	notifiers []Notifier
+	errOutput io.Writer
+	output    io.Writer
+	// plannedFullRepoSha is the commit of the full repository branch when
+	// the Plan being applied was made. Create fails if the branch has
+	// changed.
+	plannedFullRepoSha string
+	// clock and rand are set by WithClock and WithRandSource. When nil, the
+	// system clock and the default random source are used.
+	clock Clock
+	rand  *lockedRand
+	// chaos are the steps of Create at which to inject failures, set by
+	// WithChaos.
+	chaos map[string]bool
+	// workspaceBranches are full repository branches specified on the
+	// command-line, each of which is reviewed separately. workspaceBranch
+	// is the branch reviewed, set by ForFullRepoBranch.
+	workspaceBranches []string
+	workspaceBranch   string
+	// splitPaths are the paths of the content reviewed by one part of a
+	// review that was split, set by forSplitPart.
+	splitPaths []string
+	// sharedHTTPClient is set by shareHTTPClient, once clientOptions
+	// include an HTTP client shared by all Github API clients.
+	sharedHTTPClient bool
+}
+
+// FullPullRequestCreatorOption specifies FullPullRequestCreator options as
+// functions.
+type FullPullRequestCreatorOption func(*FullPullRequestCreator) error
+
+// WithAPIMiddleware wraps the Github API requests of f with middleware, as
+// described by the WithMiddleware client option.
+func WithAPIMiddleware(middleware ...Middleware) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.clientOptions = append(f.clientOptions, WithMiddleware(middleware...))
+		return nil
+	}
+}
+
+// WithClientOptions constructs the Github API client of f using
+// clientOptions, such as WithAPIHost for a Github Enterprise Server
+// instance, or WithHTTPClient to use a test server. They are added to any
+// client options already set, such as by command-line flags.
+func WithClientOptions(clientOptions ...ClientOption) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.clientOptions = append(f.clientOptions, clientOptions...)
+		return nil
+	}
+}
+
+func WithToken(token string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if token == "" {
+			return errors.New("token cannot be empty, please specify a Github personal access token")
+		}
+		f.token = token
+		return nil
+	}
+}
+
+func WithFullRepoBranch(branch string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if branch == "" {
+			return errors.New("the full repo branch cannot be empty")
+		}
+		f.FullRepoBranch = branch
+		return nil
+	}
+}
+
+func WithTitle(title string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if title == "" {
+			return errors.New("the title cannot be empty")
+		}
+		f.Title = title
+		return nil
+	}
+}
+
+func WithBody(body string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if body == "" {
+			return errors.New("the body cannot be empty")
 		}
 		f.Body = body
 		return nil
 	}
 }
 
-func WithBaseBranchName(branch string) fullPullRequestCreatorOption {
+func WithBaseBranchName(branch string) FullPullRequestCreatorOption {
 	return func(f *FullPullRequestCreator) error {
 		if branch == "" {
 			return errors.New("the base branch name cannot be empty")
@@ -341,28 +1777,147 @@ func WithBaseBranchName(branch string) fullPullRequestCreatorOption {
 	}
 }
 
-func WithHeadBranchName(branch string) fullPullRequestCreatorOption {
+func WithHeadBranchName(branch string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if branch == "" {
+			return errors.New("the head branch name cannot be empty")
+		}
+		f.HeadBranch = branch
+		return nil
+	}
+}
+
+// WithBranchPrefix sets the prefix of branch names created by prme, which
+// also changes the base and head branch names unless those have been
+// specified.
+func WithBranchPrefix(prefix string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		if prefix == "" {
+			return errors.New("the branch prefix cannot be empty")
+		}
+		f.setBranchPrefix(prefix)
+		return nil
+	}
+}
+
+// setBranchPrefix sets the branch prefix, and the base and head branch names
+// if they use the previous prefix and default suffixes.
+func (f *FullPullRequestCreator) setBranchPrefix(prefix string) {
+	if f.BaseBranch == f.BranchPrefix+baseBranchSuffix {
+		f.BaseBranch = prefix + baseBranchSuffix
+	}
+	if f.HeadBranch == f.BranchPrefix+headBranchSuffix {
+		f.HeadBranch = prefix + headBranchSuffix
+	}
+	f.BranchPrefix = prefix
+}
+
+// WithCommitStatus marks the head branch with a pending commit status,
+// linking to the created pull request.
+func WithCommitStatus() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.SetCommitStatus = true
+		return nil
+	}
+}
+
+// WithCommitComment comments on the tip commit of the full repository
+// branch, linking to the created pull request.
+func WithCommitComment() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.CommentOnFullRepoBranch = true
+		return nil
+	}
+}
+
+// WithSkipPreflight skips verifying that review branches and an open pull
+// request exist, as described by FullPullRequestCreator.SkipPreflight.
+func WithSkipPreflight() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.SkipPreflight = true
+		return nil
+	}
+}
+
+// WithForceDelete deletes and recreates base and head branches that already
+// exist, if they were created by prme.
+func WithForceDelete() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.ForceDelete = true
+		return nil
+	}
+}
+
+// WithBreakLock removes the lock of the repository before locking it, such
+// as one left by a prme process that crashed.
+func WithBreakLock() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.BreakLock = true
+		return nil
+	}
+}
+
+// WithDraft creates the pull request as a draft.
+func WithDraft() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.Draft = true
+		return nil
+	}
+}
+
+// WithAssignees assigns the users assignees to the pull request.
+func WithAssignees(assignees ...string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		for _, assignee := range assignees {
+			if assignee == "" {
+				return errors.New("an assignee cannot be empty")
+			}
+		}
+		f.Assignees = append(f.Assignees, assignees...)
+		return nil
+	}
+}
+
+// WithDryRun displays the review that would be created, without changing
+// anything, as described by DryRun.
+func WithDryRun() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.DryRun = true
+		return nil
+	}
+}
+
+// ErrDryRun is returned by Create when DryRun is set, once the review that
+// would be created has been displayed.
+var ErrDryRun = errors.New("dry run, nothing was changed")
+
+// WithReviewers requests reviews of the pull request from reviewers, which
+// are user logins, or teams specified as organization/team.
+func WithReviewers(reviewers ...string) FullPullRequestCreatorOption {
 	return func(f *FullPullRequestCreator) error {
-		if branch == "" {
-			return errors.New("the head branch name cannot be empty")
+		for _, reviewer := range reviewers {
+			if reviewer == "" {
+				return errors.New("a reviewer cannot be empty")
+			}
 		}
-		f.HeadBranch = branch
+		f.Reviewers = append(f.Reviewers, reviewers...)
 		return nil
 	}
 }
 
-func NewFullPullRequestCreator(repo string, options ...fullPullRequestCreatorOption) (*FullPullRequestCreator, error) {
+func NewFullPullRequestCreator(repo string, options ...FullPullRequestCreatorOption) (*FullPullRequestCreator, error) {
 	if repo == "" {
 		return nil, errors.New("repo cannot be empty")
 	}
 	f := &FullPullRequestCreator{
 		Repo:           repo,
-		Token:          "",
-		Title:          "Full Review",
-		Body:           "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
-		BaseBranch:     "prme-full-review",
-		HeadBranch:     "prme-full-content",
-		FullRepoBranch: "main",
+		token:          "",
+		Title:          defaultTitle,
+		Body:           defaultBody,
+		BranchPrefix:   DefaultBranchPrefix,
+		BaseBranch:     DefaultBranchPrefix + baseBranchSuffix,
+		HeadBranch:     DefaultBranchPrefix + headBranchSuffix,
+		FullRepoBranch: defaultFullRepoBranch,
 	}
 	for _, option := range options {
 		err := option(f)
@@ -373,79 +1928,689 @@ func NewFullPullRequestCreator(repo string, options ...fullPullRequestCreatorOpt
 	return f, nil
 }
 
-func (f FullPullRequestCreator) Create() (string, error) {
+// preparedReview is a review whose repository and options have been
+// verified by prepare.
+type preparedReview struct {
+	repo                        *Repo
+	fullRepoSha, idempotencyKey string
+	// milestone is the resolved Milestone of the review, if any.
+	milestone *Milestone
+}
+
+// prepare verifies the repository and options of f, applying the OrgConfig
+// and resolving the full repository branch, before anything is changed. If
+// the review was already created or is open, its pull request is returned
+// along with ErrAlreadyCreated or ErrReviewExists. The token permissions
+// needed to create the review are only verified if checkPermissions is
+// true.
+func (f *FullPullRequestCreator) prepare(checkPermissions bool) (_ *preparedReview, existing *PullRequest, err error) {
 	if f.FullRepoBranch == "" {
-		return "", errors.New("the full repo branch cannot be empty")
+		return nil, nil, errors.New("the full repo branch cannot be empty")
 	}
 	if f.BaseBranch == "" {
-		return "", errors.New("the base branch cannot be empty")
+		return nil, nil, errors.New("the base branch cannot be empty")
 	}
 	if f.HeadBranch == "" {
-		return "", errors.New("the head branch cannot be empty")
+		return nil, nil, errors.New("the head branch cannot be empty")
 	}
 	if f.Title == "" {
-		return "", errors.New("the title cannot be empty")
+		return nil, nil, errors.New("the title cannot be empty")
 	}
 	if f.Body == "" {
-		return "", errors.New("the body cannot be empty")
+		return nil, nil, errors.New("the body cannot be empty")
 	}
-	r, err := NewRepo(f.Repo, f.Token)
+	if !f.repoAllowed(f.Repo) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRepoExcluded, f.Repo)
+	}
+	r, err := NewRepo(f.Repo, f.token, f.clientOptions...)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+	// Checks run concurrently because batches repeat them for every
+	// repository. The first checks do not depend on the organization
+	// configuration, which is retrieved meanwhile. The repository is
+	// checked using a copy of r, as checking it exists updates its name if
+	// the repository was renamed or transferred.
+	var cfg *OrgConfig
+	canonical := *r
+	err = runChecks(
+		func() error {
+			ok, err := canonical.Exists()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("repository %q does not exist or the access token does not provide access", r)
+			}
+			return nil
+		},
+		func() error {
+			return f.checkTopic(r)
+		},
+		func() error {
+			if !checkPermissions {
+				return nil
+			}
+			return r.CheckTokenPermissions()
+		},
+		func() (err error) {
+			if !f.SkipOrgConfig {
+				cfg, err = f.orgConfig()
+			}
+			return err
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.EqualFold(repoOwner(canonical.String()), repoOwner(r.String())) && !f.SkipOrgConfig {
+		// The repository was transferred to another owner, whose
+		// configuration applies.
+		f.Repo = canonical.String()
+		cfg, err = f.orgConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	*r = canonical
+	f.Repo = r.String()
+	if cfg != nil {
+		f.applyOrgConfig(cfg)
 	}
-	ok, err := r.Exists()
+	f.Title, f.Body, err = SanitizePullRequestText(f.Title, f.Body)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-	if !ok {
-		return "", fmt.Errorf("repository %q does not exist or the access token does not provide access", r)
+	err = f.checkReviewerPushAccess(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The BranchPicker may prompt for the full repository branch, so it is
+	// resolved before the remaining checks, rather than prompting while
+	// they run.
+	var (
+		milestone   *Milestone
+		fullRepoSha string
+		openPR      *PullRequest
+	)
+	if f.BranchPicker != nil {
+		f.FullRepoBranch, fullRepoSha, err = f.resolveFullRepoBranch(r)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	ok, err = r.BranchExists(f.FullRepoBranch)
+	err = runChecks(
+		func() (err error) {
+			if fullRepoSha == "" {
+				f.FullRepoBranch, fullRepoSha, err = f.resolveFullRepoBranch(r)
+				if err != nil {
+					return err
+				}
+			}
+			if f.plannedFullRepoSha != "" && fullRepoSha != f.plannedFullRepoSha {
+				return fmt.Errorf("%w: branch %q of repository %q is at commit %s, not %s", ErrPlanOutdated, f.FullRepoBranch, r, fullRepoSha, f.plannedFullRepoSha)
+			}
+			if f.Path != "" {
+				_, err = r.SubtreeSha(fullRepoSha, f.Path)
+				if err != nil {
+					return err
+				}
+			}
+			// The merge commit message includes the resolved full
+			// repository branch.
+			return r.CheckBranchRules(f.HeadBranch, emptyTreeCommitMessage, f.mergeCommitMessage())
+		},
+		func() error {
+			return r.CheckBranchRules(f.BaseBranch, emptyTreeCommitMessage)
+		},
+		func() (err error) {
+			if f.Milestone != "" {
+				milestone, err = r.ResolveMilestone(f.Milestone)
+			}
+			return err
+		},
+		func() (err error) {
+			if !f.ForceDelete && !f.SkipPreflight {
+				openPR, err = r.FindOpenPullRequest(f.BaseBranch, f.HeadBranch)
+			}
+			return err
+		},
+	)
 	if err != nil {
+		return nil, nil, err
+	}
+	idempotencyKey := f.IdempotencyKey(fullRepoSha)
+	if f.StateFile != "" {
+		store, err := NewStateStore(f.StateFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		record, err := store.FindReview(idempotencyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if record != nil {
+			return nil, &PullRequest{
+				Number:  record.Number,
+				HTMLURL: record.URL,
+				State:   record.State,
+			}, fmt.Errorf("%w: %s", ErrAlreadyCreated, record.URL)
+		}
+	}
+	if openPR != nil {
+		return nil, openPR, fmt.Errorf("%w: %s", ErrReviewExists, openPR.HTMLURL)
+	}
+	return &preparedReview{
+		repo:           r,
+		fullRepoSha:    fullRepoSha,
+		idempotencyKey: idempotencyKey,
+		milestone:      milestone,
+	}, nil, nil
+}
+
+// mergeCommitMessage is the message of the commit merging the full
+// repository branch into the head branch.
+func (f FullPullRequestCreator) mergeCommitMessage() string {
+	if f.Path != "" {
+		return fmt.Sprintf("Add %s of %s to %s for full review", f.Path, f.FullRepoBranch, f.HeadBranch)
+	}
+	return fmt.Sprintf("Merge %s into %s for full review", f.FullRepoBranch, f.HeadBranch)
+}
+
+// Create creates the branches and pull request for a full review of the
+// repository, returning the PR URL.
+//
+// Deprecated: Use CreateWithResult, which returns the created PullRequest.
+func (f FullPullRequestCreator) Create() (PRURL string, err error) {
+	PR, err := f.CreateWithResult()
+	if PR == nil {
 		return "", err
 	}
-	if !ok {
-		return "", fmt.Errorf("full repository branch %q does not exist in repository %q", f.FullRepoBranch, r)
+	return PR.HTMLURL, err
+}
+
+// CreateWithResult creates the branches and pull request for a full review
+// of the repository, returning the created pull request. If a step fails
+// after the pull request is created, such as labeling it, the pull request
+// is returned with the error, and is already recorded in the state store.
+func (f FullPullRequestCreator) CreateWithResult() (_ *PullRequest, err error) {
+	defer func() {
+		if err != nil && !errors.Is(err, ErrAlreadyCreated) && !errors.Is(err, ErrReviewExists) && !errors.Is(err, ErrRepoExcluded) && !errors.Is(err, ErrDryRun) {
+			f.notify(func(n Notifier) error { return n.RunFailed(f.Repo, err) })
+		}
+	}()
+	if f.DryRun {
+		plan, err := f.Plan()
+		if err != nil {
+			return nil, err
+		}
+		if f.output != nil {
+			plan.Write(f.output)
+		}
+		return nil, ErrDryRun
 	}
-	ok, err = r.BranchExists(f.BaseBranch)
+	p, existing, err := f.prepare(true)
 	if err != nil {
-		return "", err
+		return existing, err
 	}
-	if ok {
-		return "", fmt.Errorf("base branch %q already exists in repository %q", f.BaseBranch, r)
+	r, fullRepoSha, idempotencyKey := p.repo, p.fullRepoSha, p.idempotencyKey
+	f.progress("reviewing branch %s at commit %s", f.FullRepoBranch, fullRepoSha)
+	if len(f.splitPaths) == 0 {
+		files, err := f.reviewFiles(r, fullRepoSha)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > MaxPullRequestFiles {
+			if f.plannedFullRepoSha != "" {
+				return nil, fmt.Errorf("%w: repository %s has %d files", ErrSplitNotPlanned, r, len(files))
+			}
+			return f.createSplit(files)
+		}
 	}
-	ok, err = r.BranchExists(f.HeadBranch)
+	err = f.injectChaos("lock")
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if f.BreakLock {
+		holder, err := r.BreakLock()
+		if err != nil {
+			return nil, err
+		}
+		if holder != nil {
+			f.progress("broke the lock held by %s since %s", holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+	}
+	// Lock the repository before checking whether branches exist, so
+	// another prme process does not create them in the meantime.
+	unlock, err := r.Lock(fullRepoSha)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		unlockErr := unlock()
+		if unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+	f.progress("creating the base branch %s and head branch %s", f.BaseBranch, f.HeadBranch)
+	mergeSha, err := f.createBranches(r, fullRepoSha)
+	if errors.Is(err, ErrBranchProtected) && f.RetryProtectedBranches {
+		mergeSha, err = f.retryProtectedBranches(r, fullRepoSha, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = f.injectChaos("create-pull-request")
+	if err != nil {
+		return nil, err
+	}
+	f.progress("creating the pull request %q", f.Title)
+	createPullRequest := r.CreatePullRequestWithResult
+	if f.Draft {
+		createPullRequest = r.CreateDraftPullRequest
 	}
-	if ok {
-		return "", fmt.Errorf("head branch %q already exists in repository %q", f.HeadBranch, r)
+	PR, err := createPullRequest(f.Title, f.Body, f.BaseBranch, f.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	// The review is recorded before the pull request is decorated, so a
+	// failure below does not have a repeated run create another review.
+	err = f.injectChaos("record-state")
+	if err != nil {
+		return PR, err
+	}
+	if f.StateFile != "" {
+		store, err := NewStateStore(f.StateFile)
+		if err != nil {
+			return PR, err
+		}
+		err = store.AddReview(ReviewRecord{
+			Repo:            r.String(),
+			Number:          PR.Number,
+			URL:             PR.HTMLURL,
+			State:           PR.State,
+			FullRepoBranch:  f.FullRepoBranch,
+			BaseBranch:      f.BaseBranch,
+			HeadBranch:      f.HeadBranch,
+			CreatedAt:       f.now(),
+			IdempotencyKey:  idempotencyKey,
+			RemindAfterDays: f.RemindAfterDays,
+			Filtered:        f.filtersContent(),
+		})
+		if err != nil {
+			return PR, fmt.Errorf("while recording pull request %s in the state store: %w", PR.HTMLURL, err)
+		}
+	}
+	f.notify(func(n Notifier) error { return n.PRCreated(r.String(), PR) })
+	if f.TableOfContents {
+		err = f.addTableOfContents(r, PR)
+		if err != nil {
+			return PR, fmt.Errorf("while adding a table of contents to pull request %s: %w", PR.HTMLURL, err)
+		}
+		f.progress("added a table of contents to the pull request body")
+	}
+	err = f.injectChaos("label")
+	if err != nil {
+		return PR, err
+	}
+	for _, label := range f.Labels {
+		err = r.EnsureLabel(label, defaultLabelColor)
+		if err != nil {
+			return PR, fmt.Errorf("while labeling pull request %s: %w", PR.HTMLURL, err)
+		}
+	}
+	if len(f.Labels) > 0 {
+		err = r.AddLabels(PR.Number, f.Labels)
+		if err != nil {
+			return PR, fmt.Errorf("while labeling pull request %s: %w", PR.HTMLURL, err)
+		}
+		f.progress("added the labels %s", strings.Join(f.Labels, ", "))
+	}
+	err = f.injectChaos("request-reviewers")
+	if err != nil {
+		return PR, err
+	}
+	if len(f.Reviewers) > 0 {
+		err = r.RequestReviewers(PR.Number, f.Reviewers)
+		if err != nil {
+			return PR, fmt.Errorf("while requesting reviewers for pull request %s: %w", PR.HTMLURL, err)
+		}
+		f.progress("requested reviews from %s", strings.Join(f.Reviewers, ", "))
+	}
+	if len(f.Assignees) > 0 {
+		unassigned, err := r.AddAssignees(PR.Number, f.Assignees)
+		if err != nil {
+			return PR, fmt.Errorf("while assigning pull request %s: %w", PR.HTMLURL, err)
+		}
+		if len(unassigned) > 0 {
+			f.warn("these users could not be assigned to pull request %s, and may not have access to the repository: %s", PR.HTMLURL, strings.Join(unassigned, ", "))
+		}
+		f.progress("assigned %s", strings.Join(f.Assignees, ", "))
+	}
+	if p.milestone != nil {
+		err = r.SetMilestone(PR.Number, p.milestone.Number)
+		if err != nil {
+			return PR, fmt.Errorf("while adding pull request %s to milestone %q: %w", PR.HTMLURL, p.milestone.Title, err)
+		}
+		f.progress("added the pull request to milestone %s", p.milestone.Title)
 	}
+	err = f.commentHowTo(r, PR)
+	if err != nil {
+		return PR, fmt.Errorf("while commenting how to contribute review fixes on pull request %s: %w", PR.HTMLURL, err)
+	}
+	err = f.injectChaos("commit-status")
+	if err != nil {
+		return PR, err
+	}
+	if f.SetCommitStatus && mergeSha != "" {
+		err = r.CreateCommitStatus(mergeSha, pendingReviewStatus(PR.HTMLURL))
+		if err != nil {
+			return PR, fmt.Errorf("while setting the commit status for pull request %s: %w", PR.HTMLURL, err)
+		}
+		f.progress("set a pending %s commit status on the head branch", FullReviewStatusContext)
+	}
+	err = f.injectChaos("commit-comment")
+	if err != nil {
+		return PR, err
+	}
+	if f.CommentOnFullRepoBranch {
+		err = r.CreateCommitComment(fullRepoSha, reviewInProgressComment(PR.HTMLURL))
+		if err != nil {
+			return PR, fmt.Errorf("while commenting on the %s branch for pull request %s: %w", f.FullRepoBranch, PR.HTMLURL, err)
+		}
+		f.progress("commented on the tip commit of %s", f.FullRepoBranch)
+	}
+	if f.RemoveTopic || f.AddTopic != "" {
+		err = f.updateTopics(r)
+		if err != nil {
+			return PR, fmt.Errorf("while updating the topics of repository %q for pull request %s: %w", r, PR.HTMLURL, err)
+		}
+		f.progress("updated the topics of the repository")
+	}
+	return PR, nil
+}
 
-	err = r.CreateOrphanBranches(f.BaseBranch, f.HeadBranch)
+// createBranches creates the base and head branches, and merges the full
+// repository branch, at fullRepoSha, into the head branch, returning the
+// merge commit. The merge commit is empty if the full repository branch was
+// already merged. If f.filtersContent, the filtered content is added to the
+// head branch instead.
+func (f FullPullRequestCreator) createBranches(r *Repo, fullRepoSha string) (mergeSha string, err error) {
+	branches := []struct{ kind, name string }{{"base", f.BaseBranch}, {"head", f.HeadBranch}}
+	exists := make([]bool, len(branches))
+	checks := make([]func() error, len(branches))
+	for i, branch := range branches {
+		i, branch := i, branch
+		checks[i] = func() (err error) {
+			exists[i], err = r.BranchExists(branch.name)
+			if err != nil {
+				return err
+			}
+			if exists[i] && !f.ForceDelete {
+				return fmt.Errorf("%s branch %q already exists in repository %q, use the force-delete option to delete and recreate it", branch.kind, branch.name, r)
+			}
+			return nil
+		}
+	}
+	if !f.SkipPreflight || f.ForceDelete {
+		err = runChecks(checks...)
+		if err != nil {
+			return "", err
+		}
+	}
+	if exists[0] || exists[1] {
+		err = r.DeleteReviewBranches(f.BaseBranch, f.HeadBranch)
+		if err != nil {
+			return "", fmt.Errorf("refusing to force-delete existing branches: %w", err)
+		}
+	}
+
+	err = f.injectChaos("create-branches")
 	if err != nil {
 		return "", err
 	}
-	err = r.MergeBranch(f.HeadBranch, f.FullRepoBranch)
+	err = r.CreateOrphanBranches(f.BaseBranch, f.HeadBranch)
 	if err != nil {
 		return "", err
 	}
-	PRURL, err := r.CreatePullRequest(f.Title, f.Body, f.BaseBranch, f.HeadBranch)
+	err = f.injectChaos("merge")
 	if err != nil {
 		return "", err
 	}
-	return PRURL, nil
+	if f.filtersContent() {
+		return f.commitFilteredContent(r, fullRepoSha)
+	}
+	mergeSha, err = r.MergeBranch(f.HeadBranch, f.FullRepoBranch, f.mergeCommitMessage())
+	if err != nil && !errors.Is(err, ErrAlreadyMerged) {
+		return "", err
+	}
+	return mergeSha, nil
+}
+
+// flagEnvVarName returns the name of the environment variable that sets the
+// command-line flag flagName, such as PRME_FBRANCH for fbranch.
+func flagEnvVarName(flagName string) string {
+	return "PRME_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
 }
 
 func flagOrEnvValue(f *flag.Flag) {
-	envVarName := "PRME_" + strings.ToUpper(f.Name)
-	envVarValue := os.Getenv(envVarName)
+	envVarValue := os.Getenv(flagEnvVarName(f.Name))
 	if envVarValue != "" && f.Value.String() == f.DefValue {
 		_ = f.Value.Set(envVarValue)
 	}
 }
 
-func NewFullPullRequestCreatorFromArgs(args []string, output, errOutput io.Writer) (*FullPullRequestCreator, error) {
+// printEnvVarUsage displays the environment variables that set the
+// command-line flags of fs, and their current values.
+func printEnvVarUsage(fs *flag.FlagSet, errOutput io.Writer) {
+	fmt.Fprintf(errOutput, `
+The following environment variables override defaults. Command-line flags will override everything.
+
+		<Environment Variable>	<Current Value>
+`)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "version" || hiddenFlags[f.Name] {
+			return
+		}
+		envVarName := flagEnvVarName(f.Name)
+		value := os.Getenv(envVarName)
+		if value != "" && (strings.Contains(f.Name, "secret") || strings.HasSuffix(f.Name, "webhook-url")) {
+			value = redactedText
+		}
+		// Values such as the SOCKS proxy URL can include credentials.
+		fmt.Fprintf(errOutput, "%s\t%q\n", envVarName, Redact(value))
+	})
+}
+
+// addCreatorFlags adds command-line flags that set the properties of a
+// FullPullRequestCreator to fs. After fs has been parsed, the returned
+// function applies the flags to a FullPullRequestCreator. Notifications
+// requested by -notify-stdout are written to output.
+func addCreatorFlags(fs *flag.FlagSet, output io.Writer) (apply func(*FullPullRequestCreator) error, err error) {
+	defaultValues, err := NewFullPullRequestCreator("dummyRepo")
+	if err != nil {
+		return nil, fmt.Errorf("while getting default values: %w", err)
+	}
+
+	CLIFullRepoBranch := fs.String("fbranch", defaultValues.FullRepoBranch, "The name of the existing branch, such as main or master, containing all repository content. A comma-separated list, such as main,master,trunk, uses the first branch that exists. This is also set via the PRME_FBRANCH environment variable.")
+	CLITitle := fs.String("title", defaultValues.Title, "The title of the pull request. This is also set via the PRME_TITLE environment variable.")
+	CLIBody := fs.String("body", defaultValues.Body, "The body; first comment of the pull request. This is also set via the PRME_TITLE environment variable.")
+	CLIBaseBranch := fs.String("bbranch", defaultValues.BaseBranch, "The name of the base orphan branch to create for the pull request.This is also set via the PRME_BBRANCH environment variable.")
+	CLIHeadBranch := fs.String("hbranch", defaultValues.HeadBranch, "The name of the head review branch to create for the pull request, where review fixes should be pushed. This is also set via the PRME_HBRANCH environment variable.")
+	CLIBranchPrefix := fs.String("branch-prefix", defaultValues.BranchPrefix, "The prefix of branch names created by prme, which also changes the default base and head branch names. This is also set via the PRME_BRANCH_PREFIX environment variable.")
+	CLISetCommitStatus := fs.Bool("status", defaultValues.SetCommitStatus, "Mark the head branch with a pending full-review commit status, linking to the pull request. This is also set via the PRME_STATUS environment variable.")
+	CLICommitComment := fs.Bool("commit-comment", false, "Comment on the tip commit of the full repository branch, linking to the pull request, so people browsing the repository discover the review. This is also set via the PRME_COMMIT_COMMENT environment variable.")
+	CLIRemindAfterDays := fs.Int("remind-after-days", 0, "Have prme serve remind requested reviewers when the pull request has had no activity for this many days. Zero disables reminders. This is also set via the PRME_REMIND_AFTER_DAYS environment variable.")
+	CLINotifySlackWebhookURL := fs.String("notify-slack-webhook-url", "", "A Slack incoming webhook URL, to which created and merged reviews, and failures, are posted. This is also set via the PRME_NOTIFY_SLACK_WEBHOOK_URL environment variable.")
+	CLINotifyWebhookURL := fs.String("notify-webhook-url", "", "A URL to which created and merged reviews, and failures, are posted as JSON. This is also set via the PRME_NOTIFY_WEBHOOK_URL environment variable.")
+	CLINotifyStdout := fs.Bool("notify-stdout", false, "Display created and merged reviews, and failures, as lines of JSON on standard output. This is also set via the PRME_NOTIFY_STDOUT environment variable.")
+	CLIClient := addClientFlags(fs)
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which to record created pull requests, used by other prme subcommands. This is also set via the PRME_STATE_FILE environment variable.")
+	var CLIExcludeRepos stringsFlag
+	fs.Var(&CLIExcludeRepos, "exclude-repo", "A shell pattern, such as myorg/infra-*, of repositories for which pull requests will never be created. This can be specified multiple times, or as a comma-separated list. This is also set via the PRME_EXCLUDE_REPO environment variable.")
+	CLILang := fs.String("lang", "", fmt.Sprintf("The language of the default pull request title and body, one of: %s. This is also set via the PRME_LANG environment variable.", strings.Join(Languages(), ", ")))
+	CLITemplateDir := fs.String("template-dir", "", "A directory of <language>.yaml files containing title, body, and howto keys, which replace the bundled title, body, and -howto comment for -lang. This is also set via the PRME_TEMPLATE_DIR environment variable.")
+	CLIForceDelete := fs.Bool("force-delete", false, "Delete and recreate the base and head branches if they already exist. Only branches created by prme, whose history begins with an empty commit, are deleted. This is also set via the PRME_FORCE_DELETE environment variable.")
+	CLIBreakLock := fs.Bool("break-lock", false, "Remove the lock of each repository before creating its pull request, such as a lock left by a prme process that crashed. Only use this when no other prme process is running for the repository. This is also set via the PRME_BREAK_LOCK environment variable.")
+	CLITopic := fs.String("topic", "", "Only create pull requests for repositories having this topic, such as needs-audit, which is useful with -org or repository patterns. This is also set via the PRME_TOPIC environment variable.")
+	CLIRemoveTopic := fs.Bool("remove-topic", false, "Remove the -topic from each repository once its pull request is created, to mark progress. This is also set via the PRME_REMOVE_TOPIC environment variable.")
+	CLIAddTopic := fs.String("add-topic", "", "A topic to add to each repository once its pull request is created, such as audit-in-progress, to mark progress. This is also set via the PRME_ADD_TOPIC environment variable.")
+	CLIPath := fs.String("path", "", "A directory of the repository, such as services/api in a monorepo, to review instead of the entire repository. The head branch only contains that directory. This is also set via the PRME_PATH environment variable.")
+	CLIMaxFileSize := fs.Int64("max-file-size", 0, "Omit files larger than this many bytes from the pull request, listing them in the "+OmittedFilesManifest+" file instead, so Github can display the diff. Zero includes all files. This is also set via the PRME_MAX_FILE_SIZE environment variable.")
+	CLISymlinks := fs.String("symlinks", LinkKeep, "How symlinks are included in the pull request: keep them as the path of their target, skip them, or materialize them as the file or directory they target. This is also set via the PRME_SYMLINKS environment variable.")
+	CLISubmodules := fs.String("submodules", LinkKeep, "How submodules are included in the pull request: keep them as the commit they point to, skip them, or materialize them as a file describing that commit. This is also set via the PRME_SUBMODULES environment variable.")
+	CLINormalizeText := fs.Bool("normalize-text", false, "Normalize text files in the pull request, converting CRLF line endings to LF, removing UTF-8 byte order marks, and converting UTF-16 to UTF-8, so the diff is not dominated by those differences. The full repository branch is not changed. Each file up to 1MiB is downloaded, which uses a Github API request. This is also set via the PRME_NORMALIZE_TEXT environment variable.")
+	CLITableOfContents := fs.Bool("toc", false, "Add a table of contents of the files to the pull request body, with a collapsible block for each directory linking to the diff of its files. When there are too many files for the body, only directories are listed. This is also set via the PRME_TOC environment variable.")
+	CLIDraft := fs.Bool("draft", false, "Create the pull request as a draft, which does not request review from code owners or satisfy required-review automation until it is marked ready for review. This is also set via the PRME_DRAFT environment variable.")
+	CLIHowTo := fs.Bool("howto", false, "Comment on the pull request once it is created, explaining to reviewers which branch to push review fixes to and what happens when the review is finalized, in the language of -lang. This is also set via the PRME_HOWTO environment variable.")
+	var CLIReviewers stringsFlag
+	fs.Var(&CLIReviewers, "reviewers", "Github logins, or teams specified as organization/team, to request reviews of the pull request from once it is created. This can be specified multiple times, or as a comma-separated list. This is also set via the PRME_REVIEWERS environment variable.")
+	var CLIAssignees stringsFlag
+	fs.Var(&CLIAssignees, "assignees", "Github logins to assign to the pull request once it is created, such as the owner of the review. This can be specified multiple times, or as a comma-separated list. This is also set via the PRME_ASSIGNEES environment variable.")
+	CLIMilestone := fs.String("milestone", "", "The title, or otherwise number, of an existing milestone to add the pull request to, so the review can be tracked in release planning. This is also set via the PRME_MILESTONE environment variable.")
+	CLICheckReviewerAccess := fs.Bool("check-reviewer-access", false, "Warn about requested reviewers who do not have push access to the repository, which is needed to push review fixes to the head branch, before the pull request is created. This is also set via the PRME_CHECK_REVIEWER_ACCESS environment variable.")
+	CLIRetryProtected := fs.Bool("retry-protected", false, "If a branch protection rule or ruleset rejects updating the base or head branch after they were created, such as one applied by automation of the organization, delete them and retry with branch names ending in the abbreviated commit of the full repository branch. This is also set via the PRME_RETRY_PROTECTED environment variable.")
+	CLISkipOrgConfig := fs.Bool("skip-org-config", false, fmt.Sprintf("Ignore defaults shared by the owner of each repository, in the %s file of its %s repository. This is also set via the PRME_SKIP_ORG_CONFIG environment variable.", OrgConfigPath, OrgConfigRepo))
+	CLISkipPreflight := fs.Bool("skip-preflight", false, "Do not verify that the base and head branches of each repository, and an open pull request between them, do not exist, saving 3 API requests per repository when they were already verified, such as for a batch of repositories just listed. Existing branches then fail the push of the new ones. With -force-delete, which verifies the branches and does not look for an open pull request, nothing is skipped. Each repository is still verified to exist, which finds its current name if it was renamed. This is also set via the PRME_SKIP_PREFLIGHT environment variable.")
+	var CLIChaos stringsFlag
+	// The chaos flag is hidden from help, see hiddenFlags.
+	fs.Var(&CLIChaos, "chaos", fmt.Sprintf("Fail immediately before each of these steps of creating a review, for testing how automation handles failures. This can be specified multiple times, or as a comma-separated list, of: %s. This is also set via the PRME_CHAOS environment variable.", strings.Join(chaosSteps, ", ")))
+	CLIAllowlistFile := fs.String("allowlist-file", "", "A file listing the only repositories, or shell patterns of repositories, for which pull requests can be created, one per line. This is also set via the PRME_ALLOWLIST_FILE environment variable.")
+
+	return func(f *FullPullRequestCreator) error {
+		f.ExcludeRepos = CLIExcludeRepos
+		if *CLIAllowlistFile != "" {
+			allowRepos, err := readAllowlistFile(*CLIAllowlistFile)
+			if err != nil {
+				return err
+			}
+			f.AllowRepos = allowRepos
+		}
+		f.FullRepoBranch = *CLIFullRepoBranch
+		f.Title = *CLITitle
+		f.Body = *CLIBody
+		if *CLILang != "" {
+			err := f.localize(*CLILang, *CLITemplateDir)
+			if err != nil {
+				return err
+			}
+		}
+		f.BaseBranch = *CLIBaseBranch
+		f.HeadBranch = *CLIHeadBranch
+		if *CLIBranchPrefix == "" {
+			return errors.New("the branch prefix cannot be empty")
+		}
+		f.setBranchPrefix(*CLIBranchPrefix)
+		f.SetCommitStatus = *CLISetCommitStatus
+		f.CommentOnFullRepoBranch = *CLICommitComment
+		if *CLIRemindAfterDays < 0 {
+			return errors.New("the number of days after which to remind reviewers cannot be negative")
+		}
+		f.RemindAfterDays = *CLIRemindAfterDays
+		if *CLINotifySlackWebhookURL != "" {
+			f.notifiers = append(f.notifiers, SlackNotifier{WebhookURL: *CLINotifySlackWebhookURL})
+		}
+		if *CLINotifyWebhookURL != "" {
+			f.notifiers = append(f.notifiers, WebhookNotifier{URL: *CLINotifyWebhookURL})
+		}
+		if *CLINotifyStdout {
+			f.notifiers = append(f.notifiers, &WriterNotifier{W: output})
+		}
+		f.StateFile = *CLIStateFile
+		f.SkipOrgConfig = *CLISkipOrgConfig
+		f.SkipPreflight = *CLISkipPreflight
+		f.ForceDelete = *CLIForceDelete
+		f.BreakLock = *CLIBreakLock
+		f.RetryProtectedBranches = *CLIRetryProtected
+		if *CLISymlinks != LinkKeep {
+			err := WithSymlinks(*CLISymlinks)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if *CLISubmodules != LinkKeep {
+			err := WithSubmodules(*CLISubmodules)(f)
+			if err != nil {
+				return err
+			}
+		}
+		f.NormalizeText = *CLINormalizeText
+		f.TableOfContents = *CLITableOfContents
+		f.Draft = *CLIDraft
+		if len(CLIReviewers) > 0 {
+			err := WithReviewers(CLIReviewers...)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if len(CLIAssignees) > 0 {
+			err := WithAssignees(CLIAssignees...)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if *CLIMilestone != "" {
+			err := WithMilestone(*CLIMilestone)(f)
+			if err != nil {
+				return err
+			}
+		}
+		f.CheckReviewerPushAccess = *CLICheckReviewerAccess
+		f.HowTo = *CLIHowTo
+		if *CLIMaxFileSize != 0 {
+			err := WithMaxFileSize(*CLIMaxFileSize)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if *CLIPath != "" {
+			err := WithPath(*CLIPath)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if *CLITopic != "" {
+			err := WithTopic(*CLITopic)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if *CLIRemoveTopic {
+			if *CLITopic == "" {
+				return errors.New("the -remove-topic flag requires the -topic flag")
+			}
+			f.RemoveTopic = true
+		}
+		if *CLIAddTopic != "" {
+			err := WithAddTopic(*CLIAddTopic)(f)
+			if err != nil {
+				return err
+			}
+		}
+		if len(CLIChaos) > 0 {
+			err := WithChaos(CLIChaos...)(f)
+			if err != nil {
+				return err
+			}
+		}
+		token, clientOptions, err := CLIClient.apply(f.token)
+		if err != nil {
+			return err
+		}
+		f.token = token
+		f.clientOptions = append(f.clientOptions, clientOptions...)
+		return nil
+	}, nil
+}
+
+// NewFullPullRequestCreatorFromArgs returns a FullPullRequestCreator
+// configured by the command-line arguments args and PRME_* environment
+// variables. The progress of creating reviews is displayed to output, and
+// warnings to errOutput, as by WithOutput and WithErrOutput. The options are
+// applied afterward, so programs can combine that configuration with options
+// that have no flag, such as WithAPIMiddleware, or override it.
+func NewFullPullRequestCreatorFromArgs(args []string, output, errOutput io.Writer, options ...FullPullRequestCreatorOption) (*FullPullRequestCreator, error) {
 	fs := flag.NewFlagSet("prme", flag.ExitOnError)
 	fs.SetOutput(errOutput)
 	fs.Usage = func() {
@@ -453,41 +2618,37 @@ func NewFullPullRequestCreatorFromArgs(args []string, output, errOutput io.Write
 
 The GH_TOKEN environment variable must be set to a Github personal access token. To create a token, see https://github.com/settings/tokens
 
-Usage: %s [flags] <repository>
-The <repository> should be of the form OwnerName/RepositoryName
+Usage: %s [flags] <repository> [<repository>...]
+       %s [flags] -org <organization>
+       %s list|serve [flags]
+The <repository> should be of the form OwnerName/RepositoryName, or RepositoryName when -owner is set. A repository can also be a shell pattern, such as 'myorg/service-*', matching repositories of the owner. Specifying multiple repositories creates a pull request for each, pausing if Github is in maintenance or rate limits requests.
 
 For example:
 export GH_TOKEN='ghp_.....'
 %s ivanfetch/pr-me
 
-Available command-line flags:
-`,
-			fs.Name(), fs.Name())
-		fs.PrintDefaults()
-		fmt.Fprintf(errOutput, `
-The following environment variables override defaults. Command-line flags will override everything.
+Run %s <subcommand> -h for help with a subcommand.
 
-		<Environment Variable>	<Current Value>
-PRME_FBRANCH	%q
-PRME_TITLE	%q
-PRME_BODY	%q
-PRME_BBRANCH	%q
-PRME_HBRANCH	%q
+Available command-line flags:
 `,
-			os.Getenv("PRME_FBRANCH"), os.Getenv("PRME_TITLE"), os.Getenv("PRME_BODY"), os.Getenv("PRME_BBRANCH"), os.Getenv("PRME_HBRANCH"))
+			fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name())
+		printFlagDefaults(fs, errOutput)
+		printEnvVarUsage(fs, errOutput)
 	}
 
-	defaultValues, err := NewFullPullRequestCreator("dummyRepo")
+	CLIVersion := fs.Bool("version", false, "Display the version and git commit.")
+	CLIOutput := fs.String("output", outputFormatText, fmt.Sprintf("The format of standard output, either %s, or %s which displays PR_URL, PR_NUMBER, BASE_BRANCH, and HEAD_BRANCH shell variables that can be evaluated, such as: eval \"$(prme owner/repo)\". Other messages are displayed to standard error. This is also set via the PRME_OUTPUT environment variable.", outputFormatText, outputFormatEnv))
+	CLIDryRun := fs.Bool("dry-run", false, "Perform all read-only verifications, then display the branches, merge, and pull request that would be created, and the API calls and git commands that would run, without cloning, pushing, or changing anything. This is also set via the PRME_DRY_RUN environment variable.")
+	CLIReportFile := fs.String("report-file", "", "A file to which a JSON summary of the run is written at exit, including the outcome and duration for each repository, and the number of Github API calls. This is also set via the PRME_REPORT_FILE environment variable.")
+	CLIProfile := fs.String("profile", "", "A file to which a CPU profile of the run is written, for analysis using go tool pprof. This is also set via the PRME_PROFILE environment variable.")
+	CLIOrg := fs.String("org", "", "An organization or user, for whose repositories pull requests will be created, instead of specifying repositories. Archived repositories are skipped. This is also set via the PRME_ORG environment variable.")
+	CLIOwner := addOwnerFlag(fs)
+	var CLIWorkspaceBranches stringsFlag
+	fs.Var(&CLIWorkspaceBranches, "workspace-branches", fmt.Sprintf("Full repository branches, such as main,release/2.x, each of which is reviewed by a separate pull request, instead of -fbranch. The branch replaces %s in -bbranch and -hbranch, otherwise it is appended to their names. This can be specified multiple times, or as a comma-separated list. This is also set via the PRME_WORKSPACE_BRANCHES environment variable.", WorkspaceBranchPlaceholder))
+	applyCreatorFlags, err := addCreatorFlags(fs, output)
 	if err != nil {
-		return nil, fmt.Errorf("while getting default values: %w", err)
+		return nil, err
 	}
-
-	CLIVersion := fs.Bool("version", false, "Display the version and git commit.")
-	CLIFullRepoBranch := fs.String("fbranch", defaultValues.FullRepoBranch, "The name of the existing branch, such as main or master, containing all repository content. This is also set via the PRME_FBRANCH environment variable.")
-	CLITitle := fs.String("title", defaultValues.Title, "The title of the pull request. This is also set via the PRME_TITLE environment variable.")
-	CLIBody := fs.String("body", defaultValues.Body, "The body; first comment of the pull request. This is also set via the PRME_TITLE environment variable.")
-	CLIBaseBranch := fs.String("bbranch", defaultValues.BaseBranch, "The name of the base orphan branch to create for the pull request.This is also set via the PRME_BBRANCH environment variable.")
-	CLIHeadBranch := fs.String("hbranch", defaultValues.HeadBranch, "The name of the head review branch to create for the pull request, where review fixes should be pushed. This is also set via the PRME_HBRANCH environment variable.")
 	err = fs.Parse(args)
 	if err != nil {
 		return nil, err
@@ -496,7 +2657,7 @@ PRME_HBRANCH	%q
 	if *CLIVersion {
 		return nil, fmt.Errorf("%s version %s, git commit %s\n", fs.Name(), Version, GitCommit)
 	}
-	if fs.NArg() == 0 {
+	if fs.NArg() == 0 && *CLIOrg == "" {
 		return nil, fmt.Errorf(
 			`Set the GH_TOKEN environment variable to a Github personal access token, then run this program with a repository name for which you would like a pull request that reviews all files.
 For example: %s IvanFetch/myproject
@@ -504,55 +2665,306 @@ For example: %s IvanFetch/myproject
 Run %s -h for additional help.`,
 			fs.Name(), fs.Name())
 	}
-	if fs.NArg() > 1 {
-		return nil, fmt.Errorf("Please only specify one repository name, and make sure any command-line flags come first. RUn %s -h for additional help.", fs.Name())
+	var repoNames []string
+	for _, arg := range fs.Args() {
+		if strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("Please make sure any command-line flags come before repository names. Run %s -h for additional help.", fs.Name())
+		}
+		repoNames = append(repoNames, qualifyRepoName(arg, *CLIOwner))
+	}
+	if *CLIOrg != "" {
+		if len(repoNames) > 0 {
+			return nil, fmt.Errorf("Please specify either repositories or the -org flag, not both. Run %s -h for additional help.", fs.Name())
+		}
+		// The repository is replaced for each repository of the organization.
+		repoNames = []string{*CLIOrg + "/*"}
+	}
+	f, err := NewFullPullRequestCreator(repoNames[0])
+	if err != nil {
+		return nil, err
+	}
+	// A repository pattern is expanded into a batch by runCreator.
+	if len(repoNames) > 1 || (*CLIOrg == "" && isRepoPattern(repoNames[0])) {
+		f.batchRepos = repoNames
+	}
+	f.batchOwner = *CLIOrg
+	f.reportFile = *CLIReportFile
+	f.profileFile = *CLIProfile
+	f.DryRun = *CLIDryRun
+	f.workspaceBranches = CLIWorkspaceBranches
+	switch *CLIOutput {
+	case outputFormatText:
+	case outputFormatEnv:
+		if len(f.batchRepos) > 0 || f.batchOwner != "" || len(f.workspaceBranches) > 0 {
+			return nil, fmt.Errorf("the %s output format is only supported for a single repository, without -workspace-branches", outputFormatEnv)
+		}
+	default:
+		return nil, fmt.Errorf("the output format must be %s or %s, not %q", outputFormatText, outputFormatEnv, *CLIOutput)
+	}
+	if *CLIOutput != outputFormatText {
+		f.outputFormat = *CLIOutput
 	}
-	repoName := strings.TrimPrefix(fs.Args()[0], "github.com/")
-	f, err := NewFullPullRequestCreator(repoName)
+	// Standard output only contains shell variables in the env format.
+	f.output = output
+	if f.outputFormat == outputFormatEnv {
+		f.output = errOutput
+	}
+	f.errOutput = errOutput
+	f.token = os.Getenv("GH_TOKEN")
+	err = applyCreatorFlags(f)
 	if err != nil {
 		return nil, err
 	}
-	f.Token = os.Getenv("GH_TOKEN")
-	if f.Token == "" {
+	for _, option := range options {
+		err = option(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The token can also be set by a host profile, or WithToken.
+	if f.token == "" {
 		return nil, errors.New("Please set the GH_TOKEN environment variable to a Github personal access token. Tokens can be managed at https://github.com/settings/tokens")
 	}
-	f.FullRepoBranch = *CLIFullRepoBranch
-	f.Title = *CLITitle
-	f.Body = *CLIBody
-	f.BaseBranch = *CLIBaseBranch
-	f.HeadBranch = *CLIHeadBranch
 	return f, nil
 }
 
-func CreateFullPullRequest(repo string, options ...fullPullRequestCreatorOption) (string, error) {
+// CreateFullPullRequest creates the full review of repo, as configured by
+// NewFullPullRequestCreator with options, returning the PR URL.
+//
+// Deprecated: Use CreateFullPullRequestWithResult, which returns the
+// created PullRequest.
+func CreateFullPullRequest(repo string, options ...FullPullRequestCreatorOption) (PRURL string, err error) {
+	PR, err := CreateFullPullRequestWithResult(repo, options...)
+	if PR == nil {
+		return "", err
+	}
+	return PR.HTMLURL, err
+}
+
+// CreateFullPullRequestWithResult creates the full review of repo, as
+// configured by NewFullPullRequestCreator with options, returning the
+// created pull request.
+func CreateFullPullRequestWithResult(repo string, options ...FullPullRequestCreatorOption) (*PullRequest, error) {
 	f, err := NewFullPullRequestCreator(repo, options...)
 	if err != nil {
+		return nil, err
+	}
+	return f.CreateWithResult()
+}
+
+// CreateFullPullRequestFromArgs creates the full review of the one
+// repository specified by args, as configured by
+// NewFullPullRequestCreatorFromArgs with options, returning the PR URL.
+//
+// Deprecated: Use CreateFullPullRequestFromArgsWithResult, which returns
+// the created PullRequest.
+func CreateFullPullRequestFromArgs(args []string, output, errOutput io.Writer, options ...FullPullRequestCreatorOption) (PRURL string, err error) {
+	PR, err := CreateFullPullRequestFromArgsWithResult(args, output, errOutput, options...)
+	if PR == nil {
 		return "", err
 	}
-	PRURL, err := f.Create()
+	return PR.HTMLURL, err
+}
+
+// CreateFullPullRequestFromArgsWithResult creates the full review of the
+// one repository specified by args, as configured by
+// NewFullPullRequestCreatorFromArgs with options, returning the created
+// pull request.
+func CreateFullPullRequestFromArgsWithResult(args []string, output, errOutput io.Writer, options ...FullPullRequestCreatorOption) (*PullRequest, error) {
+	FPR, err := NewFullPullRequestCreatorFromArgs(args, output, errOutput, options...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return PRURL, nil
+	if len(FPR.batchRepos) > 0 || FPR.batchOwner != "" {
+		return nil, errors.New("please only specify one repository name")
+	}
+	return FPR.CreateWithResult()
+}
+
+// subcommands are run by RunCLI when their name is the first command-line
+// argument. Each parses its own command-line flags.
+var subcommands = map[string]func(args []string, output, errOutput io.Writer) error{
+	"apply":    runApplyCommand,
+	"azure":    runAzureCommand,
+	"cleanup":  runCleanupCommand,
+	"export":   runExportCommand,
+	"finalize": runFinalizeCommand,
+	"gc":       runGCCommand,
+	"list":     runListCommand,
+	"plan":     runPlanCommand,
+	"refresh":  runRefreshCommand,
+	"report":   runReportCommand,
+	"serve":    runServeCommand,
+	"stats":    runStatsCommand,
 }
 
-func CreateFullPullRequestFromArgs(args []string, output, errOutput io.Writer) (string, error) {
+// RunCLI runs the prme command with args, which exclude the program name,
+// writing to output and errOutput, which are usually standard output and
+// standard error. The exit code of the command is returned, such as for
+// main to pass to os.Exit.
+func RunCLI(args []string, output, errOutput io.Writer) int {
+	// All output is redacted, so the token is not leaked into logs, such
+	// as by errors or git command output.
+	output = NewRedactingWriter(output, os.Getenv("GH_TOKEN"))
+	errOutput = NewRedactingWriter(errOutput, os.Getenv("GH_TOKEN"))
+	if len(args) > 0 {
+		if subcommand, ok := subcommands[args[0]]; ok {
+			err := subcommand(args[1:], output, errOutput)
+			if err != nil {
+				fmt.Fprintf(errOutput, "%v\n", err)
+				return 1
+			}
+			return 0
+		}
+	}
 	FPR, err := NewFullPullRequestCreatorFromArgs(args, output, errOutput)
 	if err != nil {
-		return "", err
+		fmt.Fprintf(errOutput, "%v\n", err)
+		return 1
+	}
+	messages := FPR.output
+	if FPR.profileFile != "" {
+		stopProfile, err := startCPUProfile(FPR.profileFile)
+		if err != nil {
+			fmt.Fprintf(errOutput, "%v\n", err)
+			return 1
+		}
+		defer func() {
+			err := stopProfile()
+			if err != nil {
+				fmt.Fprintf(errOutput, "while writing the profile file: %v\n", err)
+			}
+		}()
+	}
+	// Only prompt when creating a single pull request interactively.
+	if len(FPR.batchRepos) == 0 && FPR.batchOwner == "" && len(FPR.workspaceBranches) == 0 && isTerminal(os.Stdin) {
+		FPR.BranchPicker = promptBranchPicker(os.Stdin, messages)
+	}
+	startedAt := time.Now()
+	APICalls := &apiCallCounter{}
+	FPR.clientOptions = append(FPR.clientOptions, withAPICallCounter(APICalls))
+	results, err := runCreator(*FPR, messages, errOutput)
+	if FPR.reportFile != "" {
+		report := NewRunReport(results, startedAt, time.Now(), APICalls.Count())
+		if err != nil {
+			report.Error = Redact(err.Error(), FPR.token)
+		}
+		for i := range report.Repos {
+			report.Repos[i].Error = Redact(report.Repos[i].Error, FPR.token)
+		}
+		reportErr := WriteRunReport(FPR.reportFile, report)
+		if reportErr != nil {
+			fmt.Fprintf(errOutput, "while writing the report file: %v\n", reportErr)
+			return 1
+		}
 	}
-	PRURL, err := FPR.Create()
 	if err != nil {
-		return "", err
+		fmt.Fprintf(errOutput, "%v\n", err)
+		return 1
+	}
+	if FPR.outputFormat == outputFormatEnv && len(results) == 1 && results[0].PullRequest != nil {
+		writeEnvOutput(output, *FPR, results[0].PullRequest)
+	}
+	batch := FPR.batchOwner != "" || len(FPR.batchRepos) > 0 || len(FPR.workspaceBranches) > 1
+	if !batch && len(results) == 1 && errors.Is(results[0].Err, ErrReviewExists) {
+		if FPR.outputFormat != outputFormatEnv {
+			fmt.Fprintln(output, results[0].PullRequest.HTMLURL)
+		}
+		return ExitCodeReviewExists
+	}
+	for _, result := range results {
+		// Excluded repositories are only a failure when specified alone.
+		if result.Err != nil && !errors.Is(result.Err, ErrAlreadyCreated) && !errors.Is(result.Err, ErrReviewExists) && !errors.Is(result.Err, ErrDryRun) && !(batch && errors.Is(result.Err, ErrRepoExcluded)) {
+			return 1
+		}
 	}
-	return PRURL, nil
+	return 0
 }
 
-func RunCLI() {
-	PRURL, err := CreateFullPullRequestFromArgs(os.Args[1:], os.Stdout, os.Stderr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+// runCreator creates the full pull requests requested of f, displaying the
+// outcome for each repository. An error is returned if the repositories
+// cannot be determined.
+func runCreator(f FullPullRequestCreator, output, errOutput io.Writer) ([]BatchResult, error) {
+	if f.batchOwner != "" || len(f.batchRepos) > 0 || len(f.workspaceBranches) > 0 {
+		f = f.shareHTTPClient()
+	}
+	if f.batchOwner != "" {
+		var err error
+		f.batchRepos, err = f.OwnerRepos(f.batchOwner)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(f.batchRepos) > 0 {
+		var err error
+		f.batchRepos, err = f.ExpandRepoPatterns(f.batchRepos)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(f.workspaceBranches) > 0 {
+		repos := f.batchRepos
+		if len(repos) == 0 {
+			repos = []string{f.Repo}
+		}
+		results := f.CreateWorkspace(repos, f.workspaceBranches, output)
+		printBatchResults(results, output, errOutput)
+		return results, nil
+	}
+	if len(f.batchRepos) == 0 {
+		started := f.now()
+		PR, err := f.CreateWithResult()
+		result := BatchResult{Repo: f.Repo, PullRequest: PR, Err: err, Duration: f.now().Sub(started)}
+		if errors.Is(err, ErrAlreadyCreated) {
+			fmt.Fprintf(output, "Skipping, the same full pull request was already created at %s\n", PR.HTMLURL)
+			return []BatchResult{result}, nil
+		}
+		if errors.Is(err, ErrReviewExists) {
+			fmt.Fprintf(errOutput, "A full review pull request is already open for repository %s, at:\n", f.Repo)
+			return []BatchResult{result}, nil
+		}
+		if errors.Is(err, ErrDryRun) {
+			return []BatchResult{result}, nil
+		}
+		if err != nil {
+			fmt.Fprintf(errOutput, "%v\n", err)
+			return []BatchResult{result}, nil
+		}
+		fmt.Fprintf(output, "A full pull request has been created at %s\n", PR.HTMLURL)
+		return []BatchResult{result}, nil
+	}
+	results := f.CreateBatch(f.batchRepos, output)
+	printBatchResults(results, output, errOutput)
+	return results, nil
+}
+
+// printBatchResults displays the outcome for each repository, and branch
+// when reviewing several branches, of a batch.
+func printBatchResults(results []BatchResult, output, errOutput io.Writer) {
+	for _, result := range results {
+		name := result.Repo
+		if result.FullRepoBranch != "" {
+			name = fmt.Sprintf("%s (%s)", result.Repo, result.FullRepoBranch)
+		}
+		if errors.Is(result.Err, ErrRepoExcluded) {
+			fmt.Fprintf(output, "%s: skipped, excluded by repository filters\n", name)
+			continue
+		}
+		if errors.Is(result.Err, ErrAlreadyCreated) {
+			fmt.Fprintf(output, "%s: skipped, the same full pull request was already created at %s\n", name, result.PullRequest.HTMLURL)
+			continue
+		}
+		if errors.Is(result.Err, ErrReviewExists) {
+			fmt.Fprintf(output, "%s: skipped, a full review pull request is already open at %s\n", name, result.PullRequest.HTMLURL)
+			continue
+		}
+		if errors.Is(result.Err, ErrDryRun) {
+			fmt.Fprintf(output, "%s: %v\n", name, result.Err)
+			continue
+		}
+		if result.Err != nil {
+			fmt.Fprintf(errOutput, "%s: %v\n", name, result.Err)
+			continue
+		}
+		fmt.Fprintf(output, "%s: a full pull request has been created at %s\n", name, result.PullRequest.HTMLURL)
 	}
-	fmt.Printf("A full pull request has been created at %s\n", PRURL)
 }
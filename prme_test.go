@@ -1,13 +1,27 @@
 package prme_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"github.com/ivanfetch/prme"
 	"io"
-	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -410,10 +424,41 @@ func TestMergeBranch(t *testing.T) {
 
 	baseBranch := "review"
 	headBranch := "main"
-	err = r.MergeBranch(baseBranch, headBranch)
+	got, err := r.MergeBranch(baseBranch, headBranch, "Merge main into review")
 	if err != nil {
 		t.Fatal(err)
 	}
+	want := "c98eeaa40364b0486743720e8e7340e52fd98c46"
+	if want != got {
+		t.Fatalf("want merge commit sha %q, got %q", want, got)
+	}
+}
+
+func TestMergeBranchAlreadyMerged(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/merges"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.MergeBranch("review", "main", "")
+	if !errors.Is(err, prme.ErrAlreadyMerged) {
+		t.Fatalf("want error %v, got %v", prme.ErrAlreadyMerged, err)
+	}
 }
 
 func TestMergeBranchReturnsError(t *testing.T) {
@@ -462,7 +507,7 @@ func TestMergeBranchReturnsError(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		err = r.MergeBranch(tc.baseBranch, tc.headBranch)
+		_, err = r.MergeBranch(tc.baseBranch, tc.headBranch, "")
 		if err == nil {
 			t.Errorf("expected error, using repository %q, base branch %q, and head branch %q", r, tc.baseBranch, tc.headBranch)
 		}
@@ -501,16 +546,71 @@ func TestCreatePullRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := r.CreatePullRequest("test1",
+	got, err := r.CreatePullRequestWithResult("test1",
 		"A full review of this repository",
 		"orphan",
 		"review")
 	if err != nil {
 		t.Fatal(err)
 	}
-	want := "https://github.com/ivanfetch/ghapitest/pull/7"
-	if want != got {
-		t.Fatalf("want %q, got %q", want, got)
+	want := &prme.PullRequest{
+		Number:       7,
+		Title:        "test1",
+		URL:          "https://api.github.com/repos/ivanfetch/ghapitest/pulls/7",
+		HTMLURL:      "https://github.com/ivanfetch/ghapitest/pull/7",
+		State:        "open",
+		User:         prme.User{Login: "ivanfetch"},
+		Head:         prme.PullRequestBranch{Ref: "review", Sha: "05db72cfac1ee0eef0ceb72193f648f229d6fcc3"},
+		Base:         prme.PullRequestBranch{Ref: "orphan", Sha: "6139a485158a3056fb23ad9bbb9c23b4b32f45b6"},
+		CreatedAt:    time.Date(2021, 8, 19, 17, 47, 41, 0, time.UTC),
+		UpdatedAt:    time.Date(2021, 8, 19, 17, 47, 41, 0, time.UTC),
+		ChangedFiles: 2,
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect pull request using test data file %s\ndiff reflects want vs. got: %s", testFileName, cmp.Diff(want, got))
+	}
+	// The deprecated CreatePullRequest still returns the URL.
+	gotURL, err := r.CreatePullRequest("test1",
+		"A full review of this repository",
+		"orphan",
+		"review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotURL != want.HTMLURL {
+		t.Fatalf("want pull request URL %q, got %q", want.HTMLURL, gotURL)
+	}
+}
+
+func TestCreateDraftPullRequest(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/pulls", prme.FakeResponse{
+		StatusCode: http.StatusCreated,
+		Body:       `{"number": 7, "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "draft": true}`,
+	})
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.CreateDraftPullRequest("test1", "A full review of this repository", "orphan", "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Draft {
+		t.Error("want a draft pull request")
+	}
+	requests := fc.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("want 1 request, got %d", len(requests))
+	}
+	wantBody := `{"title":"test1","body":"A full review of this repository","base":"orphan","head":"review","draft":true}`
+	if wantBody != string(requests[0].Body) {
+		t.Fatalf("want request body %s, got %s", wantBody, requests[0].Body)
 	}
 }
 
@@ -559,7 +659,7 @@ func TestCreatePullRequestReturnsError(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		_, err = r.CreatePullRequest(tc.title, tc.body, tc.baseBranch, tc.headBranch)
+		_, err = r.CreatePullRequestWithResult(tc.title, tc.body, tc.baseBranch, tc.headBranch)
 		if err == nil {
 			t.Fatalf("error expected using repository %q, base branch %q, and head branch %q", r, tc.baseBranch, tc.headBranch)
 		}
@@ -570,14 +670,15 @@ func TestNewFullPullRequestCreatorFromArgs(t *testing.T) {
 	testCases := []struct {
 		description  string
 		args         []string
+		token        string
 		setEnv, want prme.FullPullRequestCreator
 	}{
 		{
 			description: "no arguments which will use default values",
 			args:        []string{"dummyRepo"},
+			token:       "dummyToken",
 			// Avoid environment in the calling OS breaking the test.
 			setEnv: prme.FullPullRequestCreator{
-				Token:          "dummyToken",
 				FullRepoBranch: "",
 				Title:          "",
 				Body:           "",
@@ -587,20 +688,20 @@ func TestNewFullPullRequestCreatorFromArgs(t *testing.T) {
 			want: prme.FullPullRequestCreator{
 				Repo:           "dummyRepo",
 				FullRepoBranch: "main",
-				Token:          "dummyToken",
 
-				Title:      "Full Review",
-				Body:       "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
-				BaseBranch: "prme-full-review",
-				HeadBranch: "prme-full-content",
+				BranchPrefix: "prme-",
+				Title:        "Full Review",
+				Body:         "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
+				BaseBranch:   "prme-full-review",
+				HeadBranch:   "prme-full-content",
 			},
 		},
 		{
 			description: "repo with github.com/ prefix",
 			args:        []string{"github.com/dummyRepo"},
+			token:       "dummyToken",
 			// Avoid environment in the calling OS breaking the test.
 			setEnv: prme.FullPullRequestCreator{
-				Token:          "dummyToken",
 				FullRepoBranch: "",
 				Title:          "",
 				Body:           "",
@@ -610,19 +711,19 @@ func TestNewFullPullRequestCreatorFromArgs(t *testing.T) {
 			want: prme.FullPullRequestCreator{
 				Repo:           "dummyRepo",
 				FullRepoBranch: "main",
-				Token:          "dummyToken",
 
-				Title:      "Full Review",
-				Body:       "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
-				BaseBranch: "prme-full-review",
-				HeadBranch: "prme-full-content",
+				BranchPrefix: "prme-",
+				Title:        "Full Review",
+				Body:         "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
+				BaseBranch:   "prme-full-review",
+				HeadBranch:   "prme-full-content",
 			},
 		},
 		{
 			description: "set environment variables",
 			args:        []string{"dummyRepo"},
+			token:       "dummyTokenSetByEnvVar",
 			setEnv: prme.FullPullRequestCreator{
-				Token:          "dummyTokenSetByEnvVar",
 				FullRepoBranch: "master",
 				Title:          "complete review",
 				Body:           "A full review.",
@@ -630,46 +731,109 @@ func TestNewFullPullRequestCreatorFromArgs(t *testing.T) {
 				HeadBranch:     "review",
 			},
 			want: prme.FullPullRequestCreator{
-				Token:          "dummyTokenSetByEnvVar",
 				Repo:           "dummyRepo",
 				FullRepoBranch: "master",
 				Title:          "complete review",
 				Body:           "A full review.",
 				BaseBranch:     "orphan",
 				HeadBranch:     "review",
+				BranchPrefix:   "prme-",
 			},
 		},
 		{
 			description: "specify flags",
 			args:        []string{"-title", "my review", "-body", "another review!", "-fbranch", "prod", "-bbranch", "base", "-hbranch", "myreview", "myrepo"},
-			setEnv: prme.FullPullRequestCreator{
-				Token: "dummyToken",
-			},
+			token:       "dummyToken",
+			setEnv:      prme.FullPullRequestCreator{},
 			want: prme.FullPullRequestCreator{
 				Repo:           "myrepo",
-				Token:          "dummyToken",
 				FullRepoBranch: "prod",
 				Title:          "my review",
 				Body:           "another review!",
 				BaseBranch:     "base",
 				HeadBranch:     "myreview",
+				BranchPrefix:   "prme-",
+			},
+		},
+		{
+			description: "branch prefix",
+			args:        []string{"-branch-prefix", "audit/", "-hbranch", "myreview", "myrepo"},
+			token:       "dummyToken",
+			setEnv:      prme.FullPullRequestCreator{},
+			want: prme.FullPullRequestCreator{
+				Repo:           "myrepo",
+				FullRepoBranch: "main",
+				Title:          "Full Review",
+				Body:           "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
+				BaseBranch:     "audit/full-review",
+				HeadBranch:     "myreview",
+				BranchPrefix:   "audit/",
+			},
+		},
+		{
+			description: "repository name with owner",
+			args:        []string{"-owner", "myorg", "myrepo"},
+			token:       "dummyToken",
+			setEnv:      prme.FullPullRequestCreator{},
+			want: prme.FullPullRequestCreator{
+				Repo:           "myorg/myrepo",
+				FullRepoBranch: "main",
+				Title:          "Full Review",
+				Body:           "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
+				BaseBranch:     "prme-full-review",
+				HeadBranch:     "prme-full-content",
+				BranchPrefix:   "prme-",
+			},
+		},
+		{
+			description: "reviewers",
+			args:        []string{"-reviewers", "octocat,ivanfetch/security-team", "-reviewers", "ivanfetch", "myrepo"},
+			token:       "dummyToken",
+			setEnv:      prme.FullPullRequestCreator{},
+			want: prme.FullPullRequestCreator{
+				Repo:           "myrepo",
+				FullRepoBranch: "main",
+				Title:          "Full Review",
+				Body:           "A full review of the entire repository. When this PR is complete, be sure to manually merge its head branch into the main branch for this repository.",
+				BaseBranch:     "prme-full-review",
+				HeadBranch:     "prme-full-content",
+				BranchPrefix:   "prme-",
+				Reviewers:      []string{"octocat", "ivanfetch/security-team", "ivanfetch"},
 			},
 		},
 	}
+	defaultStateFile, err := prme.DefaultStateFile()
+	if err != nil {
+		t.Fatal(err)
+	}
 	// Use of t.Setenv() below, prohibits t.Parallel()
 	for _, tc := range testCases {
-		t.Setenv("GH_TOKEN", tc.setEnv.Token)
+		tc.want.StateFile = defaultStateFile
+		t.Setenv("PRME_STATE_FILE", "")
+		t.Setenv("PRME_BRANCH_PREFIX", "")
+		t.Setenv("PRME_OWNER", "")
+		t.Setenv("GH_TOKEN", tc.token)
 		t.Setenv("PRME_TITLE", tc.setEnv.Title)
 		t.Setenv("PRME_BODY", tc.setEnv.Body)
 		t.Setenv("PRME_FBRANCH", tc.setEnv.FullRepoBranch)
 		t.Setenv("PRME_BBRANCH", tc.setEnv.BaseBranch)
 		t.Setenv("PRME_HBRANCH", tc.setEnv.HeadBranch)
 
-		got, err := prme.NewFullPullRequestCreatorFromArgs(tc.args, ioutil.Discard, ioutil.Discard)
+		got, err := prme.NewFullPullRequestCreatorFromArgs(tc.args, io.Discard, io.Discard)
 		if err != nil {
 			t.Fatalf("for test-case %s, %v", tc.description, err)
 		}
 		t.Logf("test %q got FullPullRequestCreator: %+v", tc.description, got)
+		for _, option := range []prme.FullPullRequestCreatorOption{
+			prme.WithToken(tc.token),
+			prme.WithOutput(io.Discard),
+			prme.WithErrOutput(io.Discard),
+		} {
+			err = option(&tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
 
 		cmpOptions := cmp.AllowUnexported(*got)
 		if !cmp.Equal(tc.want, *got, cmpOptions) {
@@ -677,3 +841,1193 @@ func TestNewFullPullRequestCreatorFromArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestNewFullPullRequestCreatorFromArgsInvalidOutput(t *testing.T) {
+	t.Setenv("GH_TOKEN", "dummyToken")
+	t.Setenv("PRME_OUTPUT", "")
+	argSets := [][]string{
+		{"-output", "env", "ivanfetch/one", "ivanfetch/two"},
+		{"-output", "env", "-org", "ivanfetch"},
+		{"-output", "yaml", "ivanfetch/one"},
+	}
+	for _, args := range argSets {
+		_, err := prme.NewFullPullRequestCreatorFromArgs(args, io.Discard, io.Discard)
+		if err == nil {
+			t.Errorf("want an error for arguments %v, got nil", args)
+		}
+	}
+}
+
+func TestNewFullPullRequestCreatorFromArgsWithOptions(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("PRME_TITLE", "")
+	got, err := prme.NewFullPullRequestCreatorFromArgs([]string{"-title", "my review", "-draft", "myrepo"}, io.Discard, io.Discard,
+		prme.WithToken("dummyToken"),
+		prme.WithTitle("another review"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "another review" {
+		t.Errorf("want the title from the option to override the flag, got %q", got.Title)
+	}
+	if !got.Draft {
+		t.Error("want the -draft flag to be kept")
+	}
+	_, err = prme.NewFullPullRequestCreatorFromArgs([]string{"myrepo"}, io.Discard, io.Discard, prme.WithTitle(""))
+	if err == nil {
+		t.Error("want an error from an invalid option")
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("GH_TOKEN", "mySecretToken")
+	t.Setenv("PRME_OUTPUT", "")
+
+	var output, errOutput strings.Builder
+	got := prme.RunCLI([]string{"-output", "mySecretToken", "myrepo"}, &output, &errOutput)
+	if got != 1 {
+		t.Errorf("want exit code 1 for an invalid output format, got %d", got)
+	}
+	if !strings.Contains(errOutput.String(), "the output format must be") {
+		t.Errorf("want an error about the output format, got %q", errOutput.String())
+	}
+	if strings.Contains(errOutput.String(), "mySecretToken") {
+		t.Errorf("want the token redacted from errors, got %q", errOutput.String())
+	}
+	if output.Len() != 0 {
+		t.Errorf("want nothing on standard output, got %q", output.String())
+	}
+
+	errOutput.Reset()
+	got = prme.RunCLI([]string{"refresh"}, &output, &errOutput)
+	if got != 1 {
+		t.Errorf("want exit code 1 for a subcommand without a repository, got %d", got)
+	}
+	if !strings.Contains(errOutput.String(), "please specify one repository") {
+		t.Errorf("want the subcommand error, got %q", errOutput.String())
+	}
+}
+
+func TestCreateWithClientOptions(t *testing.T) {
+	t.Parallel()
+
+	var requested bool
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/ivanfetch/ghapitest" {
+			requested = true
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	_, err := prme.CreateFullPullRequest("ivanfetch/ghapitest",
+		prme.WithToken("dummyToken"),
+		prme.WithClientOptions(
+			prme.WithHTTPClient(ts.Client()),
+			prme.WithAPIHost(ts.URL),
+		),
+	)
+	if err == nil {
+		t.Fatal("want an error for a repository that does not exist")
+	}
+	if !requested {
+		t.Errorf("want the repository to be requested from the test server, got error %v", err)
+	}
+}
+
+func TestCreateRef(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestCreateRef.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/refs"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		var gotBody struct{ Ref, Sha string }
+		err := json.NewDecoder(r.Body).Decode(&gotBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRef := "refs/heads/review"
+		if wantRef != gotBody.Ref {
+			t.Errorf("want ref %q, got %q", wantRef, gotBody.Ref)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w.WriteHeader(http.StatusCreated)
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.CreateRef("heads/review", "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateRef(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestUpdateRef.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/refs/heads/review"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		if r.Method != http.MethodPatch {
+			t.Errorf("want HTTP method %s, got %s", http.MethodPatch, r.Method)
+		}
+		var gotBody struct {
+			Sha   string
+			Force bool
+		}
+		err := json.NewDecoder(r.Body).Decode(&gotBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotBody.Force {
+			t.Error("want force to be true")
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.UpdateRef("refs/heads/review", "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteRef(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/refs/heads/review"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		if r.Method != http.MethodDelete {
+			t.Errorf("want HTTP method %s, got %s", http.MethodDelete, r.Method)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.DeleteRef("heads/review")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTree(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestGetTree.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/trees/main?recursive=1"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetTree("main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &prme.Tree{
+		Sha: "be00934837e27c470bba39d04f78e9ca6c8d759e",
+		Entries: []prme.TreeEntry{
+			{Path: "README.md", Mode: "100644", Type: "blob", Sha: "5b2c9e3c0b6c0e8c33e2a1c4b5ac5c7bd1e0f2a1", Size: 30},
+			{Path: "docs", Mode: "040000", Type: "tree", Sha: "d564d0bc3dd917926892c55e3706cc116d5b165e"},
+			{Path: "docs/usage.md", Mode: "100644", Type: "blob", Sha: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect tree using test data file %s\ndiff reflects want vs. got: %s", testFileName, cmp.Diff(want, got))
+	}
+}
+
+func TestGetBlob(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestGetBlob.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/blobs/5b2c9e3c0b6c0e8c33e2a1c4b5ac5c7bd1e0f2a1"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetBlob("5b2c9e3c0b6c0e8c33e2a1c4b5ac5c7bd1e0f2a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# ghapitest\n\nTest repository.\n"
+	if want != string(got.Content) {
+		t.Fatalf("want blob content %q, got %q", want, got.Content)
+	}
+}
+
+func TestCreateCommitStatus(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/statuses/87d2b8f97a27554711c1eb0d1bb0f8f623a2af25"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		var got prme.CommitStatus
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := prme.CommitStatus{
+			State:   "pending",
+			Context: prme.FullReviewStatusContext,
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("got incorrect commit status\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.CreateCommitStatus("87d2b8f97a27554711c1eb0d1bb0f8f623a2af25", prme.CommitStatus{
+		State:   "pending",
+		Context: prme.FullReviewStatusContext,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateCommitComment(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/commits/87d2b8f97a27554711c1eb0d1bb0f8f623a2af25/comments"
+		if wantRequestURL != r.RequestURI {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		var got struct{ Body string }
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "A full review is in progress"
+		if want != got.Body {
+			t.Errorf("want comment %q, got %q", want, got.Body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.CreateCommitComment("87d2b8f97a27554711c1eb0d1bb0f8f623a2af25", "A full review is in progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetPullRequest(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestGetPullRequest.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls/7"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetPullRequest(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Head.Ref != "review" || got.Base.Ref != "orphan" || got.State != "open" {
+		t.Fatalf("got incorrect pull request %+v using test data file %s", got, testFileName)
+	}
+}
+
+func TestDoReturnsRateLimitAndNextPage(t *testing.T) {
+	t.Parallel()
+
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", "1629516000")
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/ivanfetch/ghapitest/pulls?page=2>; rel="next"`, ts.URL))
+		fmt.Fprint(w, "[]")
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(http.MethodGet, "/repos/ivanfetch/ghapitest/pulls", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	want := prme.RateLimit{
+		Limit:     5000,
+		Remaining: 4999,
+		Reset:     time.Unix(1629516000, 0),
+	}
+	if !cmp.Equal(want, resp.Rate) {
+		t.Errorf("got incorrect response rate limit\ndiff reflects want vs. got: %s", cmp.Diff(want, resp.Rate))
+	}
+	if !cmp.Equal(want, c.RateLimit()) {
+		t.Errorf("got incorrect client rate limit\ndiff reflects want vs. got: %s", cmp.Diff(want, c.RateLimit()))
+	}
+	wantNextPageURI := "/repos/ivanfetch/ghapitest/pulls?page=2"
+	if wantNextPageURI != resp.NextPageURI {
+		t.Errorf("want next page URI %q, got %q", wantNextPageURI, resp.NextPageURI)
+	}
+}
+
+func TestMiddlewareWrapsRequestsInOrder(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "outer,inner"
+		got := r.Header.Get("X-Middleware")
+		if want != got {
+			t.Errorf("want X-Middleware header %q, got %q", want, got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	var calls []string
+	appendHeader := func(name string) prme.Middleware {
+		return func(next prme.Doer) prme.Doer {
+			return prme.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				if v := req.Header.Get("X-Middleware"); v != "" {
+					name = v + "," + name
+				}
+				req.Header.Set("X-Middleware", name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithMiddleware(appendHeader("outer"), appendHeader("inner")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.MakeAPIRequest(http.MethodGet, "/rate_limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	want := []string{"outer", "inner"}
+	if !cmp.Equal(want, calls) {
+		t.Fatalf("got incorrect middleware call order\ndiff reflects want vs. got: %s", cmp.Diff(want, calls))
+	}
+}
+
+func TestWithPerPage(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/milestones?state=all&per_page=50"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		fmt.Fprint(w, "[]")
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithPerPage(50),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.FindMilestone("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = prme.NewClient("dummyToken", prme.WithPerPage(101))
+	if err == nil {
+		t.Fatal("want error for more than 100 results per page")
+	}
+}
+
+func TestDoReturnsTypedServiceErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/maintenance":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/abuse":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(http.MethodGet, "/maintenance", nil)
+	var maintenanceErr *prme.MaintenanceError
+	if !errors.As(err, &maintenanceErr) {
+		t.Fatalf("want a MaintenanceError, got %v", err)
+	}
+	if maintenanceErr.RetryAfter != 30*time.Second {
+		t.Errorf("want retry after 30s, got %v", maintenanceErr.RetryAfter)
+	}
+
+	_, err = c.Do(http.MethodGet, "/abuse", nil)
+	var abuseErr *prme.AbuseRateLimitError
+	if !errors.As(err, &abuseErr) {
+		t.Fatalf("want an AbuseRateLimitError, got %v", err)
+	}
+
+	resp, err := c.Do(http.MethodGet, "/forbidden", nil)
+	if err != nil {
+		t.Fatalf("want no error for a permission HTTP 403, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "Resource not accessible") {
+		t.Fatalf("want the response body to remain readable, got %q", body)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/declared":
+			w.Header().Set("Content-Length", "2048")
+			fmt.Fprint(w, strings.Repeat(" ", 2048))
+		case "/repos/ivanfetch/ghapitest/git/trees/t1":
+			// Flushing sends the response without a Content-Length.
+			fmt.Fprint(w, `{"sha":"t1","tree":[`)
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, strings.Repeat(`{"path":"file","type":"blob"},`, 100))
+			fmt.Fprint(w, `{"path":"last","type":"blob"}]}`)
+		default:
+			fmt.Fprint(w, strings.Repeat(" ", 1024))
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithMaxResponseSize(1024),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tooLarge *prme.ResponseTooLargeError
+	_, err = r.Client.Do(http.MethodGet, "/declared", nil)
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("want a ResponseTooLargeError for a declared length over the limit, got %v", err)
+	}
+	_, err = r.GetTree("t1", false)
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("want a ResponseTooLargeError while decoding a response over the limit, got %v", err)
+	}
+	if tooLarge.Limit != 1024 {
+		t.Errorf("want the limit of 1024 bytes in the error, got %d", tooLarge.Limit)
+	}
+	resp, err := r.Client.Do(http.MethodGet, "/exact", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("want a response of exactly the limit to be read, got %v", err)
+	}
+	if len(body) != 1024 {
+		t.Errorf("want 1024 bytes, got %d", len(body))
+	}
+	_, err = prme.NewClient("dummyToken", prme.WithMaxResponseSize(0))
+	if err == nil {
+		t.Error("want an error for a maximum response size of 0")
+	}
+}
+
+func TestListBranches(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestListBranches.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/matching-refs/heads/prme-?per_page=100"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.ListBranches(prme.DefaultBranchPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"prme-full-content", "prme-full-review"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect branches\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestGetRef(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestGetRef.json"
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI == "/repos/ivanfetch/ghapitest/git/ref/heads/will-not-exist" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		wantRequestURL := "/repos/ivanfetch/ghapitest/git/ref/heads/review"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetRef("heads/review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "87d2b8f97a27554711c1eb0d1bb0f8f623a2af25"
+	if want != got {
+		t.Fatalf("want sha %q, got %q", want, got)
+	}
+	_, err = r.GetRef("heads/will-not-exist")
+	if !errors.Is(err, prme.ErrRefNotFound) {
+		t.Fatalf("want error %v, got %v", prme.ErrRefNotFound, err)
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		statusCode  int
+		wantErr     bool
+	}{
+		{description: "valid token", statusCode: http.StatusOK},
+		{description: "invalid token", statusCode: http.StatusUnauthorized, wantErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				wantRequestURL := "/rate_limit"
+				gotRequestURL := r.RequestURI
+				if wantRequestURL != gotRequestURL {
+					t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+				}
+				w.WriteHeader(tc.statusCode)
+				fmt.Fprint(w, `{}`)
+			}))
+			defer ts.Close()
+
+			c, err := prme.NewClient("dummyToken",
+				prme.WithHTTPClient(ts.Client()),
+				prme.WithAPIHost(ts.URL),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.CheckToken()
+			if tc.wantErr && err == nil {
+				t.Fatal("want an error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestGetFileContents(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/repos/ivanfetch/.prme/contents/config.yaml":
+			// The content is wrapped across lines, as Github does.
+			fmt.Fprint(w, `{"type": "file", "encoding": "base64", "content": "dGl0bGU6IFNl\nY3VyaXR5IFJldmlldwo=\n"}`)
+		case "/repos/ivanfetch/.prme/contents/missing.yaml":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		default:
+			t.Errorf("unexpected request for Github URL %q", r.RequestURI)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/.prme", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.GetFileContents("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "title: Security Review\n"
+	if want != string(got) {
+		t.Errorf("want contents %q, got %q", want, got)
+	}
+	_, err = r.GetFileContents("missing.yaml")
+	if !errors.Is(err, prme.ErrFileNotFound) {
+		t.Errorf("want %v for a missing file, got %v", prme.ErrFileNotFound, err)
+	}
+}
+
+func TestRequestReviewers(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls/7/requested_reviewers"
+		if r.Method != http.MethodPost || r.RequestURI != wantRequestURL {
+			t.Errorf("Want POST %q for Github URL, got %s %q", wantRequestURL, r.Method, r.RequestURI)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"reviewers":["octocat"],"team_reviewers":["security-team"]}`
+		if want != string(body) {
+			t.Errorf("want request body %s, got %s", want, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RequestReviewers(7, []string{"octocat", "ivanfetch/security-team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSanitizePullRequestText(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description         string
+		title, body         string
+		wantTitle, wantBody string
+		wantErr             bool
+	}{
+		{
+			description: "control characters are removed",
+			title:       "Full\x00 Review\n",
+			body:        "Line one\r\nLine\x1b[31m two\tend",
+			wantTitle:   "Full Review",
+			wantBody:    "Line one\nLine[31m two\tend",
+		},
+		{
+			description: "invalid UTF-8 is removed",
+			title:       "Full \xffReview",
+			body:        "body",
+			wantTitle:   "Full Review",
+			wantBody:    "body",
+		},
+		{
+			description: "title too long",
+			title:       strings.Repeat("t", prme.MaxTitleLength+1),
+			body:        "body",
+			wantErr:     true,
+		},
+		{
+			description: "body too long",
+			title:       "Full Review",
+			body:        strings.Repeat("b", prme.MaxBodyLength+1),
+			wantErr:     true,
+		},
+		{
+			description: "multi-byte characters count once",
+			title:       strings.Repeat("レ", prme.MaxTitleLength),
+			body:        "body",
+			wantTitle:   strings.Repeat("レ", prme.MaxTitleLength),
+			wantBody:    "body",
+		},
+		{
+			description: "title of only control characters",
+			title:       "\x00\x01",
+			body:        "body",
+			wantErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			gotTitle, gotBody, err := prme.SanitizePullRequestText(tc.title, tc.body)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("want an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantTitle != gotTitle {
+				t.Errorf("want title %q, got %q", tc.wantTitle, gotTitle)
+			}
+			if tc.wantBody != gotBody {
+				t.Errorf("want body %q, got %q", tc.wantBody, gotBody)
+			}
+		})
+	}
+}
+
+func TestGetRepository(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest"
+		if wantRequestURL != r.RequestURI {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		fmt.Fprint(w, `{"full_name": "ivanfetch/ghapitest", "default_branch": "trunk", "private": true}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.GetRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &prme.Repository{FullName: "ivanfetch/ghapitest", DefaultBranch: "trunk", Private: true}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect repository\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestFindOpenPullRequest(t *testing.T) {
+	t.Parallel()
+
+	open := true
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/pulls?state=open&base=orphan&head=ivanfetch%3Areview"
+		if wantRequestURL != r.RequestURI {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		if !open {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"number": 7, "html_url": "https://github.com/ivanfetch/ghapitest/pull/7"}]`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.FindOpenPullRequest("orphan", "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Number != 7 || got.HTMLURL != "https://github.com/ivanfetch/ghapitest/pull/7" {
+		t.Fatalf("got incorrect pull request %+v", got)
+	}
+	open = false
+	got, err = r.FindOpenPullRequest("orphan", "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("want no pull request when none are open, got %+v", got)
+	}
+}
+
+func TestWithUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/ivanfetch/ghapitest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"full_name": "ivanfetch/ghapitest"}`)
+	}))
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	// The API host is not resolvable, so requests must use the socket.
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithAPIHost("http://github.invalid"),
+		prme.WithUnixSocket(socketPath),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatal("want the repository to exist")
+	}
+}
+
+func TestWithAPIAddresses(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"full_name": "ivanfetch/ghapitest"}`)
+	}))
+	defer ts.Close()
+	_, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The API host is not resolvable, so requests must use the pinned
+	// address.
+	apiHost := "http://github.invalid:" + port
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithAPIHost(apiHost),
+		prme.WithAPIAddresses("127.0.0.1"),
+		prme.WithIPVersion(4),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatal("want the repository to exist")
+	}
+
+	r, err = prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithAPIHost(apiHost),
+		prme.WithAPIAddresses("127.0.0.1"),
+		prme.WithIPVersion(6),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Exists()
+	if err == nil {
+		t.Fatal("want an error connecting to an IPv4 address using only IPv6, got none")
+	}
+
+	_, err = prme.NewClient("dummyToken", prme.WithAPIAddresses("github.com"))
+	if err == nil {
+		t.Fatal("want an error for an API address that is not an IP address, got none")
+	}
+	_, err = prme.NewClient("dummyToken", prme.WithIPVersion(5))
+	if err == nil {
+		t.Fatal("want an error for IP version 5, got none")
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its
+// private key as PEM files, returning their paths and the certificate.
+func writeClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "prme test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(t.TempDir(), "client.crt")
+	keyFile = filepath.Join(t.TempDir(), "client.key")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile, cert := writeClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"full_name": "ivanfetch/ghapitest"}`)
+	}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Exists()
+	if err == nil {
+		t.Fatal("want an error connecting without a client certificate, got none")
+	}
+
+	r, err = prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+		prme.WithClientCertificate(certFile, keyFile),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatal("want the repository to exist")
+	}
+}
@@ -0,0 +1,25 @@
+package prme
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+)
+
+// startCPUProfile writes a CPU profile to file, in the pprof format read by
+// go tool pprof, until the returned function is called.
+func startCPUProfile(file string) (stop func() error, err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("while creating the profile file: %w", err)
+	}
+	err = pprof.StartCPUProfile(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("while starting the CPU profile: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		return f.Close()
+	}, nil
+}
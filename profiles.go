@@ -0,0 +1,357 @@
+package prme
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Git protocols used to clone repositories, set by WithGitProtocol.
+const (
+	GitProtocolSSH   = "ssh"
+	GitProtocolHTTPS = "https"
+)
+
+// ErrHostProfileNotFound is returned when a host profile is not defined in
+// the configuration file.
+var ErrHostProfileNotFound = errors.New("the host profile is not defined")
+
+// DefaultConfigFile returns the default configuration file, in the per-user
+// configuration directory.
+func DefaultConfigFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "prme", "config.yaml"), nil
+}
+
+// HostProfile is the configuration of a Github instance, such as github.com
+// or a Github Enterprise Server, so it does not have to be specified for each
+// run.
+type HostProfile struct {
+	Name string
+	// APIURL is the Github API URL, such as
+	// https://github.example.com/api/v3.
+	APIURL string
+	// TokenEnv is an environment variable containing the token, and
+	// TokenCommand a command, run by sh, that displays the token. If neither
+	// is set, the GH_TOKEN environment variable is used.
+	TokenEnv, TokenCommand string
+	// CACert is a PEM encoded file of certificate authorities trusted for
+	// the API host, in addition to those of the system.
+	CACert string
+	// ClientCert and ClientKey are a PEM encoded client certificate and its
+	// key, presented to the API host.
+	ClientCert, ClientKey string
+	// GitProtocol is GitProtocolSSH or GitProtocolHTTPS.
+	GitProtocol string
+	// GitHost is the host of git remotes, when it differs from the host of
+	// APIURL.
+	GitHost string
+	// RateLimit is disabled, or the longest duration to wait for the rate
+	// limit of the API host to reset, as supported by
+	// ParseRateLimitOverride.
+	RateLimit string
+}
+
+// ParseHostProfiles parses the host profiles of a configuration file, which
+// uses the subset of YAML described by ParseOrgConfig, adding nested keys.
+// Unknown keys are ignored.
+//
+// For example:
+//
+//	hosts:
+//	  enterprise:
+//	    api_url: https://github.example.com/api/v3
+//	    token_env: GHE_TOKEN
+//	    ca_cert: /etc/ssl/example-ca.pem
+//	    git_protocol: https
+//	    git_host: git.example.com
+//	    rate_limit: disabled
+func ParseHostProfiles(data []byte) (map[string]HostProfile, error) {
+	values, err := parseYAMLSubset(data)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]HostProfile)
+	hosts, ok := values["hosts"]
+	if !ok {
+		return profiles, nil
+	}
+	if hosts.mapping == nil {
+		return nil, errors.New("hosts must contain nested host profiles")
+	}
+	for name, value := range hosts.mapping {
+		if value.mapping == nil {
+			return nil, fmt.Errorf("the host profile %s must contain nested keys", name)
+		}
+		p := HostProfile{Name: name}
+		for key, v := range value.mapping {
+			var field *string
+			switch key {
+			case "api_url":
+				field = &p.APIURL
+			case "token_env":
+				field = &p.TokenEnv
+			case "token_command":
+				field = &p.TokenCommand
+			case "ca_cert":
+				field = &p.CACert
+			case "client_cert":
+				field = &p.ClientCert
+			case "client_key":
+				field = &p.ClientKey
+			case "git_protocol":
+				field = &p.GitProtocol
+			case "git_host":
+				field = &p.GitHost
+			case "rate_limit":
+				field = &p.RateLimit
+			default:
+				continue
+			}
+			*field, err = v.scalar(key)
+			if err != nil {
+				return nil, fmt.Errorf("in the host profile %s: %w", name, err)
+			}
+		}
+		err = p.validate()
+		if err != nil {
+			return nil, err
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// validate returns an error if the settings of the profile are invalid.
+func (p HostProfile) validate() error {
+	if p.APIURL != "" {
+		u, err := url.Parse(p.APIURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("the api_url of host profile %s must be an http or https URL, not %q", p.Name, p.APIURL)
+		}
+	}
+	if p.TokenEnv != "" && p.TokenCommand != "" {
+		return fmt.Errorf("the host profile %s can set token_env or token_command, not both", p.Name)
+	}
+	if (p.ClientCert == "") != (p.ClientKey == "") {
+		return fmt.Errorf("the host profile %s must set both client_cert and client_key", p.Name)
+	}
+	if p.GitProtocol != "" && p.GitProtocol != GitProtocolSSH && p.GitProtocol != GitProtocolHTTPS {
+		return fmt.Errorf("the git_protocol of host profile %s must be %s or %s, not %q", p.Name, GitProtocolSSH, GitProtocolHTTPS, p.GitProtocol)
+	}
+	if p.RateLimit != "" {
+		_, _, err := ParseRateLimitOverride("host=" + p.RateLimit)
+		if err != nil {
+			return fmt.Errorf("the rate_limit of host profile %s must be disabled or a duration such as 10m, not %q", p.Name, p.RateLimit)
+		}
+	}
+	return nil
+}
+
+// LoadHostProfile returns the host profile name from configFile.
+// ErrHostProfileNotFound is returned if it is not defined.
+func LoadHostProfile(configFile, name string) (*HostProfile, error) {
+	data, err := os.ReadFile(configFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s, the configuration file %s does not exist", ErrHostProfileNotFound, name, configFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ParseHostProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s: %w", configFile, err)
+	}
+	p, ok := profiles[name]
+	if !ok {
+		var names []string
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s, the profiles in %s are: %s", ErrHostProfileNotFound, name, configFile, strings.Join(names, ", "))
+	}
+	return &p, nil
+}
+
+// Token returns the token from the token source of the profile, or an empty
+// string if it has none.
+func (p HostProfile) Token() (string, error) {
+	if p.TokenEnv != "" {
+		token := os.Getenv(p.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("the %s environment variable, the token_env of host profile %s, is not set", p.TokenEnv, p.Name)
+		}
+		return token, nil
+	}
+	if p.TokenCommand != "" {
+		output, err := exec.Command("sh", "-c", p.TokenCommand).Output()
+		if err != nil {
+			return "", fmt.Errorf("while running the token_command of host profile %s: %w", p.Name, err)
+		}
+		token := strings.TrimSpace(string(output))
+		if token == "" {
+			return "", fmt.Errorf("the token_command of host profile %s did not display a token", p.Name)
+		}
+		return token, nil
+	}
+	return "", nil
+}
+
+// ClientOptions returns the client options that apply the settings of the
+// profile.
+func (p HostProfile) ClientOptions() ([]ClientOption, error) {
+	var options []ClientOption
+	if p.APIURL != "" {
+		options = append(options, WithBaseURL(p.APIURL))
+	}
+	if p.CACert != "" {
+		options = append(options, WithCACertificate(p.CACert))
+	}
+	if p.ClientCert != "" {
+		options = append(options, WithClientCertificate(p.ClientCert, p.ClientKey))
+	}
+	if p.GitProtocol != "" {
+		options = append(options, WithGitProtocol(p.GitProtocol))
+	}
+	if p.GitHost != "" {
+		options = append(options, WithGitHost(p.GitHost))
+	}
+	if p.RateLimit != "" {
+		_, override, err := ParseRateLimitOverride("host=" + p.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		host := "api.github.com"
+		if p.APIURL != "" {
+			u, err := url.Parse(p.APIURL)
+			if err != nil {
+				return nil, err
+			}
+			host = u.Hostname()
+		}
+		options = append(options, WithRateLimitOverride(host, override))
+	}
+	return options, nil
+}
+
+// hostProfileOptions returns the token and client options of the host
+// profile name, of configFile. The token is empty if the profile does not
+// set one.
+func hostProfileOptions(configFile, name string) (token string, options []ClientOption, err error) {
+	p, err := LoadHostProfile(configFile, name)
+	if err != nil {
+		return "", nil, err
+	}
+	token, err = p.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	options, err = p.ClientOptions()
+	if err != nil {
+		return "", nil, err
+	}
+	return token, options, nil
+}
+
+// WithCACertificate trusts the PEM encoded certificate authorities in
+// certFile when connecting to the Github API, in addition to those of the
+// system, such as for a Github Enterprise Server using an internal
+// certificate authority.
+func WithCACertificate(certFile string) ClientOption {
+	return func(c *Client) error {
+		data, err := os.ReadFile(certFile)
+		if err != nil {
+			return fmt.Errorf("while reading the CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM encoded certificates were found in %s", certFile)
+		}
+		t := c.transport()
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = pool
+		c.setTransport(t)
+		return nil
+	}
+}
+
+// WithGitProtocol sets the protocol git commands use to clone repositories,
+// GitProtocolSSH by default, or GitProtocolHTTPS which authenticates using
+// the token of the client.
+func WithGitProtocol(protocol string) ClientOption {
+	return func(c *Client) error {
+		if protocol != GitProtocolSSH && protocol != GitProtocolHTTPS {
+			return fmt.Errorf("the git protocol must be %s or %s, not %q", GitProtocolSSH, GitProtocolHTTPS, protocol)
+		}
+		c.gitProtocol = protocol
+		return nil
+	}
+}
+
+// WithGitHost sets the host of git remotes, such as when a Github
+// Enterprise Server is cloned from a different host than its API. It can
+// include a port, such as for SSH on a non-standard port.
+func WithGitHost(host string) ClientOption {
+	return func(c *Client) error {
+		if host == "" || strings.ContainsAny(host, "/@") {
+			return fmt.Errorf("the git host must be a host name, optionally with a port, not %q", host)
+		}
+		c.gitHostOverride = host
+		return nil
+	}
+}
+
+// gitHost returns the host of git remotes, set by WithGitHost or
+// corresponding to the API host of the client, such as github.com for
+// api.github.com.
+func (c *Client) gitHost() string {
+	if c.gitHostOverride != "" {
+		return c.gitHostOverride
+	}
+	u, err := url.Parse(c.apiHost)
+	if err != nil || u.Hostname() == "" {
+		return "github.com"
+	}
+	// Github Enterprise Server serves the API below /api/v3 of the same
+	// host, and github.com and Github Enterprise Cloud use an api. host.
+	// Cloning over HTTPS uses the same port as the API.
+	if strings.HasPrefix(u.Path, "/api/") {
+		if c.gitProtocol == GitProtocolHTTPS {
+			return u.Host
+		}
+		return u.Hostname()
+	}
+	return strings.TrimPrefix(u.Hostname(), "api.")
+}
+
+// gitAuthEnv returns environment variables configuring git to authenticate
+// HTTPS remotes using the token of the client, which keeps the token out of
+// the remote URL and command-line of git.
+func (c *Client) gitAuthEnv() []string {
+	if c.gitProtocol != GitProtocolHTTPS {
+		return nil
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + c.token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
@@ -0,0 +1,237 @@
+package prme_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testHostProfiles = `# Github instances
+hosts:
+  github:
+    git_protocol: ssh
+  enterprise:
+    api_url: https://github.example.com/api/v3
+    token_env: PRME_TEST_GHE_TOKEN
+    ca_cert: /etc/ssl/example-ca.pem
+    git_protocol: https
+    git_host: git.example.com
+    rate_limit: disabled
+    future_setting: ignored
+`
+
+func TestParseHostProfiles(t *testing.T) {
+	t.Parallel()
+
+	got, err := prme.ParseHostProfiles([]byte(testHostProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]prme.HostProfile{
+		"github": {
+			Name:        "github",
+			GitProtocol: prme.GitProtocolSSH,
+		},
+		"enterprise": {
+			Name:        "enterprise",
+			APIURL:      "https://github.example.com/api/v3",
+			TokenEnv:    "PRME_TEST_GHE_TOKEN",
+			CACert:      "/etc/ssl/example-ca.pem",
+			GitProtocol: prme.GitProtocolHTTPS,
+			GitHost:     "git.example.com",
+			RateLimit:   "disabled",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect host profiles\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestParseHostProfilesInvalid(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"hosts: github.com\n",
+		"hosts:\n  enterprise:\n    git_protocol: ftp\n",
+		"hosts:\n  enterprise:\n    api_url: github.example.com\n",
+		"hosts:\n  enterprise:\n    token_env: GHE_TOKEN\n    token_command: pass show ghe\n",
+		"hosts:\n  enterprise:\n    client_cert: cert.pem\n",
+		"hosts:\n  enterprise:\n    rate_limit: sometimes\n",
+	}
+	for _, data := range invalid {
+		_, err := prme.ParseHostProfiles([]byte(data))
+		if err == nil {
+			t.Errorf("want an error parsing host profiles %q", data)
+		}
+	}
+}
+
+func TestLoadHostProfile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(testHostProfiles), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = prme.LoadHostProfile(configFile, "missing")
+	if !errors.Is(err, prme.ErrHostProfileNotFound) {
+		t.Fatalf("want ErrHostProfileNotFound, got %v", err)
+	}
+	p, err := prme.LoadHostProfile(configFile, "enterprise")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PRME_TEST_GHE_TOKEN", "ghe-token")
+	token, err := p.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ghe-token" {
+		t.Fatalf("want the token from the token_env of the profile, got %q", token)
+	}
+	p.TokenEnv, p.TokenCommand = "", "echo command-token"
+	token, err = p.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "command-token" {
+		t.Fatalf("want the token displayed by the token_command of the profile, got %q", token)
+	}
+}
+
+func TestCloneURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		options     []prme.ClientOption
+		want        string
+	}{
+		{
+			description: "github.com",
+			want:        "ssh://git@github.com/ivanfetch/ghapitest",
+		},
+		{
+			description: "enterprise server",
+			options:     []prme.ClientOption{prme.WithBaseURL("https://github.example.com:8443/api/v3/")},
+			want:        "ssh://git@github.example.com/ivanfetch/ghapitest",
+		},
+		{
+			description: "enterprise server over HTTPS",
+			options: []prme.ClientOption{
+				prme.WithBaseURL("https://github.example.com:8443/api/v3"),
+				prme.WithGitProtocol(prme.GitProtocolHTTPS),
+			},
+			want: "https://github.example.com:8443/ivanfetch/ghapitest.git",
+		},
+		{
+			description: "separate git host",
+			options: []prme.ClientOption{
+				prme.WithBaseURL("https://github.example.com/api/v3"),
+				prme.WithGitHost("git.example.com:2222"),
+			},
+			want: "ssh://git@git.example.com:2222/ivanfetch/ghapitest",
+		},
+	}
+	for _, tc := range testCases {
+		r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", tc.options...)
+		if err != nil {
+			t.Fatalf("for test-case %s, %v", tc.description, err)
+		}
+		got := r.CloneURL()
+		if got != tc.want {
+			t.Errorf("for test-case %s, want clone URL %q, got %q", tc.description, tc.want, got)
+		}
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/api/v3/repos/ivanfetch/ghapitest"
+		if r.RequestURI != wantRequestURL {
+			t.Errorf("want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		fmt.Fprint(w, `{"full_name": "ivanfetch/ghapitest"}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithBaseURL(ts.URL+"/api/v3/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("want the repository to exist")
+	}
+	for _, baseURL := range []string{"github.example.com/api/v3", "ftp://github.example.com", "https://github.example.com/api/v3?x=1"} {
+		_, err = prme.NewClient("dummyToken", prme.WithBaseURL(baseURL))
+		if err == nil {
+			t.Errorf("want an error for the base URL %q", baseURL)
+		}
+	}
+}
+
+func TestWithGitProtocolInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.NewClient("dummyToken", prme.WithGitProtocol("ftp"))
+	if err == nil {
+		t.Fatal("want an error for an unsupported git protocol")
+	}
+}
+
+func TestSubcommandsUseHostProfile(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("PRME_HOST", "")
+	t.Setenv("PRME_CONFIG", "")
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.Header.Get("Authorization")+" "+r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(fmt.Sprintf("hosts:\n  ghes:\n    api_url: %s/api/v3\n    token_command: echo ghes-token\n", ts.URL)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"gc", "-yes", "myorg/myrepo"},
+		{"export", "https://github.example.com/myorg/myrepo/pull/7"},
+		{"report", "https://github.example.com/myorg/myrepo/pull/7"},
+	} {
+		mu.Lock()
+		requested = nil
+		mu.Unlock()
+		args = append([]string{args[0], "-host", "ghes", "-config", configFile}, args[1:]...)
+		prme.RunCLI(args, io.Discard, io.Discard)
+		mu.Lock()
+		if len(requested) == 0 || !strings.HasPrefix(requested[0], "token ghes-token /api/v3/repos/myorg/myrepo") {
+			t.Errorf("want prme %s to request the API of the host profile using its token, got requests %q", args[0], requested)
+		}
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,75 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBranchProtected is returned when a branch protection rule or ruleset
+// rejects updating a branch created by prme, such as one applied by
+// automation of the organization after prme created the branch.
+var ErrBranchProtected = errors.New("a branch protection rule or ruleset rejected updating the branch")
+
+// Is allows errors.Is to match a PushRejectedError caused by a branch
+// protection rule or ruleset with ErrBranchProtected.
+func (e *PushRejectedError) Is(target error) bool {
+	return target == ErrBranchProtected && e.RuleKind != ""
+}
+
+// isProtectionMessage returns true if the message of a Github API error
+// describes a branch protection rule or ruleset.
+func isProtectionMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "protected branch") || strings.Contains(message, "rule")
+}
+
+// WithProtectedBranchRetry retries creating the review using alternate
+// branch names, when a branch protection rule or ruleset rejects updating
+// the base or head branch after they were created. The branches that were
+// created are deleted first, so a review is not left half-finished.
+func WithProtectedBranchRetry() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.RetryProtectedBranches = true
+		return nil
+	}
+}
+
+// alternateBranchName returns the name used for branch when retrying after a
+// branch protection rule rejected it, which includes the abbreviated commit
+// of the full repository branch so it is unlikely to match the same rule.
+func alternateBranchName(branch, fullRepoSha string) string {
+	if len(fullRepoSha) > 7 {
+		fullRepoSha = fullRepoSha[:7]
+	}
+	return branch + "-" + fullRepoSha
+}
+
+// retryProtectedBranches deletes the base and head branches after
+// protectedErr rejected updating them, then creates them again with
+// alternate names, returning the merge commit like createBranches.
+func (f *FullPullRequestCreator) retryProtectedBranches(r *Repo, fullRepoSha string, protectedErr error) (mergeSha string, err error) {
+	err = r.DeleteReviewBranches(f.BaseBranch, f.HeadBranch)
+	if err != nil {
+		return "", fmt.Errorf("%w, and deleting the branches that were created failed: %v", protectedErr, err)
+	}
+	f.BaseBranch = alternateBranchName(f.BaseBranch, fullRepoSha)
+	f.HeadBranch = alternateBranchName(f.HeadBranch, fullRepoSha)
+	err = r.CheckBranchRules(f.BaseBranch, emptyTreeCommitMessage)
+	if err == nil {
+		err = r.CheckBranchRules(f.HeadBranch, emptyTreeCommitMessage, f.mergeCommitMessage())
+	}
+	if err == nil {
+		mergeSha, err = f.createBranches(r, fullRepoSha)
+	}
+	if errors.Is(err, ErrBranchProtected) {
+		deleteErr := r.DeleteReviewBranches(f.BaseBranch, f.HeadBranch)
+		if deleteErr != nil {
+			err = fmt.Errorf("%w, and deleting the branches that were created failed: %v", err, deleteErr)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("while retrying with branches %q and %q after %v: %w", f.BaseBranch, f.HeadBranch, protectedErr, err)
+	}
+	return mergeSha, nil
+}
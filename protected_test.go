@@ -0,0 +1,43 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestMergeBranchProtected(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodPost, "/repos/ivanfetch/ghapitest/merges", prme.FakeResponse{
+		StatusCode: http.StatusConflict,
+		Body:       `{"message": "Repository rule violations found\n\nCannot update this protected ref.\n\n"}`,
+	})
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.MergeBranch("prme-full-content", "main", "Merge main into prme-full-content for full review")
+	if !errors.Is(err, prme.ErrBranchProtected) {
+		t.Fatalf("want ErrBranchProtected, got %v", err)
+	}
+}
+
+func TestPushRejectedErrorIsBranchProtected(t *testing.T) {
+	t.Parallel()
+
+	ruleErr := &prme.PushRejectedError{Repo: "ivanfetch/ghapitest", RuleKind: prme.RuleKindBranchProtection}
+	if !errors.Is(ruleErr, prme.ErrBranchProtected) {
+		t.Fatal("want a push rejected by a branch protection rule to be ErrBranchProtected")
+	}
+	permissionErr := &prme.PushRejectedError{Repo: "ivanfetch/ghapitest"}
+	if errors.Is(permissionErr, prme.ErrBranchProtected) {
+		t.Fatal("want a push rejected without a rule not to be ErrBranchProtected")
+	}
+}
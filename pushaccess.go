@@ -0,0 +1,113 @@
+package prme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WithReviewerPushAccessCheck warns about requested reviewers who cannot
+// push to the repository, and so cannot push review fixes to the head
+// branch, before the pull request is created.
+func WithReviewerPushAccessCheck() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.CheckReviewerPushAccess = true
+		return nil
+	}
+}
+
+// CollaboratorPermission returns the permission of the user login to the
+// repository: admin, write, read, or none.
+func (r Repo) CollaboratorPermission(login string) (string, error) {
+	apiURI := fmt.Sprintf("/repos/%s/collaborators/%s/permission", r, login)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// Github returns 404 for users who do not exist.
+	if resp.StatusCode == http.StatusNotFound {
+		return "none", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d for %s while getting the permission of %q to repository %q", resp.StatusCode, apiURI, login, r)
+	}
+	var permissionAPIResp struct{ Permission string }
+	err = json.NewDecoder(resp.Body).Decode(&permissionAPIResp)
+	if err != nil {
+		return "", err
+	}
+	return permissionAPIResp.Permission, nil
+}
+
+// teamPermissions returns the permission of each team with access to the
+// repository, such as push or admin, by team slug.
+func (r Repo) teamPermissions() (map[string]string, error) {
+	permissions := make(map[string]string)
+	apiURI := fmt.Sprintf("/repos/%s/teams", r)
+	err := r.Client.getAllPages(apiURI, func(body io.Reader) error {
+		var page []struct{ Slug, Permission string }
+		err := json.NewDecoder(body).Decode(&page)
+		if err != nil {
+			return err
+		}
+		for _, team := range page {
+			permissions[team.Slug] = team.Permission
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while listing teams of repository %q: %w", r, err)
+	}
+	return permissions, nil
+}
+
+// ReviewersWithoutPushAccess returns the reviewers, user logins or teams as
+// organization/team, who cannot push to the repository.
+func (r Repo) ReviewersWithoutPushAccess(reviewers []string) ([]string, error) {
+	var without []string
+	var teams map[string]string
+	for _, reviewer := range reviewers {
+		if i := strings.Index(reviewer, "/"); i >= 0 {
+			if teams == nil {
+				var err error
+				teams, err = r.teamPermissions()
+				if err != nil {
+					return nil, err
+				}
+			}
+			switch teams[reviewer[i+1:]] {
+			case "push", "maintain", "admin":
+			default:
+				without = append(without, reviewer)
+			}
+			continue
+		}
+		permission, err := r.CollaboratorPermission(reviewer)
+		if err != nil {
+			return nil, err
+		}
+		if permission != "admin" && permission != "write" {
+			without = append(without, reviewer)
+		}
+	}
+	return without, nil
+}
+
+// checkReviewerPushAccess warns about reviewers who cannot push review fixes
+// to the head branch, if requested by CheckReviewerPushAccess.
+func (f FullPullRequestCreator) checkReviewerPushAccess(r *Repo) error {
+	if !f.CheckReviewerPushAccess || len(f.Reviewers) == 0 {
+		return nil
+	}
+	without, err := r.ReviewersWithoutPushAccess(f.Reviewers)
+	if err != nil {
+		return err
+	}
+	if len(without) > 0 {
+		f.warn("these reviewers cannot push review fixes to the head branch %q of repository %s: %s", f.HeadBranch, r, strings.Join(without, ", "))
+	}
+	return nil
+}
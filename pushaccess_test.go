@@ -0,0 +1,38 @@
+package prme_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestReviewersWithoutPushAccess(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/collaborators/writer/permission", `{"permission": "write"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/collaborators/admin/permission", `{"permission": "admin"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/collaborators/reader/permission", `{"permission": "read"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/teams", `[
+		{"slug": "maintainers", "permission": "maintain"},
+		{"slug": "auditors", "permission": "pull"}
+	]`)
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The user ghost does not exist, and the team owner/other does not have
+	// access to the repository.
+	got, err := r.ReviewersWithoutPushAccess([]string{"writer", "reader", "owner/maintainers", "admin", "ghost", "owner/auditors", "owner/other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"reader", "ghost", "owner/auditors", "owner/other"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect reviewers without push access\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,153 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitCommandError is returned when a git command fails, including its
+// output, from which credentials have been redacted.
+type GitCommandError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *GitCommandError) Error() string {
+	return fmt.Sprintf("command %q returned error %v and output: %s", e.Command, e.Err, e.Output)
+}
+
+func (e *GitCommandError) Unwrap() error {
+	return e.Err
+}
+
+// Kinds of rules that reject pushes, as reported by PushRejectedError.
+const (
+	RuleKindBranchProtection = "branch protection rule"
+	RuleKindRuleset          = "repository ruleset"
+)
+
+// PushRejectedError is returned when Github rejects pushing branches, such
+// as due to a branch protection rule or repository ruleset.
+type PushRejectedError struct {
+	Repo string
+	// Branches are those whose update was rejected, if Github specified
+	// them.
+	Branches []string
+	// RuleKind is RuleKindBranchProtection or RuleKindRuleset, or empty if
+	// Github did not specify the kind of rule.
+	RuleKind string
+	// Reasons are the explanations given by Github.
+	Reasons []string
+	// Advice describes what is needed to allow the push.
+	Advice string
+}
+
+func (e *PushRejectedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Github rejected pushing")
+	if len(e.Branches) > 0 {
+		fmt.Fprintf(&b, " %s", strings.Join(e.Branches, ", "))
+	}
+	fmt.Fprintf(&b, " to repository %q", e.Repo)
+	if e.RuleKind != "" {
+		fmt.Fprintf(&b, " due to a %s", e.RuleKind)
+	}
+	if len(e.Reasons) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.TrimSuffix(strings.Join(e.Reasons, " "), "."))
+	}
+	if e.Advice != "" {
+		fmt.Fprintf(&b, ". %s", e.Advice)
+	}
+	return b.String()
+}
+
+var (
+	// pushRuleViolationPattern matches the Github error naming the rejected
+	// branch, where GH006 is a branch protection rule and GH013 a
+	// ruleset.
+	pushRuleViolationPattern = regexp.MustCompile(`(?m)^remote: error: (GH006|GH013): .* for refs/heads/(\S+?)\.?\s*$`)
+	// pushReasonPattern matches the explanations that follow, as errors of
+	// branch protection rules or list items of rulesets.
+	pushReasonPattern = regexp.MustCompile(`(?m)^remote: (?:error: |- )(.+?)\s*$`)
+	// pushPermissionPattern matches Github denying any push to the
+	// repository.
+	pushPermissionPattern = regexp.MustCompile(`(?m)Permission to \S+ denied to (\S+?)\.?\s*$`)
+)
+
+// pushAdvice describes what allows pushes rejected for reasons containing
+// each phrase.
+var pushAdvice = []struct{ phrase, advice string }{
+	{"verified signatures", "The rule requires signed commits, and the empty-tree commit created by prme is not signed. Exclude branches beginning with the prme branch prefix from the rule, or use -branch-prefix to create branches it does not apply to"},
+	{"not authorized to push", "The rule restricts who can push to matching branches. Add the Github user of the token to those allowed to push, which requires the admin permission for the repository, or use -branch-prefix to create branches the rule does not apply to"},
+	{"creations being restricted", "The ruleset restricts creating matching branches. Add the Github user of the token to the bypass list of the ruleset, which requires the admin permission for the repository, or use -branch-prefix to create branches the ruleset does not apply to"},
+	{"through a pull request", "The rule requires changes to be made through a pull request, which prme cannot do for its base and head branches. Use -branch-prefix to create branches the rule does not apply to"},
+	{"status check", "The rule requires status checks to pass before updating matching branches. Use -branch-prefix to create branches the rule does not apply to"},
+	{"Cannot update this protected ref", "The ruleset prevents updating matching branches. Add the Github user of the token to the bypass list of the ruleset, or use -branch-prefix to create branches the ruleset does not apply to"},
+}
+
+// ParsePushRejection returns the PushRejectedError described by the output
+// of a git push to repo, or nil if the output does not describe Github
+// rejecting the push.
+func ParsePushRejection(repo, gitOutput string) *PushRejectedError {
+	if m := pushPermissionPattern.FindStringSubmatch(gitOutput); m != nil {
+		return &PushRejectedError{
+			Repo:    repo,
+			Reasons: []string{strings.TrimSuffix(strings.TrimSpace(m[0]), ".") + "."},
+			Advice:  fmt.Sprintf("The Github user %s needs the write permission for the repository", m[1]),
+		}
+	}
+	violations := pushRuleViolationPattern.FindAllStringSubmatch(gitOutput, -1)
+	if violations == nil {
+		return nil
+	}
+	e := &PushRejectedError{Repo: repo}
+	switch violations[0][1] {
+	case "GH006":
+		e.RuleKind = RuleKindBranchProtection
+	case "GH013":
+		e.RuleKind = RuleKindRuleset
+	}
+	for _, v := range violations {
+		if !stringsContain(e.Branches, v[2]) {
+			e.Branches = append(e.Branches, v[2])
+		}
+	}
+	for _, m := range pushReasonPattern.FindAllStringSubmatch(gitOutput, -1) {
+		reason := m[1]
+		if pushRuleViolationPattern.MatchString(m[0]) || strings.HasPrefix(reason, "Visit ") || strings.HasPrefix(reason, "Review all repository rules") || stringsContain(e.Reasons, reason) {
+			continue
+		}
+		e.Reasons = append(e.Reasons, reason)
+		for _, pa := range pushAdvice {
+			if e.Advice == "" && strings.Contains(reason, pa.phrase) {
+				e.Advice = pa.advice
+			}
+		}
+	}
+	return e
+}
+
+// explainPushError returns a PushRejectedError if err is a failed git push
+// that Github rejected, otherwise err.
+func explainPushError(repo string, err error) error {
+	var gitErr *GitCommandError
+	if !errors.As(err, &gitErr) {
+		return err
+	}
+	if rejected := ParsePushRejection(repo, gitErr.Output); rejected != nil {
+		return rejected
+	}
+	return err
+}
+
+func stringsContain(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParsePushRejection(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		output      string
+		want        *prme.PushRejectedError
+		// wantAdvice is a phrase the advice should contain.
+		wantAdvice string
+	}{
+		{
+			description: "branch protection requiring signed commits",
+			output: `remote: error: GH006: Protected branch update failed for refs/heads/prme-full-review.
+remote: error: Commits must have verified signatures.
+To github.com:ivanfetch/ghapitest.git
+ ! [remote rejected] prme-full-review -> prme-full-review (protected branch hook declined)
+error: failed to push some refs to 'github.com:ivanfetch/ghapitest.git'`,
+			want: &prme.PushRejectedError{
+				Repo:     "ivanfetch/ghapitest",
+				Branches: []string{"prme-full-review"},
+				RuleKind: prme.RuleKindBranchProtection,
+				Reasons:  []string{"Commits must have verified signatures."},
+			},
+			wantAdvice: "signed commits",
+		},
+		{
+			description: "branch protection restricting pushes",
+			output: `remote: error: GH006: Protected branch update failed for refs/heads/prme-full-content.
+remote: error: You're not authorized to push to this branch. Visit https://docs.github.com/articles/about-protected-branches/ for more information.
+To github.com:ivanfetch/ghapitest.git
+ ! [remote rejected] prme-full-content -> prme-full-content (protected branch hook declined)`,
+			want: &prme.PushRejectedError{
+				Repo:     "ivanfetch/ghapitest",
+				Branches: []string{"prme-full-content"},
+				RuleKind: prme.RuleKindBranchProtection,
+				Reasons:  []string{"You're not authorized to push to this branch. Visit https://docs.github.com/articles/about-protected-branches/ for more information."},
+			},
+			wantAdvice: "allowed to push",
+		},
+		{
+			description: "ruleset restricting creations of both branches",
+			output: `remote: error: GH013: Repository rule violations found for refs/heads/prme-full-review.
+remote: Review all repository rules at http://github.com/ivanfetch/ghapitest/rules?ref=refs%2Fheads%2Fprme-full-review
+remote:
+remote: - Cannot create ref due to creations being restricted.
+remote:
+remote: error: GH013: Repository rule violations found for refs/heads/prme-full-content.
+remote: - Cannot create ref due to creations being restricted.
+To github.com:ivanfetch/ghapitest.git
+ ! [remote rejected] prme-full-review -> prme-full-review (push declined due to repository rule violations)
+ ! [remote rejected] prme-full-content -> prme-full-content (push declined due to repository rule violations)`,
+			want: &prme.PushRejectedError{
+				Repo:     "ivanfetch/ghapitest",
+				Branches: []string{"prme-full-review", "prme-full-content"},
+				RuleKind: prme.RuleKindRuleset,
+				Reasons:  []string{"Cannot create ref due to creations being restricted."},
+			},
+			wantAdvice: "bypass list",
+		},
+		{
+			description: "no write permission",
+			output: `ERROR: Permission to ivanfetch/ghapitest.git denied to prme-bot.
+fatal: Could not read from remote repository.`,
+			want: &prme.PushRejectedError{
+				Repo:    "ivanfetch/ghapitest",
+				Reasons: []string{"Permission to ivanfetch/ghapitest.git denied to prme-bot."},
+			},
+			wantAdvice: "prme-bot needs the write permission",
+		},
+		{
+			description: "network failure",
+			output:      `ssh: connect to host github.com port 22: Connection timed out`,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			got := prme.ParsePushRejection("ivanfetch/ghapitest", tc.output)
+			if !cmp.Equal(tc.want, got, cmpopts.IgnoreFields(prme.PushRejectedError{}, "Advice")) {
+				t.Fatalf("got incorrect push rejection\ndiff reflects want vs. got: %s", cmp.Diff(tc.want, got))
+			}
+			if got != nil && !strings.Contains(got.Advice, tc.wantAdvice) {
+				t.Errorf("want advice containing %q, got %q", tc.wantAdvice, got.Advice)
+			}
+		})
+	}
+}
+
+func TestPushRejectedErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	err := prme.ParsePushRejection("ivanfetch/ghapitest", `remote: error: GH006: Protected branch update failed for refs/heads/prme-full-review.
+remote: error: Commits must have verified signatures.`)
+	want := `Github rejected pushing prme-full-review to repository "ivanfetch/ghapitest" due to a branch protection rule: Commits must have verified signatures. The rule requires signed commits, and the empty-tree commit created by prme is not signed. Exclude branches beginning with the prme branch prefix from the rule, or use -branch-prefix to create branches it does not apply to`
+	if err.Error() != want {
+		t.Fatalf("got incorrect error message\ndiff reflects want vs. got: %s", cmp.Diff(want, err.Error()))
+	}
+}
@@ -0,0 +1,82 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RateLimitOverride changes how the client handles the rate limits of an API
+// host, such as a Github Enterprise Server instance on which rate limiting is
+// disabled, so requests are not throttled unnecessarily.
+type RateLimitOverride struct {
+	// Disabled ignores the rate limit of the host, so the client never
+	// waits for it to reset. The secondary rate limit is still respected.
+	Disabled bool
+	// MaxWait is the longest to wait for the rate limit of the host to
+	// reset, before returning a RateLimitError. Zero uses the default of two
+	// minutes.
+	MaxWait time.Duration
+}
+
+// WithRateLimitOverride sets how the client handles the rate limits of the
+// API host, such as github.example.com. The override is ignored when the
+// client uses a different API host.
+func WithRateLimitOverride(host string, override RateLimitOverride) ClientOption {
+	return func(c *Client) error {
+		if host == "" {
+			return errors.New("the host of a rate limit override cannot be empty")
+		}
+		if override.MaxWait < 0 {
+			return fmt.Errorf("the maximum wait for the rate limit of %s cannot be negative", host)
+		}
+		if c.rateLimitOverrides == nil {
+			c.rateLimitOverrides = make(map[string]RateLimitOverride)
+		}
+		c.rateLimitOverrides[strings.ToLower(host)] = override
+		return nil
+	}
+}
+
+// ParseRateLimitOverride parses a rate limit override of the form
+// host=disabled, or host=<duration>, such as github.example.com=10m, which
+// sets the maximum wait for the rate limit of the host to reset.
+func ParseRateLimitOverride(s string) (host string, override RateLimitOverride, err error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", RateLimitOverride{}, fmt.Errorf("invalid rate limit override %q, specify host=disabled or host=<duration>, such as github.example.com=10m", s)
+	}
+	host = parts[0]
+	if strings.EqualFold(parts[1], "disabled") {
+		return host, RateLimitOverride{Disabled: true}, nil
+	}
+	maxWait, err := time.ParseDuration(parts[1])
+	if err != nil || maxWait < 0 {
+		return "", RateLimitOverride{}, fmt.Errorf("invalid rate limit override %q, the maximum wait must be disabled or a duration such as 10m", s)
+	}
+	return host, RateLimitOverride{MaxWait: maxWait}, nil
+}
+
+// rateLimitOverride returns the RateLimitOverride of the API host of the
+// client, or the zero value if it has none.
+func (c *Client) rateLimitOverride() RateLimitOverride {
+	if len(c.rateLimitOverrides) == 0 {
+		return RateLimitOverride{}
+	}
+	u, err := url.Parse(c.apiHost)
+	if err != nil {
+		return RateLimitOverride{}
+	}
+	return c.rateLimitOverrides[strings.ToLower(u.Hostname())]
+}
+
+// maxRateLimitWait returns the longest MakeAPIRequestWithRateLimit waits
+// for the rate limit of the API host to reset.
+func (c *Client) maxRateLimitWait() time.Duration {
+	if maxWait := c.rateLimitOverride().MaxWait; maxWait > 0 {
+		return maxWait
+	}
+	return defaultMaxRateLimitWait
+}
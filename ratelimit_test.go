@@ -0,0 +1,133 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// rateLimitExceeded is a response of a primary rate limit that resets five
+// minutes after now.
+func rateLimitExceeded(now time.Time) prme.FakeResponse {
+	return prme.FakeResponse{
+		StatusCode: http.StatusForbidden,
+		Header: http.Header{
+			"X-Ratelimit-Limit":     []string{"5000"},
+			"X-Ratelimit-Remaining": []string{"0"},
+			"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(5*time.Minute).Unix(), 10)},
+		},
+		Body: `{"message": "API rate limit exceeded"}`,
+	}
+}
+
+func TestRateLimitOverrideMaxWait(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	clock := prme.NewManualClock(start)
+	fc, err := prme.NewFakeClient(prme.WithClientClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", rateLimitExceeded(start))
+	_, err = fc.MakeAPIRequestWithRateLimit(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	var rateLimitErr *prme.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("want a RateLimitError without an override, got %v", err)
+	}
+
+	fc, err = prme.NewFakeClient(prme.WithClientClock(clock), prme.WithRateLimitOverride("api.github.com", prme.RateLimitOverride{MaxWait: 10 * time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", rateLimitExceeded(start))
+	resp, err := fc.MakeAPIRequestWithRateLimit(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	want := start.Add(5 * time.Minute)
+	if !clock.Now().Equal(want) {
+		t.Fatalf("want the clock advanced to the rate limit reset at %v, got %v", want, clock.Now())
+	}
+}
+
+func TestRateLimitOverrideDisabled(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	clock := prme.NewManualClock(start)
+	fc, err := prme.NewFakeClient(prme.WithClientClock(clock), prme.WithRateLimitOverride("API.github.com", prme.RateLimitOverride{Disabled: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", rateLimitExceeded(start))
+	resp, err := fc.MakeAPIRequestWithRateLimit(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(fc.Requests()) != 1 || !clock.Now().Equal(start) {
+		t.Fatalf("want no wait or retry when the rate limit is disabled, got %d requests and the clock at %v", len(fc.Requests()), clock.Now())
+	}
+	if fc.RateLimit() != (prme.RateLimit{}) {
+		t.Fatalf("want no rate limit recorded when it is disabled, got %+v", fc.RateLimit())
+	}
+}
+
+func TestRateLimitMissingHeaders(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Github Enterprise Server with rate limiting disabled omits the rate
+	// limit headers.
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", `{"full_name": "ivanfetch/ghapitest"}`)
+	resp, err := fc.MakeAPIRequestWithRateLimit(http.MethodGet, "/repos/ivanfetch/ghapitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if fc.RateLimit() != (prme.RateLimit{}) {
+		t.Fatalf("want the zero rate limit without headers, got %+v", fc.RateLimit())
+	}
+}
+
+func TestParseRateLimitOverride(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		wantHost string
+		want     prme.RateLimitOverride
+		wantErr  bool
+	}{
+		{input: "github.example.com=disabled", wantHost: "github.example.com", want: prme.RateLimitOverride{Disabled: true}},
+		{input: "github.example.com=10m", wantHost: "github.example.com", want: prme.RateLimitOverride{MaxWait: 10 * time.Minute}},
+		{input: "github.example.com", wantErr: true},
+		{input: "=10m", wantErr: true},
+		{input: "github.example.com=forever", wantErr: true},
+		{input: "github.example.com=-1m", wantErr: true},
+	}
+	for _, tc := range testCases {
+		host, got, err := prme.ParseRateLimitOverride(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("want an error parsing %q", tc.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsing %q: %v", tc.input, err)
+			continue
+		}
+		if host != tc.wantHost || got != tc.want {
+			t.Errorf("parsing %q, want %s %+v, got %s %+v", tc.input, tc.wantHost, tc.want, host, got)
+		}
+	}
+}
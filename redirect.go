@@ -0,0 +1,56 @@
+package prme
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// isRedirect returns true if status is an HTTP redirect, which the Github API
+// returns for repositories that were renamed or transferred.
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// relativeURI returns rawURL, such as from a Location or Link header,
+// relative to the API host of the client.
+func (c *Client) relativeURI(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(u.RequestURI(), strings.TrimSuffix(c.apiBasePath(), "/")), nil
+}
+
+// getRepoFollowingRedirect makes a GET API request for the repository,
+// following a redirect to the canonical repository if the HTTP client did
+// not already, such as when middleware handles requests. The returned bool
+// is true if the request was redirected.
+func (r Repo) getRepoFollowingRedirect() (*http.Response, bool, error) {
+	apiURI := "/repos/" + r.String()
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, false, err
+	}
+	if isRedirect(resp.StatusCode) {
+		location := resp.Header.Get("Location")
+		resp.Body.Close()
+		redirectURI, err := r.Client.relativeURI(location)
+		if err != nil {
+			return nil, false, err
+		}
+		resp, err = r.Client.MakeAPIRequest(http.MethodGet, redirectURI)
+		if err != nil {
+			return nil, false, err
+		}
+		return resp, true, nil
+	}
+	// The HTTP client follows redirects, leaving the final request in the
+	// response.
+	redirected := resp.Request != nil && resp.Request.URL != nil && !strings.HasSuffix(resp.Request.URL.Path, apiURI)
+	return resp, redirected, nil
+}
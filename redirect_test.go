@@ -0,0 +1,70 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRepoExistsFollowsRedirect(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetResponse(http.MethodGet, "/repos/ivanfetch/ghapitest", prme.FakeResponse{
+		StatusCode: http.StatusMovedPermanently,
+		Header:     http.Header{"Location": []string{"https://api.github.com/repositories/395712561"}},
+		Body:       `{"message": "Moved Permanently", "url": "https://api.github.com/repositories/395712561"}`,
+	})
+	fc.SetJSONResponse(http.MethodGet, "/repositories/395712561", `{"id": 395712561, "full_name": "myorg/ghapitest-fork"}`)
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("want the redirected repository to exist")
+	}
+	if r.String() != "myorg/ghapitest-fork" {
+		t.Fatalf("want the repository to be updated to myorg/ghapitest-fork, got %q", r)
+	}
+}
+
+func TestRepoExistsFollowedByHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/ivanfetch/old-name":
+			http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+		case "/repositories/42":
+			w.Write([]byte(`{"id": 42, "full_name": "ivanfetch/new-name"}`))
+		default:
+			t.Errorf("unexpected request for %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/old-name", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || r.String() != "ivanfetch/new-name" {
+		t.Fatalf("want the renamed repository ivanfetch/new-name to exist, got %q and exists %v", r, ok)
+	}
+}
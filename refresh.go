@@ -0,0 +1,190 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Comparison is the difference between two commits, from the perspective
+// of the base commit.
+type Comparison struct {
+	// Status is one of ahead, behind, diverged, or identical.
+	Status string `json:"status"`
+	// AheadBy is the number of commits in head that are not in base.
+	AheadBy  int `json:"ahead_by"`
+	BehindBy int `json:"behind_by"`
+	// Commits are those in head that are not in base, oldest first. Github
+	// returns at most 250 commits.
+	Commits []struct {
+		Sha    string `json:"sha"`
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	} `json:"commits"`
+}
+
+// CompareCommits returns the comparison of head to base, which can be
+// branch names or commit shas.
+func (r Repo) CompareCommits(base, head string) (*Comparison, error) {
+	apiURI := fmt.Sprintf("/repos/%s/compare/%s...%s", r, url.PathEscape(base), url.PathEscape(head))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while comparing %q to %q in repository %q", resp.StatusCode, apiURI, head, base, r)
+	}
+	var c Comparison
+	err = json.NewDecoder(resp.Body).Decode(&c)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Staleness is how far the full repository branch has advanced past the
+// content of a review.
+type Staleness struct {
+	// CommitsBehind is the number of commits in the full repository branch
+	// that are not in the review, and Since is the date of the oldest of
+	// those commits. Since is zero if the review is up to date.
+	CommitsBehind int
+	Since         time.Time
+}
+
+// ReviewStaleness returns how far fullRepoBranch has advanced past the
+// review headBranch.
+func (r Repo) ReviewStaleness(headBranch, fullRepoBranch string) (*Staleness, error) {
+	c, err := r.CompareCommits(headBranch, fullRepoBranch)
+	if err != nil {
+		return nil, err
+	}
+	s := &Staleness{CommitsBehind: c.AheadBy}
+	if len(c.Commits) > 0 {
+		s.Since = c.Commits[0].Commit.Committer.Date
+	}
+	return s, nil
+}
+
+// StalenessPolicy determines when a review is stale. Zero fields are not
+// considered.
+type StalenessPolicy struct {
+	// MaxCommits is the number of commits the full repository branch can
+	// advance past the review before it is stale.
+	MaxCommits int
+	// MaxAge is how long the oldest commit that is not in the review can be
+	// before the review is stale.
+	MaxAge time.Duration
+}
+
+// IsStale returns whether s is stale as of now, according to the policy.
+func (p StalenessPolicy) IsStale(s Staleness, now time.Time) bool {
+	if s.CommitsBehind == 0 {
+		return false
+	}
+	if p.MaxCommits > 0 && s.CommitsBehind >= p.MaxCommits {
+		return true
+	}
+	return p.MaxAge > 0 && now.Sub(s.Since) >= p.MaxAge
+}
+
+// RefreshReview merges fullRepoBranch into the review headBranch, so the
+// review includes changes made since it was created. ErrAlreadyMerged is
+// returned if the review is already up to date, and ErrMergeConflict if
+// the changes conflict with the review, which HandleRefreshConflict helps
+// resolve.
+func (r Repo) RefreshReview(headBranch, fullRepoBranch string) (mergeSha string, err error) {
+	return r.MergeBranch(headBranch, fullRepoBranch, fmt.Sprintf("Refresh the full review with changes from %s", fullRepoBranch))
+}
+
+// ErrRefreshFiltered is returned when refreshing a review whose content was
+// filtered, such as using -path, because merging the full repository branch
+// would add the unfiltered repository to it.
+var ErrRefreshFiltered = errors.New("reviews of filtered content cannot be refreshed, recreate the review using -force-delete instead")
+
+// refreshRecordedReview refreshes a review recorded in the state store, as
+// RefreshReview does, returning ErrRefreshFiltered if its content was
+// filtered.
+func (r Repo) refreshRecordedReview(review ReviewRecord) error {
+	if review.Filtered {
+		return fmt.Errorf("%w: %s", ErrRefreshFiltered, review.URL)
+	}
+	_, err := r.RefreshReview(review.HeadBranch, review.FullRepoBranch)
+	return err
+}
+
+// runRefreshCommand merges changes from the full repository branch into the
+// most recent open review of a repository.
+func runRefreshCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme refresh", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand merges changes made to the full repository branch since a review was created into its head branch, so the review does not go stale.
+
+Usage: %s [flags] <repository owner>/<repository name>
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which prme records created pull requests. This is also set via the PRME_STATE_FILE environment variable.")
+	CLIOwner := addOwnerFlag(fs)
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one repository, in the form OwnerName/RepositoryName")
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	r, err := NewRepo(qualifyRepoName(fs.Arg(0), *CLIOwner), token, clientOptions...)
+	if err != nil {
+		return err
+	}
+	store, err := NewStateStore(*CLIStateFile)
+	if err != nil {
+		return err
+	}
+	review, err := store.latestOpenReview(r.String())
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return fmt.Errorf("%w: %s", ErrNoOpenReview, r)
+	}
+	err = r.refreshRecordedReview(*review)
+	if errors.Is(err, ErrAlreadyMerged) {
+		fmt.Fprintf(output, "The review %s already includes all changes from %s\n", review.URL, review.FullRepoBranch)
+		return nil
+	}
+	if errors.Is(err, ErrMergeConflict) {
+		c, err := r.HandleRefreshConflict(*review)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "The review %s has merge conflicts with changes from %s, which have been pushed to the branch %s and commented on the pull request, with instructions to resolve them\n", review.URL, review.FullRepoBranch, c.Branch)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "The review %s has been refreshed with changes from %s\n", review.URL, review.FullRepoBranch)
+	return nil
+}
@@ -0,0 +1,95 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReviewStaleness(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/repos/ivanfetch/ghapitest/compare/prme-full-content...main"
+		if wantRequestURL != r.RequestURI {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, r.RequestURI)
+		}
+		fmt.Fprint(w, `{"status": "ahead", "ahead_by": 2, "behind_by": 0, "commits": [
+			{"sha": "1111111111111111111111111111111111111111", "commit": {"committer": {"date": "2021-08-01T10:00:00Z"}}},
+			{"sha": "2222222222222222222222222222222222222222", "commit": {"committer": {"date": "2021-08-10T10:00:00Z"}}}]}`)
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.ReviewStaleness("prme-full-content", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := prme.Staleness{CommitsBehind: 2, Since: time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)}
+	if want != *got {
+		t.Fatalf("want staleness %+v, got %+v", want, *got)
+	}
+}
+
+func TestStalenessPolicyIsStale(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	testCases := []struct {
+		description string
+		policy      prme.StalenessPolicy
+		staleness   prme.Staleness
+		want        bool
+	}{
+		{"up to date", prme.StalenessPolicy{MaxCommits: 1, MaxAge: time.Hour}, prme.Staleness{}, false},
+		{"under both limits", prme.StalenessPolicy{MaxCommits: 10, MaxAge: 30 * 24 * time.Hour}, prme.Staleness{CommitsBehind: 2, Since: now.AddDate(0, 0, -3)}, false},
+		{"too many commits", prme.StalenessPolicy{MaxCommits: 2}, prme.Staleness{CommitsBehind: 2, Since: now}, true},
+		{"too old", prme.StalenessPolicy{MaxAge: 24 * time.Hour}, prme.Staleness{CommitsBehind: 1, Since: now.AddDate(0, 0, -2)}, true},
+		{"no limits", prme.StalenessPolicy{}, prme.Staleness{CommitsBehind: 500, Since: now.AddDate(-1, 0, 0)}, false},
+	}
+	for _, tc := range testCases {
+		got := tc.policy.IsStale(tc.staleness, now)
+		if tc.want != got {
+			t.Errorf("%s: want stale %v, got %v", tc.description, tc.want, got)
+		}
+	}
+}
+
+func TestRefreshRefusesFilteredReviews(t *testing.T) {
+	// Use of t.Setenv() below, prohibits t.Parallel()
+	t.Setenv("GH_TOKEN", "dummyToken")
+	t.Setenv("PRME_OWNER", "")
+	t.Setenv("PRME_STATE_KEY", "")
+	t.Setenv("PRME_STATE_KEYCHAIN", "")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	err := os.WriteFile(stateFile, []byte(`{"reviews":[{"repo":"ivanfetch/ghapitest","number":1,"url":"https://github.com/ivanfetch/ghapitest/pull/1","state":"open","full_repo_branch":"main","base_branch":"prme-base","head_branch":"prme-head","created_at":"2022-01-01T00:00:00Z","filtered":true}]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output, errOutput strings.Builder
+	got := prme.RunCLI([]string{"refresh", "-state-file", stateFile, "ivanfetch/ghapitest"}, &output, &errOutput)
+	if got != 1 {
+		t.Errorf("want exit code 1 refreshing a filtered review, got %d", got)
+	}
+	if !strings.Contains(errOutput.String(), prme.ErrRefreshFiltered.Error()) {
+		t.Errorf("want an error that filtered reviews cannot be refreshed, got %q", errOutput.String())
+	}
+
+	errOutput.Reset()
+	prme.RunCLI([]string{"refresh", "-state-file", stateFile, "IvanFetch/GHAPITest"}, &output, &errOutput)
+	if !strings.Contains(errOutput.String(), prme.ErrRefreshFiltered.Error()) {
+		t.Errorf("want the review found using a repository name of a different case, got %q", errOutput.String())
+	}
+}
@@ -0,0 +1,172 @@
+package prme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ListRequestedReviewers returns the users and teams, as organization/team,
+// whose review is requested on the pull request number. Reviewers are no
+// longer requested once they submit a review.
+func (r Repo) ListRequestedReviewers(number int) ([]string, error) {
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", r, number)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while listing requested reviewers for pull request %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	var requestedAPIResp struct {
+		Users []User
+		Teams []struct{ Slug string }
+	}
+	err = json.NewDecoder(resp.Body).Decode(&requestedAPIResp)
+	if err != nil {
+		return nil, err
+	}
+	owner, _ := r.splitOwnerAndName()
+	var reviewers []string
+	for _, user := range requestedAPIResp.Users {
+		reviewers = append(reviewers, user.Login)
+	}
+	for _, team := range requestedAPIResp.Teams {
+		reviewers = append(reviewers, owner+"/"+team.Slug)
+	}
+	return reviewers, nil
+}
+
+// ReminderComment returns the text reminding reviewers of a review that has
+// had no activity for inactiveDays, or an empty string if there are no
+// reviewers to remind.
+func ReminderComment(reviewers []string, inactiveDays int) string {
+	if len(reviewers) == 0 {
+		return ""
+	}
+	var mentions string
+	for _, reviewer := range reviewers {
+		mentions += "@" + reviewer + " "
+	}
+	return fmt.Sprintf("%sThis full review has had no activity for %d days, please continue reviewing when you are able.", mentions, inactiveDays)
+}
+
+// RemindReviewers comments on the pull request number, mentioning its
+// requested reviewers, if it is open and has had no activity for
+// inactiveDays as of now. The pull request is returned, and whether a
+// reminder was posted. No reminder is posted if no reviewers are requested,
+// such as once all have reviewed. The reminder is itself activity, so
+// reviewers are reminded at most once every inactiveDays.
+func (r Repo) RemindReviewers(number, inactiveDays int, now time.Time) (*PullRequest, bool, error) {
+	PR, err := r.GetPullRequest(number)
+	if err != nil {
+		return nil, false, err
+	}
+	if PR.State != "open" || now.Sub(PR.UpdatedAt) < time.Duration(inactiveDays)*24*time.Hour {
+		return PR, false, nil
+	}
+	reviewers, err := r.ListRequestedReviewers(number)
+	if err != nil {
+		return PR, false, err
+	}
+	comment := ReminderComment(reviewers, inactiveDays)
+	if comment == "" {
+		return PR, false, nil
+	}
+	_, err = r.CreateIssueComment(number, comment)
+	if err != nil {
+		return PR, false, err
+	}
+	return PR, true, nil
+}
+
+// PostSlackMessage posts text to a Slack incoming webhook.
+func PostSlackMessage(webhookURL, text string) error {
+	messageJSON, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{text})
+	if err != nil {
+		return err
+	}
+	hc := &http.Client{Timeout: 10 * time.Second}
+	resp, err := hc.Post(webhookURL, "application/json", bytes.NewReader(messageJSON))
+	if err != nil {
+		// The webhook URL is a secret, which errors from the HTTP client
+		// include.
+		return fmt.Errorf("while posting to Slack: %s", strings.ReplaceAll(err.Error(), webhookURL, redactedText))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d while posting to Slack", resp.StatusCode)
+	}
+	return nil
+}
+
+// remindInactiveReviews reminds the requested reviewers of open reviews in
+// the state store that have had no activity for their RemindAfterDays. If
+// slackWebhookURL is not empty, reminders are also posted to Slack. The
+// states of reviews that are no longer open are updated in the store, and
+// notifiers are notified of merged reviews.
+func (s *reviewServer) remindInactiveReviews(now time.Time) {
+	if s.creator.StateFile == "" {
+		return
+	}
+	store, err := NewStateStore(s.creator.StateFile)
+	if err != nil {
+		s.logger.Printf("while reminding reviewers: %v", err)
+		return
+	}
+	st, err := store.Load()
+	if err != nil {
+		s.logger.Printf("while reminding reviewers: %v", err)
+		return
+	}
+	for _, review := range st.Reviews {
+		if review.State != "open" || (review.RemindAfterDays == 0 && len(s.creator.notifiers) == 0) {
+			continue
+		}
+		r, err := NewRepo(review.Repo, s.creator.token, s.creator.clientOptions...)
+		if err != nil {
+			s.logger.Printf("while reminding reviewers of %s: %v", review.URL, err)
+			continue
+		}
+		var PR *PullRequest
+		var reminded bool
+		if review.RemindAfterDays > 0 {
+			PR, reminded, err = r.RemindReviewers(review.Number, review.RemindAfterDays, now)
+		} else {
+			PR, err = r.GetPullRequest(review.Number)
+		}
+		if err != nil {
+			s.logger.Printf("while reminding reviewers of %s: %v", review.URL, err)
+			continue
+		}
+		if PR.State != "open" {
+			state := PR.State
+			if PR.Merged {
+				state = "merged"
+				s.creator.notify(func(n Notifier) error { return n.PRMerged(review.Repo, PR) })
+			}
+			err = store.setReviewState(review.Repo, review.Number, state)
+			if err != nil {
+				s.logger.Printf("while updating the state of %s: %v", review.URL, err)
+			}
+			continue
+		}
+		if !reminded {
+			continue
+		}
+		s.logger.Printf("reminded reviewers of %s, which has had no activity for %d days", review.URL, review.RemindAfterDays)
+		if s.slackWebhookURL == "" {
+			continue
+		}
+		err = PostSlackMessage(s.slackWebhookURL, fmt.Sprintf("The full review %s of %s has had no activity for %d days.", review.URL, review.Repo, review.RemindAfterDays))
+		if err != nil {
+			s.logger.Printf("while reminding reviewers of %s: %v", review.URL, err)
+		}
+	}
+}
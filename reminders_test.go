@@ -0,0 +1,96 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRemindReviewers(t *testing.T) {
+	t.Parallel()
+
+	var gotComment string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/ivanfetch/ghapitest/pulls/7":
+			fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "state": "open", "updated_at": "2021-08-19T17:47:41Z"}`)
+		case "GET /repos/ivanfetch/ghapitest/pulls/7/requested_reviewers":
+			fmt.Fprint(w, `{"users": [{"login": "octocat"}], "teams": [{"slug": "security-team"}]}`)
+		case "GET /repos/ivanfetch/ghapitest/pulls/8":
+			fmt.Fprint(w, `{"number": 8, "html_url": "https://github.com/ivanfetch/ghapitest/pull/8", "state": "open", "updated_at": "2021-08-19T17:47:41Z"}`)
+		case "GET /repos/ivanfetch/ghapitest/pulls/8/requested_reviewers":
+			fmt.Fprint(w, `{"users": [], "teams": []}`)
+		case "POST /repos/ivanfetch/ghapitest/issues/7/comments":
+			var comment struct{ Body string }
+			err := json.NewDecoder(r.Body).Decode(&comment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotComment = comment.Body
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updatedAt := time.Date(2021, 8, 19, 17, 47, 41, 0, time.UTC)
+	_, reminded, err := r.RemindReviewers(7, 7, updatedAt.Add(6*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminded || gotComment != "" {
+		t.Fatalf("want no reminder before 7 days of inactivity, got comment %q", gotComment)
+	}
+	_, reminded, err = r.RemindReviewers(7, 7, updatedAt.Add(7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := prme.ReminderComment([]string{"octocat", "ivanfetch/security-team"}, 7)
+	if !reminded || want != gotComment {
+		t.Fatalf("want reminder comment %q, got %q", want, gotComment)
+	}
+	gotComment = ""
+	_, reminded, err = r.RemindReviewers(8, 7, updatedAt.Add(7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminded || gotComment != "" {
+		t.Fatalf("want no reminder without requested reviewers, got comment %q", gotComment)
+	}
+}
+
+func TestPostSlackMessage(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message struct{ Text string }
+		err := json.NewDecoder(r.Body).Decode(&message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Text != "A reminder" {
+			t.Errorf("want Slack message %q, got %q", "A reminder", message.Text)
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	err := prme.PostSlackMessage(ts.URL+"/services/T000/B000/XXXX", "A reminder")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
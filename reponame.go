@@ -0,0 +1,65 @@
+package prme
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidRepoName is returned when a repository is not of the form
+// OwnerName/RepositoryName, using the characters Github allows.
+var ErrInvalidRepoName = errors.New("the repository must be of the form OwnerName/RepositoryName")
+
+var (
+	// ownerNamePattern matches Github user and organization names, which
+	// are alphanumeric with single hyphens, not beginning with a hyphen.
+	ownerNamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+	// repoNamePattern matches Github repository names.
+	repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// ValidateRepoName returns ErrInvalidRepoName, describing the problem, if
+// ownerAndName is not a valid repository of the form
+// OwnerName/RepositoryName. The case of the owner and name is not verified,
+// as Github ignores it; Repo.Exists updates the repository to the case of
+// its canonical name.
+func ValidateRepoName(ownerAndName string) error {
+	if strings.IndexFunc(ownerAndName, unicode.IsSpace) != -1 {
+		return fmt.Errorf("%w, without whitespace: %q", ErrInvalidRepoName, ownerAndName)
+	}
+	parts := strings.Split(ownerAndName, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("%w, with exactly one slash: %q", ErrInvalidRepoName, ownerAndName)
+	}
+	owner, name := parts[0], parts[1]
+	if !ownerNamePattern.MatchString(owner) {
+		return fmt.Errorf("%w, the owner %q can only contain alphanumeric characters or hyphens, and cannot begin or end with a hyphen", ErrInvalidRepoName, owner)
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".git") {
+		return fmt.Errorf("%w, without the .git suffix of a clone URL, such as %s/%s", ErrInvalidRepoName, owner, name[:len(name)-len(".git")])
+	}
+	if name == "." || name == ".." || !repoNamePattern.MatchString(name) {
+		return fmt.Errorf("%w, the repository name %q can only contain alphanumeric characters, periods, hyphens, or underscores", ErrInvalidRepoName, name)
+	}
+	return nil
+}
+
+// addOwnerFlag adds the -owner command-line flag to fs, which is the default
+// owner of repositories specified without one.
+func addOwnerFlag(fs *flag.FlagSet) *string {
+	return fs.String("owner", "", "The organization or user owning repositories that are specified by name only, such as myrepo instead of myorg/myrepo. This is also set via the PRME_OWNER environment variable.")
+}
+
+// qualifyRepoName returns the repository specified on the command-line as
+// OwnerName/RepositoryName, removing a github.com/ prefix, and adding owner
+// if only the name of the repository was specified.
+func qualifyRepoName(arg, owner string) string {
+	repoName := strings.TrimPrefix(arg, "github.com/")
+	if owner != "" && !strings.Contains(repoName, "/") {
+		return owner + "/" + repoName
+	}
+	return repoName
+}
@@ -0,0 +1,60 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestValidateRepoName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description, repo string
+		wantErr           bool
+	}{
+		{description: "valid", repo: "ivanfetch/ghapitest"},
+		{description: "periods, hyphens, and underscores", repo: "my-org/.github_config.d"},
+		{description: "no slash", repo: "ghapitest", wantErr: true},
+		{description: "more than one slash", repo: "ivanfetch/ghapitest/tree", wantErr: true},
+		{description: "trailing .git", repo: "ivanfetch/ghapitest.git", wantErr: true},
+		{description: "whitespace", repo: "ivanfetch/ghapitest ", wantErr: true},
+		{description: "empty owner", repo: "/ghapitest", wantErr: true},
+		{description: "empty name", repo: "ivanfetch/", wantErr: true},
+		{description: "owner beginning with a hyphen", repo: "-ivanfetch/ghapitest", wantErr: true},
+		{description: "invalid characters", repo: "ivanfetch/gh@pitest", wantErr: true},
+		{description: "parent directory", repo: "ivanfetch/..", wantErr: true},
+	}
+	for _, tc := range testCases {
+		err := prme.ValidateRepoName(tc.repo)
+		if tc.wantErr && !errors.Is(err, prme.ErrInvalidRepoName) {
+			t.Errorf("%s: want ErrInvalidRepoName for %q, got %v", tc.description, tc.repo, err)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("%s: want no error for %q, got %v", tc.description, tc.repo, err)
+		}
+	}
+}
+
+func TestRepoExistsUsesCanonicalCase(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/IvanFetch/GHAPITest", `{"full_name": "ivanfetch/ghapitest"}`)
+
+	r, err := prme.NewRepo("IvanFetch/GHAPITest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "ivanfetch/ghapitest" {
+		t.Fatalf("want the canonical name ivanfetch/ghapitest, got %q", r)
+	}
+}
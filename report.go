@@ -0,0 +1,118 @@
+package prme
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Outcomes of creating a full pull request for a repository, as included in
+// a RunReport.
+const (
+	OutcomeCreated = "created"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+	// OutcomeExisting is a pull request that was already created by a
+	// previous identical run, or is already open.
+	OutcomeExisting = "existing"
+)
+
+// RepoReport is the outcome of creating a full pull request for one
+// repository.
+type RepoReport struct {
+	Repo            string  `json:"repo"`
+	FullRepoBranch  string  `json:"full_repo_branch,omitempty"`
+	Outcome         string  `json:"outcome"`
+	PullRequestURL  string  `json:"pull_request_url,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// RunReport summarizes a run of prme, for consumption by logging systems
+// when prme runs unattended, such as a Kubernetes CronJob.
+type RunReport struct {
+	StartedAt       time.Time    `json:"started_at"`
+	FinishedAt      time.Time    `json:"finished_at"`
+	DurationSeconds float64      `json:"duration_seconds"`
+	APICalls        int          `json:"api_calls"`
+	Created         int          `json:"created"`
+	Failed          int          `json:"failed"`
+	Skipped         int          `json:"skipped"`
+	Existing        int          `json:"existing"`
+	Error           string       `json:"error,omitempty"`
+	Repos           []RepoReport `json:"repos"`
+}
+
+// NewRunReport returns a RunReport of results, for a run between
+// startedAt and finishedAt which made apiCalls Github API requests.
+func NewRunReport(results []BatchResult, startedAt, finishedAt time.Time, apiCalls int) RunReport {
+	report := RunReport{
+		StartedAt:       startedAt,
+		FinishedAt:      finishedAt,
+		DurationSeconds: finishedAt.Sub(startedAt).Seconds(),
+		APICalls:        apiCalls,
+		Repos:           make([]RepoReport, 0, len(results)),
+	}
+	for _, result := range results {
+		repoReport := RepoReport{
+			Repo:            result.Repo,
+			FullRepoBranch:  result.FullRepoBranch,
+			DurationSeconds: result.Duration.Seconds(),
+		}
+		switch {
+		case errors.Is(result.Err, ErrRepoExcluded):
+			repoReport.Outcome = OutcomeSkipped
+			repoReport.Error = result.Err.Error()
+			report.Skipped++
+		case errors.Is(result.Err, ErrAlreadyCreated), errors.Is(result.Err, ErrReviewExists):
+			repoReport.Outcome = OutcomeExisting
+			repoReport.PullRequestURL = result.PullRequest.HTMLURL
+			report.Existing++
+		case result.Err != nil:
+			repoReport.Outcome = OutcomeFailed
+			repoReport.Error = result.Err.Error()
+			report.Failed++
+		default:
+			repoReport.Outcome = OutcomeCreated
+			repoReport.PullRequestURL = result.PullRequest.HTMLURL
+			report.Created++
+		}
+		report.Repos = append(report.Repos, repoReport)
+	}
+	return report
+}
+
+// WriteRunReport writes report as JSON to the file path.
+func WriteRunReport(path string, report RunReport) error {
+	return writeJSONFile(path, report)
+}
+
+// apiCallCounter counts Github API requests, and can be shared by multiple
+// clients to count an entire run.
+type apiCallCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (a *apiCallCounter) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// withAPICallCounter counts API requests made by an instance of the client
+// using a.
+func withAPICallCounter(a *apiCallCounter) ClientOption {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				a.mu.Lock()
+				a.count++
+				a.mu.Unlock()
+				return next.Do(req)
+			})
+		})
+		return nil
+	}
+}
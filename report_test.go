@@ -0,0 +1,51 @@
+package prme_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewRunReport(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	results := []prme.BatchResult{
+		{
+			Repo:        "ivanfetch/one",
+			PullRequest: &prme.PullRequest{HTMLURL: "https://github.com/ivanfetch/one/pull/1"},
+			Duration:    2 * time.Second,
+		},
+		{
+			Repo:     "ivanfetch/two",
+			Err:      errors.New("branch main does not exist"),
+			Duration: time.Second,
+		},
+		{
+			Repo: "ivanfetch/infra",
+			Err:  fmt.Errorf("%w: ivanfetch/infra", prme.ErrRepoExcluded),
+		},
+	}
+	want := prme.RunReport{
+		StartedAt:       startedAt,
+		FinishedAt:      startedAt.Add(time.Minute),
+		DurationSeconds: 60,
+		APICalls:        12,
+		Created:         1,
+		Failed:          1,
+		Skipped:         1,
+		Repos: []prme.RepoReport{
+			{Repo: "ivanfetch/one", Outcome: prme.OutcomeCreated, PullRequestURL: "https://github.com/ivanfetch/one/pull/1", DurationSeconds: 2},
+			{Repo: "ivanfetch/two", Outcome: prme.OutcomeFailed, Error: "branch main does not exist", DurationSeconds: 1},
+			{Repo: "ivanfetch/infra", Outcome: prme.OutcomeSkipped, Error: prme.ErrRepoExcluded.Error() + ": ivanfetch/infra"},
+		},
+	}
+	got := prme.NewRunReport(results, startedAt, startedAt.Add(time.Minute), 12)
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect report\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,87 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// branchProtectionRequest is the request body protecting a branch. Every
+// field is required by Github, even when null.
+type branchProtectionRequest struct {
+	RequiredStatusChecks       *struct{} `json:"required_status_checks"`
+	EnforceAdmins              bool      `json:"enforce_admins"`
+	RequiredPullRequestReviews *struct{} `json:"required_pull_request_reviews"`
+	Restrictions               *struct{} `json:"restrictions"`
+	LockBranch                 bool      `json:"lock_branch"`
+	AllowForcePushes           bool      `json:"allow_force_pushes"`
+	AllowDeletions             bool      `json:"allow_deletions"`
+}
+
+// RetainBranch protects branch so it is read-only and cannot be deleted,
+// including by administrators, so the reviewed snapshot is retained. This
+// requires the admin permission for the repository.
+func (r Repo) RetainBranch(branch string) error {
+	apiURI := fmt.Sprintf("/repos/%s/branches/%s/protection", r, url.PathEscape(branch))
+	protectionJSON, err := json.Marshal(branchProtectionRequest{
+		EnforceAdmins: true,
+		LockBranch:    true,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPut, apiURI, protectionJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while protecting branch %q in repository %q, which requires the admin permission", resp.StatusCode, apiURI, branch, r)
+	}
+	return nil
+}
+
+// BranchProtected returns true if branch is protected, such as by
+// RetainBranch. False is returned if the branch does not exist.
+func (r Repo) BranchProtected(branch string) (bool, error) {
+	apiURI := fmt.Sprintf("/repos/%s/branches/%s", r, url.PathEscape(branch))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d for %s while getting branch %q in repository %q", resp.StatusCode, apiURI, branch, r)
+	}
+	var branchAPIResp struct{ Protected bool }
+	err = json.NewDecoder(resp.Body).Decode(&branchAPIResp)
+	if err != nil {
+		return false, err
+	}
+	return branchAPIResp.Protected, nil
+}
+
+// RetainReviewBranches protects the base and head branches of a full
+// review using RetainBranch, instead of deleting them, ignoring either that
+// does not exist.
+func (r Repo) RetainReviewBranches(baseBranch, headBranch string) error {
+	for _, branch := range []string{baseBranch, headBranch} {
+		_, err := r.GetRef("heads/" + branch)
+		if errors.Is(err, ErrRefNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = r.RetainBranch(branch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestRetainReviewBranches(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/ref/heads/prme-full-content", `{"ref": "refs/heads/prme-full-content", "object": {"sha": "c1"}}`)
+	fc.SetJSONResponse(http.MethodPut, "/repos/owner/repo/branches/prme-full-content/protection", `{}`)
+
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The base branch does not exist, so only the head branch is retained.
+	err = r.RetainReviewBranches("prme-full-review", "prme-full-content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	for _, req := range fc.Requests() {
+		if req.Method == http.MethodPut {
+			err = json.Unmarshal(req.Body, &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := map[string]interface{}{
+		"required_status_checks":        nil,
+		"enforce_admins":                true,
+		"required_pull_request_reviews": nil,
+		"restrictions":                  nil,
+		"lock_branch":                   true,
+		"allow_force_pushes":            false,
+		"allow_deletions":               false,
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("got incorrect branch protection\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestLeftoverBranchesSkipsRetainedBranches(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/matching-refs/heads/prme-", `[
+		{"ref": "refs/heads/prme-retained"},
+		{"ref": "refs/heads/prme-old-review"}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/pulls", `[]`)
+	for _, branch := range []string{"prme-retained", "prme-old-review"} {
+		fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/ref/heads/"+branch, `{"ref": "refs/heads/`+branch+`", "object": {"sha": "c1"}}`)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/commits/c1", `{"sha": "c1", "tree": {"sha": "`+prme.EmptyTreeSha+`"}, "parents": []}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/branches/prme-retained", `{"name": "prme-retained", "protected": true}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/branches/prme-old-review", `{"name": "prme-old-review", "protected": false}`)
+
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.LeftoverBranches("prme-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"prme-old-review"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect leftover branches\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,502 @@
+package prme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// graphQL makes a Github GraphQL API request of query with variables, and
+// decodes the data of the response into v.
+func (c *Client) graphQL(query string, variables map[string]interface{}, v interface{}) error {
+	queryJSON, err := json.Marshal(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}{query, variables})
+	if err != nil {
+		return err
+	}
+	resp, err := c.MakeAPIRequestWithData(http.MethodPost, "/graphql", queryJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for a GraphQL query", resp.StatusCode)
+	}
+	var graphQLResp struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	err = json.NewDecoder(resp.Body).Decode(&graphQLResp)
+	if err != nil {
+		return err
+	}
+	if len(graphQLResp.Errors) > 0 {
+		return fmt.Errorf("GraphQL query error: %s", graphQLResp.Errors[0].Message)
+	}
+	return json.Unmarshal(graphQLResp.Data, v)
+}
+
+// ReviewThread is a conversation about a line of a file in a pull request,
+// described by its first comment.
+type ReviewThread struct {
+	Path       string    `json:"path"`
+	Line       int       `json:"line"`
+	IsResolved bool      `json:"is_resolved"`
+	Author     string    `json:"author"`
+	Body       string    `json:"body"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Comments is the number of comments in the thread, including the
+	// first.
+	Comments int `json:"comments"`
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes {
+          isResolved
+          path
+          line
+          comments(first: 1) {
+            totalCount
+            nodes { author { login } body url createdAt }
+          }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+// ListReviewThreads returns the review threads of the pull request number,
+// following each page of 100 threads. Whether threads are resolved is only
+// available from the Github GraphQL API.
+func (r Repo) ListReviewThreads(number int) ([]ReviewThread, error) {
+	owner, name := r.splitOwnerAndName()
+	var threads []ReviewThread
+	var cursor *string
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest *struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							IsResolved bool
+							Path       string
+							Line       int
+							Comments   struct {
+								TotalCount int
+								Nodes      []struct {
+									Author    struct{ Login string }
+									Body, URL string
+									CreatedAt time.Time
+								}
+							}
+						}
+						PageInfo struct {
+							HasNextPage bool
+							EndCursor   string
+						}
+					}
+				}
+			}
+		}
+		err := r.Client.graphQL(reviewThreadsQuery, map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": number,
+			"cursor": cursor,
+		}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("while listing review threads for pull request %d in repository %q: %w", number, r, err)
+		}
+		if data.Repository.PullRequest == nil {
+			return nil, fmt.Errorf("pull request %d was not found in repository %q while listing review threads", number, r)
+		}
+		page := data.Repository.PullRequest.ReviewThreads
+		for _, node := range page.Nodes {
+			thread := ReviewThread{
+				Path:       node.Path,
+				Line:       node.Line,
+				IsResolved: node.IsResolved,
+				Comments:   node.Comments.TotalCount,
+			}
+			if len(node.Comments.Nodes) > 0 {
+				first := node.Comments.Nodes[0]
+				thread.Author = first.Author.Login
+				thread.Body = first.Body
+				thread.URL = first.URL
+				thread.CreatedAt = first.CreatedAt
+			}
+			threads = append(threads, thread)
+		}
+		if !page.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		if page.PageInfo.EndCursor == "" || (cursor != nil && page.PageInfo.EndCursor == *cursor) {
+			return nil, fmt.Errorf("the Github API did not return the next page of review threads for pull request %d in repository %q", number, r)
+		}
+		endCursor := page.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+// splitOwnerAndName returns the owner and name of the repository.
+func (r Repo) splitOwnerAndName() (owner, name string) {
+	parts := strings.SplitN(r.String(), "/", 2)
+	return parts[0], parts[1]
+}
+
+// Commenter is a participant of a review, and how many reviews and
+// comments they made.
+type Commenter struct {
+	Login    string `json:"login"`
+	Comments int    `json:"comments"`
+}
+
+// ReviewerActivity is how much a participant of a review has done, so the
+// workload of long reviews can be balanced.
+type ReviewerActivity struct {
+	Login string `json:"login"`
+	// FilesCommented is the number of files on which they made review
+	// comments, and Comments is the number of review and pull request
+	// comments they made.
+	FilesCommented int `json:"files_commented"`
+	Comments       int `json:"comments"`
+	// Reviews is the number of reviews they submitted, of which Approvals
+	// approved the pull request and ChangesRequested requested changes.
+	Reviews          int `json:"reviews"`
+	Approvals        int `json:"approvals"`
+	ChangesRequested int `json:"changes_requested"`
+}
+
+// newReviewerActivity returns the activity of each participant of reviews,
+// comments, and reviewComments, ordered by files commented, then comments.
+func newReviewerActivity(reviews []Review, comments []IssueComment, reviewComments []ReviewComment) []ReviewerActivity {
+	activity := make(map[string]*ReviewerActivity)
+	get := func(login string) *ReviewerActivity {
+		if activity[login] == nil {
+			activity[login] = &ReviewerActivity{Login: login}
+		}
+		return activity[login]
+	}
+	for _, review := range reviews {
+		a := get(review.User.Login)
+		a.Reviews++
+		switch review.State {
+		case "APPROVED":
+			a.Approvals++
+		case "CHANGES_REQUESTED":
+			a.ChangesRequested++
+		}
+	}
+	for _, comment := range comments {
+		get(comment.User.Login).Comments++
+	}
+	files := make(map[string]map[string]bool)
+	for _, comment := range reviewComments {
+		get(comment.User.Login).Comments++
+		if files[comment.User.Login] == nil {
+			files[comment.User.Login] = make(map[string]bool)
+		}
+		files[comment.User.Login][comment.Path] = true
+	}
+	var result []ReviewerActivity
+	for login, a := range activity {
+		a.FilesCommented = len(files[login])
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FilesCommented != result[j].FilesCommented {
+			return result[i].FilesCommented > result[j].FilesCommented
+		}
+		if result[i].Comments != result[j].Comments {
+			return result[i].Comments > result[j].Comments
+		}
+		return result[i].Login < result[j].Login
+	})
+	return result
+}
+
+// TimelineEvent is something that happened during a review.
+type TimelineEvent struct {
+	At          time.Time `json:"at"`
+	Actor       string    `json:"actor"`
+	Description string    `json:"description"`
+	URL         string    `json:"url,omitempty"`
+}
+
+// ReviewReport summarizes a full review, for compliance documentation.
+type ReviewReport struct {
+	Repo        string      `json:"repo"`
+	PullRequest PullRequest `json:"pull_request"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	// Files is the number of files that were reviewed, and CommentedFiles
+	// is how many of those have review comments.
+	Files             int                `json:"files"`
+	CommentedFiles    int                `json:"commented_files"`
+	Commenters        []Commenter        `json:"commenters"`
+	Reviewers         []ReviewerActivity `json:"reviewers"`
+	Timeline          []TimelineEvent    `json:"timeline"`
+	UnresolvedThreads []ReviewThread     `json:"unresolved_threads"`
+}
+
+// CoveragePercent returns the percentage of reviewed files with review
+// comments.
+func (rr ReviewReport) CoveragePercent() float64 {
+	if rr.Files == 0 {
+		return 0
+	}
+	return float64(rr.CommentedFiles) * 100 / float64(rr.Files)
+}
+
+// NewReviewReport gathers a ReviewReport of the pull request number.
+func (r Repo) NewReviewReport(number int) (*ReviewReport, error) {
+	PR, err := r.GetPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	rr := &ReviewReport{
+		Repo:        r.String(),
+		PullRequest: *PR,
+		GeneratedAt: time.Now().UTC(),
+	}
+	paths, err := r.ListFiles(PR.Head.Sha)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, p := range paths {
+		files[p] = true
+	}
+	rr.Files = len(files)
+	reviews, err := r.ListReviews(number)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := r.ListIssueComments(number)
+	if err != nil {
+		return nil, err
+	}
+	reviewComments, err := r.ListReviewComments(number)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := r.ListReviewThreads(number)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	rr.Timeline = append(rr.Timeline, TimelineEvent{At: PR.CreatedAt, Actor: PR.User.Login, Description: "opened the review", URL: PR.HTMLURL})
+	for _, review := range reviews {
+		counts[review.User.Login]++
+		rr.Timeline = append(rr.Timeline, TimelineEvent{At: review.SubmittedAt, Actor: review.User.Login, Description: "reviewed: " + strings.ToLower(strings.ReplaceAll(review.State, "_", " ")), URL: review.HTMLURL})
+	}
+	for _, comment := range comments {
+		counts[comment.User.Login]++
+		rr.Timeline = append(rr.Timeline, TimelineEvent{At: comment.CreatedAt, Actor: comment.User.Login, Description: "commented", URL: comment.HTMLURL})
+	}
+	commentedFiles := make(map[string]bool)
+	for _, comment := range reviewComments {
+		counts[comment.User.Login]++
+		if files[comment.Path] {
+			commentedFiles[comment.Path] = true
+		}
+		rr.Timeline = append(rr.Timeline, TimelineEvent{At: comment.CreatedAt, Actor: comment.User.Login, Description: "commented on " + comment.Path, URL: comment.HTMLURL})
+	}
+	if !PR.ClosedAt.IsZero() {
+		description := "closed the review"
+		if PR.Merged {
+			description = "merged the review"
+		}
+		rr.Timeline = append(rr.Timeline, TimelineEvent{At: PR.ClosedAt, Description: description})
+	}
+	sort.SliceStable(rr.Timeline, func(i, j int) bool {
+		return rr.Timeline[i].At.Before(rr.Timeline[j].At)
+	})
+	rr.CommentedFiles = len(commentedFiles)
+	for login, count := range counts {
+		rr.Commenters = append(rr.Commenters, Commenter{Login: login, Comments: count})
+	}
+	sort.Slice(rr.Commenters, func(i, j int) bool {
+		if rr.Commenters[i].Comments != rr.Commenters[j].Comments {
+			return rr.Commenters[i].Comments > rr.Commenters[j].Comments
+		}
+		return rr.Commenters[i].Login < rr.Commenters[j].Login
+	})
+	rr.Reviewers = newReviewerActivity(reviews, comments, reviewComments)
+	for _, thread := range threads {
+		if !thread.IsResolved {
+			rr.UnresolvedThreads = append(rr.UnresolvedThreads, thread)
+		}
+	}
+	return rr, nil
+}
+
+var reviewReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Full review of {{.Repo}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>Full review of {{.Repo}}</h1>
+<table>
+<tr><th>Pull request</th><td><a href="{{.PullRequest.HTMLURL}}">#{{.PullRequest.Number}} {{.PullRequest.Title}}</a></td></tr>
+<tr><th>State</th><td>{{.PullRequest.State}}</td></tr>
+<tr><th>Reviewed commit</th><td>{{.PullRequest.Head.Sha}}</td></tr>
+<tr><th>Coverage</th><td>{{.CommentedFiles}} of {{.Files}} files have review comments ({{printf "%.1f" .CoveragePercent}}%)</td></tr>
+<tr><th>Generated</th><td>{{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</td></tr>
+</table>
+<h2>Commenters</h2>
+<table>
+<tr><th>Login</th><th>Reviews and comments</th></tr>
+{{range .Commenters}}<tr><td>{{.Login}}</td><td>{{.Comments}}</td></tr>
+{{else}}<tr><td colspan="2">None</td></tr>
+{{end}}</table>
+<h2>Reviewer activity</h2>
+<table>
+<tr><th>Login</th><th>Files commented</th><th>Comments</th><th>Reviews</th><th>Approvals</th><th>Changes requested</th></tr>
+{{range .Reviewers}}<tr><td>{{.Login}}</td><td>{{.FilesCommented}}</td><td>{{.Comments}}</td><td>{{.Reviews}}</td><td>{{.Approvals}}</td><td>{{.ChangesRequested}}</td></tr>
+{{else}}<tr><td colspan="6">None</td></tr>
+{{end}}</table>
+<h2>Unresolved threads</h2>
+<table>
+<tr><th>File</th><th>Author</th><th>Comment</th><th>Comments</th></tr>
+{{range .UnresolvedThreads}}<tr><td>{{.Path}}{{if .Line}}:{{.Line}}{{end}}</td><td>{{.Author}}</td><td><a href="{{.URL}}">{{.Body}}</a></td><td>{{.Comments}}</td></tr>
+{{else}}<tr><td colspan="4">None</td></tr>
+{{end}}</table>
+<h2>Timeline</h2>
+<table>
+<tr><th>Date</th><th>Who</th><th>What</th></tr>
+{{range .Timeline}}<tr><td>{{.At.Format "2006-01-02 15:04"}}</td><td>{{.Actor}}</td><td>{{if .URL}}<a href="{{.URL}}">{{.Description}}</a>{{else}}{{.Description}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a standalone HTML document.
+func (rr ReviewReport) WriteHTML(w io.Writer) error {
+	return reviewReportTemplate.Execute(w, rr)
+}
+
+// Formats of a review report.
+const (
+	ReportFormatHTML = "html"
+	ReportFormatPDF  = "pdf"
+)
+
+// htmlToPDFCommand converts HTML from standard input to a PDF on standard
+// output.
+var htmlToPDFCommand = []string{"wkhtmltopdf", "--quiet", "-", "-"}
+
+// writePDF converts the HTML document to a PDF written to w, using
+// htmlToPDFCommand.
+func writePDF(HTML []byte, w io.Writer) error {
+	_, err := exec.LookPath(htmlToPDFCommand[0])
+	if err != nil {
+		return fmt.Errorf("%s is required to create a PDF report, or use the %s format: %w", htmlToPDFCommand[0], ReportFormatHTML, err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(htmlToPDFCommand[0], htmlToPDFCommand[1:]...)
+	cmd.Stdin = bytes.NewReader(HTML)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("while converting the report to PDF: %v: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// runReportCommand renders a report of a review.
+func runReportCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme report", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand renders a report of a full review, including coverage, commenters, the activity of each reviewer, unresolved threads, and a timeline, suitable for compliance documentation.
+
+Usage: %s [flags] <pull request URL>
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	CLIFormat := fs.String("format", ReportFormatHTML, fmt.Sprintf("The report format, either %s, or %s which requires %s to be installed. This is also set via the PRME_FORMAT environment variable.", ReportFormatHTML, ReportFormatPDF, htmlToPDFCommand[0]))
+	CLIOutputFile := fs.String("o", "", "The report file to write. By default, this is <repository name>-review-<pull request number>.<format>.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please specify one pull request URL")
+	}
+	if *CLIFormat != ReportFormatHTML && *CLIFormat != ReportFormatPDF {
+		return fmt.Errorf("unsupported report format %q, use %s or %s", *CLIFormat, ReportFormatHTML, ReportFormatPDF)
+	}
+	repoName, number, err := ParsePullRequestURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	r, err := NewRepo(repoName, token, clientOptions...)
+	if err != nil {
+		return err
+	}
+	rr, err := r.NewReviewReport(number)
+	if err != nil {
+		return err
+	}
+	var HTML bytes.Buffer
+	err = rr.WriteHTML(&HTML)
+	if err != nil {
+		return err
+	}
+	report := HTML.Bytes()
+	if *CLIFormat == ReportFormatPDF {
+		var PDF bytes.Buffer
+		err = writePDF(report, &PDF)
+		if err != nil {
+			return err
+		}
+		report = PDF.Bytes()
+	}
+	outputFile := *CLIOutputFile
+	if outputFile == "" {
+		_, name := r.splitOwnerAndName()
+		outputFile = fmt.Sprintf("%s-review-%d.%s", name, number, *CLIFormat)
+	}
+	err = os.WriteFile(outputFile, report, 0o644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "The report of review %s has been written to %s\n", fs.Arg(0), outputFile)
+	return nil
+}
@@ -0,0 +1,166 @@
+package prme_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewReviewReport(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/ivanfetch/ghapitest/pulls/7":
+			fmt.Fprint(w, `{"number": 7, "title": "Full review", "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "state": "open", "created_at": "2021-08-19T17:47:41Z", "user": {"login": "ivanfetch"}, "head": {"ref": "review", "sha": "05db72cfac1ee0eef0ceb72193f648f229d6fcc3"}}`)
+		case "/repos/ivanfetch/ghapitest/git/trees/05db72cfac1ee0eef0ceb72193f648f229d6fcc3":
+			fmt.Fprint(w, `{"sha": "05db72cfac1ee0eef0ceb72193f648f229d6fcc3", "tree": [
+				{"path": "README.md", "type": "blob"},
+				{"path": "cmd", "type": "tree"},
+				{"path": "cmd/main.go", "type": "blob"},
+				{"path": "go.mod", "type": "blob"},
+				{"path": "prme.go", "type": "blob"}]}`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/reviews":
+			fmt.Fprint(w, `[{"id": 1, "state": "CHANGES_REQUESTED", "submitted_at": "2021-08-21T10:00:00Z", "user": {"login": "octocat"}}]`)
+		case "/repos/ivanfetch/ghapitest/issues/7/comments":
+			fmt.Fprint(w, `[{"id": 2, "body": "Started", "created_at": "2021-08-20T10:00:00Z", "user": {"login": "hubot"}}]`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/comments":
+			fmt.Fprint(w, `[{"id": 3, "body": "Typo", "path": "README.md", "created_at": "2021-08-21T09:00:00Z", "user": {"login": "octocat"}}]`)
+		case "/graphql":
+			fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+				"nodes": [
+					{"isResolved": true, "path": "go.mod", "line": 1, "comments": {"nodes": [{"author": {"login": "hubot"}, "body": "Fixed"}]}},
+					{"isResolved": false, "path": "README.md", "line": 3, "comments": {"nodes": [{"author": {"login": "octocat"}, "body": "Typo <here>"}]}}
+				],
+				"pageInfo": {"hasNextPage": false}}}}}}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := r.NewReviewReport(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Files != 4 || rr.CommentedFiles != 1 || rr.CoveragePercent() != 25 {
+		t.Errorf("want 1 of 4 files commented, got %d of %d (%.1f%%)", rr.CommentedFiles, rr.Files, rr.CoveragePercent())
+	}
+	wantCommenters := []prme.Commenter{{Login: "octocat", Comments: 2}, {Login: "hubot", Comments: 1}}
+	if !cmp.Equal(wantCommenters, rr.Commenters) {
+		t.Errorf("got incorrect commenters\ndiff reflects want vs. got: %s", cmp.Diff(wantCommenters, rr.Commenters))
+	}
+	wantReviewers := []prme.ReviewerActivity{
+		{Login: "octocat", FilesCommented: 1, Comments: 1, Reviews: 1, ChangesRequested: 1},
+		{Login: "hubot", Comments: 1},
+	}
+	if !cmp.Equal(wantReviewers, rr.Reviewers) {
+		t.Errorf("got incorrect reviewer activity\ndiff reflects want vs. got: %s", cmp.Diff(wantReviewers, rr.Reviewers))
+	}
+	var gotTimeline []string
+	for _, event := range rr.Timeline {
+		gotTimeline = append(gotTimeline, event.Actor+" "+event.Description)
+	}
+	wantTimeline := []string{"ivanfetch opened the review", "hubot commented", "octocat commented on README.md", "octocat reviewed: changes requested"}
+	if !cmp.Equal(wantTimeline, gotTimeline) {
+		t.Errorf("got incorrect timeline\ndiff reflects want vs. got: %s", cmp.Diff(wantTimeline, gotTimeline))
+	}
+	if len(rr.UnresolvedThreads) != 1 || rr.UnresolvedThreads[0].Path != "README.md" {
+		t.Errorf("want only the README.md thread unresolved, got %+v", rr.UnresolvedThreads)
+	}
+
+	var HTML bytes.Buffer
+	err = rr.WriteHTML(&HTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"1 of 4 files have review comments (25.0%)", "README.md:3", "Typo &lt;here&gt;"} {
+		if !strings.Contains(HTML.String(), want) {
+			t.Errorf("want the HTML report to contain %q", want)
+		}
+	}
+}
+
+func TestNewReviewReportPaginates(t *testing.T) {
+	t.Parallel()
+
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/ivanfetch/ghapitest/pulls/7":
+			fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/ivanfetch/ghapitest/pull/7", "state": "open", "head": {"sha": "headsha"}}`)
+		case "/repos/ivanfetch/ghapitest/git/trees/headsha":
+			if r.URL.Query().Get("recursive") != "" {
+				fmt.Fprint(w, `{"sha": "roottree", "truncated": true, "tree": [{"path": "README.md", "type": "blob"}]}`)
+				return
+			}
+			t.Errorf("want the truncated tree to be listed by directory from its tree sha")
+		case "/repos/ivanfetch/ghapitest/git/trees/roottree":
+			fmt.Fprint(w, `{"sha": "roottree", "tree": [{"path": "README.md", "type": "blob"}, {"path": "cmd", "type": "tree", "sha": "cmdtree"}]}`)
+		case "/repos/ivanfetch/ghapitest/git/trees/cmdtree":
+			fmt.Fprint(w, `{"sha": "cmdtree", "tree": [{"path": "main.go", "type": "blob"}, {"path": "flags.go", "type": "blob"}]}`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/reviews", "/repos/ivanfetch/ghapitest/issues/7/comments":
+			fmt.Fprint(w, `[]`)
+		case "/repos/ivanfetch/ghapitest/pulls/7/comments":
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/ivanfetch/ghapitest/pulls/7/comments?per_page=100&page=2>; rel="next"`, ts.URL))
+				fmt.Fprint(w, `[{"id": 1, "path": "README.md", "user": {"login": "octocat"}}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id": 2, "path": "cmd/main.go", "user": {"login": "hubot"}}]`)
+		case "/graphql":
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), `"cursor":"page2"`) {
+				fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+					"nodes": [{"isResolved": false, "path": "README.md", "comments": {"totalCount": 250, "nodes": [{"body": "First"}]}}],
+					"pageInfo": {"hasNextPage": true, "endCursor": "page2"}}}}}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+				"nodes": [{"isResolved": false, "path": "cmd/main.go", "comments": {"totalCount": 1, "nodes": [{"body": "Second"}]}}],
+				"pageInfo": {"hasNextPage": false}}}}}}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := r.NewReviewReport(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Files != 3 || rr.CommentedFiles != 2 {
+		t.Errorf("want 2 of 3 files commented, got %d of %d", rr.CommentedFiles, rr.Files)
+	}
+	var gotThreads []string
+	for _, thread := range rr.UnresolvedThreads {
+		gotThreads = append(gotThreads, fmt.Sprintf("%s %d", thread.Path, thread.Comments))
+	}
+	wantThreads := []string{"README.md 250", "cmd/main.go 1"}
+	if !cmp.Equal(wantThreads, gotThreads) {
+		t.Errorf("got incorrect unresolved threads\ndiff reflects want vs. got: %s", cmp.Diff(wantThreads, gotThreads))
+	}
+}
@@ -0,0 +1,193 @@
+package prme
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RulesetRule is a rule of a repository or organization ruleset, which
+// applies to a branch.
+type RulesetRule struct {
+	// Type is the kind of rule, such as creation or branch_name_pattern.
+	Type       string          `json:"type"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// RulesetSourceType is Repository or Organization, and RulesetSource
+	// is its name.
+	RulesetSourceType string `json:"ruleset_source_type"`
+	RulesetSource     string `json:"ruleset_source"`
+	RulesetID         int    `json:"ruleset_id"`
+}
+
+// RulesetViolationError is returned by CheckBranchRules when a ruleset would
+// reject creating or updating a branch.
+type RulesetViolationError struct {
+	Repo, Branch string
+	Rule         RulesetRule
+	Reason       string
+}
+
+func (e *RulesetViolationError) Error() string {
+	return fmt.Sprintf("branch %q in repository %q would be rejected by the %s rule of ruleset %d of %s %s: %s. Ask an administrator to add the Github user of the token to the bypass list of the ruleset, or use -branch-prefix to create branches the ruleset does not apply to", e.Branch, e.Repo, e.Rule.Type, e.Rule.RulesetID, strings.ToLower(e.Rule.RulesetSourceType), e.Rule.RulesetSource, e.Reason)
+}
+
+// BranchRules returns the rules of rulesets, of the repository or its
+// organization, that apply to branch, whether or not it exists. No rules are
+// returned by Github instances that do not support rulesets.
+func (r Repo) BranchRules(branch string) ([]RulesetRule, error) {
+	var rules []RulesetRule
+	apiURI := fmt.Sprintf("/repos/%s/rules/branches/%s", r, url.PathEscape(branch))
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting rules for branch %q in repository %q", resp.StatusCode, apiURI, branch, r)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rules)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// canBypassRuleset returns true if the Github user of the token can always
+// bypass the ruleset id of the repository.
+func (r Repo) canBypassRuleset(id int) (bool, error) {
+	apiURI := fmt.Sprintf("/repos/%s/rulesets/%d", r, id)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		// Rulesets of the organization may not be visible.
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d for %s while getting ruleset %d in repository %q", resp.StatusCode, apiURI, id, r)
+	}
+	var rulesetAPIResp struct {
+		CurrentUserCanBypass string `json:"current_user_can_bypass"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rulesetAPIResp)
+	if err != nil {
+		return false, err
+	}
+	return rulesetAPIResp.CurrentUserCanBypass == "always", nil
+}
+
+// rulePattern is the parameters of rules that require a name or message to
+// match a pattern.
+type rulePattern struct {
+	Name     string `json:"name"`
+	Negate   bool   `json:"negate"`
+	Operator string `json:"operator"`
+	Pattern  string `json:"pattern"`
+}
+
+// matches returns true if s satisfies the pattern.
+func (p rulePattern) matches(s string) (bool, error) {
+	var matched bool
+	switch p.Operator {
+	case "starts_with":
+		matched = strings.HasPrefix(s, p.Pattern)
+	case "ends_with":
+		matched = strings.HasSuffix(s, p.Pattern)
+	case "contains":
+		matched = strings.Contains(s, p.Pattern)
+	case "regex":
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return false, fmt.Errorf("while parsing the ruleset pattern %q: %w", p.Pattern, err)
+		}
+		matched = re.MatchString(s)
+	default:
+		return false, fmt.Errorf("unknown ruleset pattern operator %q", p.Operator)
+	}
+	return matched != p.Negate, nil
+}
+
+// describe returns the requirement of the pattern, for error messages.
+func (p rulePattern) describe() string {
+	requirement := "must"
+	if p.Negate {
+		requirement = "must not"
+	}
+	return fmt.Sprintf("%s %s %q", requirement, strings.ReplaceAll(p.Operator, "_", " "), p.Pattern)
+}
+
+// ruleViolation returns why rule rejects creating branch with commits of
+// commitMessages, or an empty string if it does not.
+func ruleViolation(rule RulesetRule, branch string, commitMessages []string) (string, error) {
+	switch rule.Type {
+	case "creation":
+		return "creating matching branches is restricted", nil
+	case "required_signatures":
+		return "commits must be signed, and the empty-tree commit created by prme is not", nil
+	case "branch_name_pattern", "commit_message_pattern":
+		var p rulePattern
+		err := json.Unmarshal(rule.Parameters, &p)
+		if err != nil {
+			return "", fmt.Errorf("while parsing the parameters of the %s rule of ruleset %d: %w", rule.Type, rule.RulesetID, err)
+		}
+		if rule.Type == "branch_name_pattern" {
+			ok, err := p.matches(branch)
+			if err != nil || ok {
+				return "", err
+			}
+			return "the branch name " + p.describe(), nil
+		}
+		for _, message := range commitMessages {
+			ok, err := p.matches(message)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return fmt.Sprintf("the commit message %q %s", message, p.describe()), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// CheckBranchRules verifies that rulesets do not reject creating branch with
+// commits having commitMessages, such as due to ref-name rules, before
+// anything is changed. A RulesetViolationError describes the rule that would
+// reject the branch, unless the Github user of the token can bypass its
+// ruleset.
+func (r Repo) CheckBranchRules(branch string, commitMessages ...string) error {
+	rules, err := r.BranchRules(branch)
+	if err != nil {
+		return err
+	}
+	canBypass := make(map[int]bool)
+	for _, rule := range rules {
+		reason, err := ruleViolation(rule, branch, commitMessages)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			continue
+		}
+		bypass, checked := canBypass[rule.RulesetID]
+		if !checked {
+			bypass, err = r.canBypassRuleset(rule.RulesetID)
+			if err != nil {
+				return err
+			}
+			canBypass[rule.RulesetID] = bypass
+		}
+		if !bypass {
+			return &RulesetViolationError{Repo: r.String(), Branch: branch, Rule: rule, Reason: reason}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckBranchRulesBranchNamePattern(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/rules/branches/prme-full-review", `[
+		{"type": "branch_name_pattern", "parameters": {"operator": "regex", "pattern": "^(feature|fix)/"}, "ruleset_source_type": "Organization", "ruleset_source": "ivanfetch", "ruleset_id": 42}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/rulesets/42", `{"id": 42, "current_user_can_bypass": "never"}`)
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CheckBranchRules("prme-full-review")
+	var violation *prme.RulesetViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("want a RulesetViolationError, got %v", err)
+	}
+	if violation.Rule.RulesetID != 42 || violation.Branch != "prme-full-review" {
+		t.Fatalf("want a violation of ruleset 42 by branch prme-full-review, got %#v", violation)
+	}
+	if !strings.Contains(err.Error(), `the branch name must regex "^(feature|fix)/"`) {
+		t.Fatalf("want the error to describe the branch name pattern, got %q", err)
+	}
+}
+
+func TestCheckBranchRulesBypass(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/rules/branches/prme-full-review", `[
+		{"type": "creation", "ruleset_source_type": "Repository", "ruleset_source": "ivanfetch/ghapitest", "ruleset_id": 7},
+		{"type": "branch_name_pattern", "parameters": {"operator": "starts_with", "pattern": "prme-"}, "ruleset_source_type": "Repository", "ruleset_source": "ivanfetch/ghapitest", "ruleset_id": 8}
+	]`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/rulesets/7", `{"id": 7, "current_user_can_bypass": "always"}`)
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CheckBranchRules("prme-full-review")
+	if err != nil {
+		t.Fatalf("want no error when the ruleset can be bypassed and the branch name matches, got %v", err)
+	}
+}
+
+func TestCheckBranchRulesCommitMessagePattern(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/ivanfetch/ghapitest/rules/branches/prme-full-content", `[
+		{"type": "commit_message_pattern", "parameters": {"operator": "contains", "pattern": "JIRA-"}, "ruleset_source_type": "Repository", "ruleset_source": "ivanfetch/ghapitest", "ruleset_id": 9}
+	]`)
+
+	r, err := prme.NewRepo("ivanfetch/ghapitest", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CheckBranchRules("prme-full-content", "empty-tree commit")
+	var violation *prme.RulesetViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("want a RulesetViolationError, got %v", err)
+	}
+	// Without rules, such as on Github instances without rulesets, the
+	// branch is allowed.
+	err = r.CheckBranchRules("main", "empty-tree commit")
+	if err != nil {
+		t.Fatalf("want no error for a branch without rules, got %v", err)
+	}
+}
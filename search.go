@@ -0,0 +1,80 @@
+package prme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Issue is a Github issue or pull request returned by SearchIssues.
+type Issue struct {
+	Number        int    `json:"number"`
+	Title         string `json:"title"`
+	State         string `json:"state"`
+	HTMLURL       string `json:"html_url"`
+	RepositoryURL string `json:"repository_url"`
+	// PullRequest is only set when the issue is a pull request.
+	PullRequest *struct {
+		HTMLURL string `json:"html_url"`
+	} `json:"pull_request,omitempty"`
+}
+
+// IsPullRequest returns true if the issue is a pull request.
+func (i Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// Repository is a Github repository returned by SearchRepositories.
+type Repository struct {
+	FullName      string   `json:"full_name"`
+	DefaultBranch string   `json:"default_branch"`
+	HTMLURL       string   `json:"html_url"`
+	Private       bool     `json:"private"`
+	Archived      bool     `json:"archived"`
+	Topics        []string `json:"topics"`
+}
+
+// SearchIssues returns all issues and pull requests matching the Github
+// search query, such as "is:pr is:open head:prme-full-content".
+func (c *Client) SearchIssues(query string) ([]Issue, error) {
+	var issues []Issue
+	apiURI := "/search/issues?q=" + url.QueryEscape(query)
+	err := c.getAllPages(apiURI, func(body io.Reader) error {
+		var searchAPIResp struct {
+			Items []Issue `json:"items"`
+		}
+		err := json.NewDecoder(body).Decode(&searchAPIResp)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, searchAPIResp.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while searching issues for %q: %w", query, err)
+	}
+	return issues, nil
+}
+
+// SearchRepositories returns all repositories matching the Github search
+// query, such as "org:ivanfetch topic:needs-audit".
+func (c *Client) SearchRepositories(query string) ([]Repository, error) {
+	var repos []Repository
+	apiURI := "/search/repositories?q=" + url.QueryEscape(query)
+	err := c.getAllPages(apiURI, func(body io.Reader) error {
+		var searchAPIResp struct {
+			Items []Repository `json:"items"`
+		}
+		err := json.NewDecoder(body).Decode(&searchAPIResp)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, searchAPIResp.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while searching repositories for %q: %w", query, err)
+	}
+	return repos, nil
+}
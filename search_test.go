@@ -0,0 +1,125 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchIssuesPaginates(t *testing.T) {
+	t.Parallel()
+
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var testFileName string
+		switch r.RequestURI {
+		case "/search/issues?q=is%3Apr+head%3Aprme-full-content&per_page=100":
+			testFileName = "testdata/TestSearchIssuesPage1.json"
+			w.Header().Set("Link", fmt.Sprintf(`<%s/search/issues?q=is%%3Apr+head%%3Aprme-full-content&per_page=100&page=2>; rel="next", <%s/search/issues?q=is%%3Apr+head%%3Aprme-full-content&per_page=100&page=2>; rel="last"`, ts.URL, ts.URL))
+		case "/search/issues?q=is%3Apr+head%3Aprme-full-content&per_page=100&page=2":
+			testFileName = "testdata/TestSearchIssuesPage2.json"
+		default:
+			t.Errorf("unexpected Github URL %q", r.RequestURI)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := c.SearchIssues("is:pr head:prme-full-content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, i := range issues {
+		got = append(got, i.Number)
+	}
+	want := []int{7, 3}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect issue numbers\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+	if !issues[0].IsPullRequest() || issues[1].IsPullRequest() {
+		t.Fatalf("want only the first issue to be a pull request, got %+v", issues)
+	}
+}
+
+func TestSearchRepositoriesWaitsForRateLimit(t *testing.T) {
+	t.Parallel()
+
+	testFileName := "testdata/TestSearchRepositories.json"
+	var requests int
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantRequestURL := "/search/repositories?q=org%3Aivanfetch+topic%3Aneeds-audit&per_page=100"
+		gotRequestURL := r.RequestURI
+		if wantRequestURL != gotRequestURL {
+			t.Errorf("Want %q for Github URL, got %q", wantRequestURL, gotRequestURL)
+		}
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f, err := os.Open(testFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		if err != nil {
+			t.Fatalf("error copying data from file %s to test HTTP server: %v", testFileName, err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := prme.NewClient("dummyToken",
+		prme.WithHTTPClient(ts.Client()),
+		prme.WithAPIHost(ts.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := c.SearchRepositories("org:ivanfetch topic:needs-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []prme.Repository{
+		{
+			FullName:      "ivanfetch/ghapitest",
+			DefaultBranch: "main",
+			HTMLURL:       "https://github.com/ivanfetch/ghapitest",
+			Topics:        []string{"needs-audit"},
+		},
+	}
+	if !cmp.Equal(want, repos) {
+		t.Fatalf("got incorrect repositories\ndiff reflects want vs. got: %s", cmp.Diff(want, repos))
+	}
+	if requests != 2 {
+		t.Fatalf("want 2 requests after a rate limit response, got %d", requests)
+	}
+}
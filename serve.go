@@ -0,0 +1,557 @@
+package prme
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxJobAttempts is the number of times serve mode tries to create a
+	// pull request for a queued repository, before dropping the job.
+	maxJobAttempts = 10
+	// jobBackoffBase is the delay before retrying a job that failed once.
+	// The delay doubles with each further failure, up to jobBackoffMax.
+	jobBackoffBase = 30 * time.Second
+	jobBackoffMax  = time.Hour
+	// jobBackoffJitter is the fraction by which backoff delays are randomly
+	// varied, so that jobs failing together are not retried together.
+	jobBackoffJitter = 0.2
+	// jobPollInterval is how often the queue is checked for due jobs.
+	jobPollInterval = 5 * time.Second
+	// readinessCheckInterval is how long the result of checking the Github
+	// token is reused, so frequent readiness probes do not each make an
+	// API request.
+	readinessCheckInterval = 30 * time.Second
+	// reminderCheckInterval is how often open reviews are checked for
+	// inactivity, to remind their reviewers, and for merges.
+	reminderCheckInterval = time.Hour
+)
+
+// Job is a queued request to create a full review pull request for a
+// repository.
+type Job struct {
+	Repo      string    `json:"repo"`
+	Attempts  int       `json:"attempts"`
+	NextRun   time.Time `json:"next_run"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// JobQueue is a queue of Jobs, persisted to a local JSON file whenever it
+// changes, so that pending jobs survive a restart.
+type JobQueue struct {
+	mu   sync.Mutex
+	path string
+	jobs []Job
+}
+
+// DefaultQueueFile returns the default job queue file, in the per-user
+// configuration directory.
+func DefaultQueueFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "prme", "queue.json"), nil
+}
+
+// NewJobQueue returns a job queue persisted to the file path, including any
+// jobs already stored there.
+func NewJobQueue(path string) (*JobQueue, error) {
+	if path == "" {
+		return nil, errors.New("the job queue file cannot be empty")
+	}
+	q := &JobQueue{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &q.jobs)
+	if err != nil {
+		return nil, fmt.Errorf("while reading job queue %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// Jobs returns the queued jobs, ordered by when they will next run.
+func (q *JobQueue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, len(q.jobs))
+	copy(jobs, q.jobs)
+	return jobs
+}
+
+// Add queues a job for repo, to run at now. If a job for repo is already
+// queued, that job is returned instead.
+func (q *JobQueue) Add(repo string, now time.Time) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Repo == repo {
+			return job, nil
+		}
+	}
+	job := Job{Repo: repo, NextRun: now}
+	q.jobs = append(q.jobs, job)
+	return job, q.save()
+}
+
+// Next returns the job that has been due the longest as of now, and whether
+// any job is due.
+func (q *JobQueue) Next(now time.Time) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.jobs) == 0 || q.jobs[0].NextRun.After(now) {
+		return Job{}, false
+	}
+	return q.jobs[0], true
+}
+
+// Reschedule records a failed attempt of the job for repo, which will next
+// run at next.
+func (q *JobQueue) Reschedule(repo string, next time.Time, jobErr error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.jobs {
+		if q.jobs[i].Repo != repo {
+			continue
+		}
+		q.jobs[i].Attempts++
+		q.jobs[i].NextRun = next
+		q.jobs[i].LastError = ""
+		if jobErr != nil {
+			q.jobs[i].LastError = jobErr.Error()
+		}
+		return q.save()
+	}
+	return fmt.Errorf("no job is queued for repository %q", repo)
+}
+
+// Remove removes the job for repo from the queue.
+func (q *JobQueue) Remove(repo string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.jobs {
+		if q.jobs[i].Repo == repo {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			return q.save()
+		}
+	}
+	return nil
+}
+
+// save sorts and persists the queue. The caller must hold q.mu.
+func (q *JobQueue) save() error {
+	sort.SliceStable(q.jobs, func(i, j int) bool {
+		return q.jobs[i].NextRun.Before(q.jobs[j].NextRun)
+	})
+	return writeJSONFile(q.path, q.jobs)
+}
+
+// jobRetryAfter returns the minimum time to wait before retrying a job that
+// failed with err, and whether err is a rate limit or transient failure
+// worth retrying.
+func jobRetryAfter(err error) (time.Duration, bool) {
+	if d, ok := batchPause(err); ok {
+		return d, true
+	}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.RetryAfter, true
+	}
+	if errors.Is(err, ErrRepoLocked) {
+		return 0, true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return 0, true
+	}
+	return 0, false
+}
+
+// jobBackoff returns how long to wait before the next attempt of a job that
+// has failed attempts times, which is at least retryAfter. The random number
+// in [0.0,1.0) determines the jitter.
+func jobBackoff(attempts int, retryAfter time.Duration, random float64) time.Duration {
+	backoff := jobBackoffBase
+	for i := 1; i < attempts && backoff < jobBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > jobBackoffMax {
+		backoff = jobBackoffMax
+	}
+	jitter := (random*2 - 1) * jobBackoffJitter
+	backoff += time.Duration(float64(backoff) * jitter)
+	if backoff < retryAfter {
+		backoff = retryAfter
+	}
+	return backoff
+}
+
+// ErrInvalidAPISecret is returned by VerifyAPISecret when a request to the
+// serve API does not include the API secret.
+var ErrInvalidAPISecret = errors.New("the API secret is missing or invalid")
+
+// VerifyAPISecret verifies that authorization, the value of the
+// Authorization header of a request to the serve API, is the bearer token
+// secret.
+func VerifyAPISecret(secret, authorization string) error {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if secret == "" || token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return ErrInvalidAPISecret
+	}
+	return nil
+}
+
+// reviewServer accepts full review requests over HTTP, and creates their
+// pull requests from a JobQueue.
+type reviewServer struct {
+	creator FullPullRequestCreator
+	queue   *JobQueue
+	logger  *log.Logger
+	wake    chan struct{}
+	// client checks the Github token and API for readiness probes.
+	client *Client
+
+	// apiSecret authenticates requests to /reviews, which create reviews
+	// using the Github token of the server.
+	apiSecret string
+
+	// webhookSecret verifies the signature of webhook deliveries, which are
+	// deduplicated by deliveries.
+	webhookSecret string
+	deliveries    *deliveryCache
+
+	// slackWebhookURL, if set, is a Slack incoming webhook to which
+	// reminders of inactive reviews are also posted.
+	slackWebhookURL string
+
+	readyMu        sync.Mutex
+	readyCheckedAt time.Time
+	readyErr       error
+}
+
+// reviewRequest is the body of a request to POST /reviews.
+type reviewRequest struct {
+	Repo string `json:"repo"`
+}
+
+// writeJSONMessage responds with status code and a JSON message, in the same
+// form as Github API errors.
+func writeJSONMessage(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{message})
+}
+
+// enqueue queues a review of repo, and wakes the job runner.
+func (s *reviewServer) enqueue(repo string) (Job, error) {
+	job, err := s.queue.Add(repo, s.creator.now())
+	if err != nil {
+		s.logger.Printf("while queueing repository %s: %v", repo, err)
+		return Job{}, err
+	}
+	s.logger.Printf("queued a review of repository %s", repo)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// handleReviews queues a review for POST requests, and lists queued jobs
+// for GET requests. Requests must include the API secret as a bearer token.
+func (s *reviewServer) handleReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := VerifyAPISecret(s.apiSecret, r.Header.Get("Authorization"))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="prme"`)
+		writeJSONMessage(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(s.queue.Jobs())
+	case http.MethodPost:
+		var req reviewRequest
+		err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&req)
+		if err != nil || req.Repo == "" {
+			writeJSONMessage(w, http.StatusBadRequest, `the request body must be JSON of the form {"repo":"owner/name"}`)
+			return
+		}
+		if !s.creator.repoAllowed(req.Repo) {
+			writeJSONMessage(w, http.StatusForbidden, fmt.Sprintf("%v: %s", ErrRepoExcluded, req.Repo))
+			return
+		}
+		job, err := s.enqueue(req.Repo)
+		if err != nil {
+			writeJSONMessage(w, http.StatusInternalServerError, "unable to queue the review")
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleHealthz responds successfully while the server is running, for
+// liveness probes.
+func (s *reviewServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz responds successfully when the Github API is reachable and
+// accepts the token, for readiness probes.
+func (s *reviewServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	err := s.ready(s.creator.now())
+	w.Header().Set("Content-Type", "text/plain")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "not ready: %v\n", err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// ready returns the result of checking the Github token, checking again
+// if the previous result is older than readinessCheckInterval.
+func (s *reviewServer) ready(now time.Time) error {
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
+	if !s.readyCheckedAt.IsZero() && now.Sub(s.readyCheckedAt) < readinessCheckInterval {
+		return s.readyErr
+	}
+	s.readyErr = s.client.CheckToken()
+	s.readyCheckedAt = now
+	if s.readyErr != nil {
+		s.logger.Printf("not ready: %v", s.readyErr)
+	}
+	return s.readyErr
+}
+
+// runJobs runs due jobs from the queue until ctx is done.
+func (s *reviewServer) runJobs(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			job, ok := s.queue.Next(s.creator.now())
+			if !ok || ctx.Err() != nil {
+				break
+			}
+			s.runJob(job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runReminders reminds reviewers of inactive reviews every
+// reminderCheckInterval, until ctx is done.
+func (s *reviewServer) runReminders(ctx context.Context) {
+	ticker := time.NewTicker(reminderCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.remindInactiveReviews(s.creator.now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob creates the pull request for job, rescheduling it with backoff if
+// it fails due to rate limits or a transient failure.
+func (s *reviewServer) runJob(job Job) {
+	repoCreator := s.creator
+	repoCreator.Repo = job.Repo
+	PR, err := repoCreator.CreateWithResult()
+	if errors.Is(err, ErrAlreadyCreated) || errors.Is(err, ErrReviewExists) {
+		s.logger.Printf("skipped repository %s, a full pull request already exists at %s", job.Repo, PR.HTMLURL)
+		err = nil
+	} else if err == nil {
+		s.logger.Printf("created a full pull request for repository %s at %s", job.Repo, PR.HTMLURL)
+	}
+	if err == nil {
+		err = s.queue.Remove(job.Repo)
+		if err != nil {
+			s.logger.Printf("while removing the job for repository %s from the queue: %v", job.Repo, err)
+		}
+		return
+	}
+	retryAfter, retryable := jobRetryAfter(err)
+	if !retryable || job.Attempts+1 >= maxJobAttempts {
+		s.logger.Printf("giving up on repository %s after %d attempts: %v", job.Repo, job.Attempts+1, err)
+		err = s.queue.Remove(job.Repo)
+		if err != nil {
+			s.logger.Printf("while removing the job for repository %s from the queue: %v", job.Repo, err)
+		}
+		return
+	}
+	wait := jobBackoff(job.Attempts+1, retryAfter, s.creator.randFloat64())
+	s.logger.Printf("retrying repository %s in %v: %v", job.Repo, wait.Round(time.Second), err)
+	err = s.queue.Reschedule(job.Repo, s.creator.now().Add(wait), err)
+	if err != nil {
+		s.logger.Printf("while rescheduling the job for repository %s: %v", job.Repo, err)
+	}
+}
+
+// runServeCommand serves an HTTP API that queues full review pull requests,
+// and creates them in the background.
+func runServeCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme serve", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand serves an HTTP API that queues full review pull requests, creating them in the background. Reviews that encounter Github rate limits or transient failures are retried later, and queued reviews are persisted so they survive a restart.
+
+The GH_TOKEN environment variable must be set to a Github personal access token.
+
+Usage: %s [flags]
+
+To queue a review, using the secret set by -api-secret:
+curl -X POST -H "Authorization: Bearer $PRME_API_SECRET" -d '{"repo":"ivanfetch/pr-me"}' http://localhost:8080/reviews
+
+The /healthz endpoint is suitable for liveness probes, and /readyz for readiness probes, which also checks that the Github API is reachable and accepts the token.
+
+When -webhook-secret is set, the /webhook endpoint accepts Github webhook deliveries, and queues a review of each created repository. Deliveries are rejected unless their signature matches the secret, and repeated deliveries are ignored.
+
+Reviews created with -remind-after-days, or the remind_after_days setting of their owner, are checked hourly. Their requested reviewers are reminded with a pull request comment, and via -slack-webhook-url if set, once the pull request has had no activity for that many days.
+
+Available command-line flags:
+`,
+			fs.Name())
+		printFlagDefaults(fs, errOutput)
+		printEnvVarUsage(fs, errOutput)
+	}
+	CLIListen := fs.String("listen", "127.0.0.1:8080", "The address on which to serve HTTP. Only local connections are accepted by default, use :8080 to accept them on every network interface. This is also set via the PRME_LISTEN environment variable.")
+	CLIAPISecret := fs.String("api-secret", "", "The secret that requests to the /reviews endpoint must include as a bearer token, in an Authorization: Bearer header, as reviews are created using the Github token of the server. This is required, and is also set via the PRME_API_SECRET environment variable.")
+	defaultQueueFile, _ := DefaultQueueFile()
+	CLIQueueFile := fs.String("queue-file", defaultQueueFile, "The file in which to persist queued reviews. This is also set via the PRME_QUEUE_FILE environment variable.")
+	CLIWebhookSecret := fs.String("webhook-secret", "", "The secret configured for a Github webhook, which enables the /webhook endpoint to queue a review of each created repository. This is also set via the PRME_WEBHOOK_SECRET environment variable.")
+	CLISlackWebhookURL := fs.String("slack-webhook-url", "", "A Slack incoming webhook URL, to which reminders of inactive reviews are also posted. This is also set via the PRME_SLACK_WEBHOOK_URL environment variable.")
+	applyCreatorFlags, err := addCreatorFlags(fs, output)
+	if err != nil {
+		return err
+	}
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s does not accept repositories as arguments, they are queued via HTTP. Run %s -h for additional help.", fs.Name(), fs.Name())
+	}
+	if *CLIAPISecret == "" {
+		return fmt.Errorf("please set -api-secret, or the PRME_API_SECRET environment variable, to the secret that requests to queue reviews must include. Run %s -h for additional help.", fs.Name())
+	}
+	// The repository is replaced for each queued job.
+	f, err := NewFullPullRequestCreator("queued/*")
+	if err != nil {
+		return err
+	}
+	f.token = os.Getenv("GH_TOKEN")
+	err = applyCreatorFlags(f)
+	if err != nil {
+		return err
+	}
+	// The token can also be set by a host profile.
+	if f.token == "" {
+		return errors.New("Please set the GH_TOKEN environment variable to a Github personal access token. Tokens can be managed at https://github.com/settings/tokens")
+	}
+	queue, err := NewJobQueue(*CLIQueueFile)
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(f.token, f.clientOptions...)
+	if err != nil {
+		return err
+	}
+	f.errOutput = output
+	s := &reviewServer{
+		creator: *f,
+		queue:   queue,
+		logger:  log.New(output, "", log.LstdFlags),
+		wake:    make(chan struct{}, 1),
+		client:  client,
+
+		apiSecret: *CLIAPISecret,
+
+		webhookSecret: *CLIWebhookSecret,
+		deliveries:    newDeliveryCache(),
+
+		slackWebhookURL: *CLISlackWebhookURL,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reviews", s.handleReviews)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	if s.webhookSecret != "" {
+		mux.HandleFunc("/webhook", s.handleWebhook)
+	}
+	server := &http.Server{
+		Addr:    *CLIListen,
+		Handler: mux,
+		// Bound how long clients can hold connections open, as the server
+		// can be exposed to the internet to receive webhooks.
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runJobs(ctx)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runReminders(ctx)
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	s.logger.Printf("serving on %s with %d queued reviews", *CLIListen, len(queue.Jobs()))
+	err = server.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		stop()
+		wg.Wait()
+		return err
+	}
+	wg.Wait()
+	return nil
+}
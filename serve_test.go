@@ -0,0 +1,113 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJobQueuePersistsAcrossRestarts(t *testing.T) {
+	t.Parallel()
+
+	queueFile := filepath.Join(t.TempDir(), "prme", "queue.json")
+	q, err := prme.NewJobQueue(queueFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	for _, repo := range []string{"ivanfetch/one", "ivanfetch/two", "ivanfetch/one"} {
+		_, err = q.Add(repo, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = q.Reschedule("ivanfetch/one", now.Add(time.Hour), errors.New("rate limited"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new queue from the same file, as after a restart.
+	q, err = prme.NewJobQueue(queueFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []prme.Job{
+		{Repo: "ivanfetch/two", NextRun: now},
+		{Repo: "ivanfetch/one", Attempts: 1, NextRun: now.Add(time.Hour), LastError: "rate limited"},
+	}
+	got := q.Jobs()
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect jobs from the queue\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestJobQueueNextOnlyReturnsDueJobs(t *testing.T) {
+	t.Parallel()
+
+	q, err := prme.NewJobQueue(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC)
+	_, ok := q.Next(now)
+	if ok {
+		t.Fatal("want no job from an empty queue")
+	}
+	_, err = q.Add("ivanfetch/one", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.Reschedule("ivanfetch/one", now.Add(time.Minute), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok = q.Next(now)
+	if ok {
+		t.Fatal("want no job before the rescheduled job is due")
+	}
+	job, ok := q.Next(now.Add(time.Minute))
+	if !ok || job.Repo != "ivanfetch/one" {
+		t.Fatalf("want the rescheduled job once it is due, got %+v", job)
+	}
+	err = q.Remove("ivanfetch/one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Jobs()) != 0 {
+		t.Fatalf("want no jobs after removing the only job, got %+v", q.Jobs())
+	}
+}
+
+func TestVerifyAPISecret(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description   string
+		secret        string
+		authorization string
+		wantErr       bool
+	}{
+		{description: "valid bearer token", secret: "mySecret", authorization: "Bearer mySecret"},
+		{description: "incorrect bearer token", secret: "mySecret", authorization: "Bearer otherSecret", wantErr: true},
+		{description: "missing authorization", secret: "mySecret", authorization: "", wantErr: true},
+		{description: "secret without the bearer scheme", secret: "mySecret", authorization: "mySecret", wantErr: true},
+		{description: "no secret configured", secret: "", authorization: "Bearer ", wantErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			err := prme.VerifyAPISecret(tc.secret, tc.authorization)
+			if tc.wantErr && !errors.Is(err, prme.ErrInvalidAPISecret) {
+				t.Fatalf("want %v, got %v", prme.ErrInvalidAPISecret, err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
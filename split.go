@@ -0,0 +1,186 @@
+package prme
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxPullRequestFiles is the most files Github displays in the diff of a
+// pull request. Reviews with more files are split into several pull
+// requests.
+const MaxPullRequestFiles = 3000
+
+// splitUnit is a file or directory that is not divided between parts of a
+// review, and the number of files it contains.
+type splitUnit struct {
+	path  string
+	files int
+}
+
+// SplitPaths divides files, the paths of the files of a tree, into parts of
+// at most maxFiles files, returning the paths of the files and directories
+// of each part. Each directory is kept in one part, unless it has more than
+// maxFiles files.
+func SplitPaths(files []string, maxFiles int) [][]string {
+	dirFiles := make(map[string]int)
+	children := make(map[string]map[string]bool)
+	addChild := func(parent, child string) {
+		if children[parent] == nil {
+			children[parent] = make(map[string]bool)
+		}
+		children[parent][child] = true
+	}
+	for _, file := range files {
+		names := strings.Split(file, "/")
+		parent := ""
+		for i := 1; i < len(names); i++ {
+			dir := strings.Join(names[:i], "/")
+			dirFiles[dir]++
+			addChild(parent, dir)
+			parent = dir
+		}
+		addChild(parent, file)
+	}
+	var units func(dir string) []splitUnit
+	units = func(dir string) []splitUnit {
+		var paths []string
+		for child := range children[dir] {
+			paths = append(paths, child)
+		}
+		sort.Strings(paths)
+		var result []splitUnit
+		for _, p := range paths {
+			n, isDir := dirFiles[p]
+			switch {
+			case !isDir:
+				result = append(result, splitUnit{path: p, files: 1})
+			case n > maxFiles:
+				result = append(result, units(p)...)
+			default:
+				result = append(result, splitUnit{path: p, files: n})
+			}
+		}
+		return result
+	}
+	var parts [][]string
+	var part []string
+	var partFiles int
+	for _, u := range units("") {
+		if len(part) > 0 && partFiles+u.files > maxFiles {
+			parts = append(parts, part)
+			part, partFiles = nil, 0
+		}
+		part = append(part, u.path)
+		partFiles += u.files
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// SelectPaths creates a tree containing only paths, the files and
+// directories of treeish, a tree or commit sha, returning the sha of the new
+// tree.
+func (r Repo) SelectPaths(treeish string, paths []string) (string, error) {
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return "", err
+	}
+	if tree.Truncated {
+		return "", fmt.Errorf("Github truncated the files of tree %q in repository %q, so part of it cannot be selected", treeish, r)
+	}
+	byPath := make(map[string]TreeEntry, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		byPath[entry.Path] = entry
+	}
+	var entries []interface{}
+	for _, p := range paths {
+		entry, ok := byPath[p]
+		if !ok {
+			return "", fmt.Errorf("%w: %q in tree %q of repository %q", ErrPathNotFound, p, treeish, r)
+		}
+		entries = append(entries, TreeEntry{Path: entry.Path, Mode: entry.Mode, Type: entry.Type, Sha: entry.Sha})
+	}
+	// Without a base tree, Github creates the trees of parent directories.
+	return r.changeTree("", entries)
+}
+
+// reviewFiles returns the paths of the files of fullRepoSha that the review
+// contains, within f.Path and excluding files larger than f.MaxFileSize. Nil
+// is returned if Github truncated the list of files.
+func (f FullPullRequestCreator) reviewFiles(r *Repo, fullRepoSha string) ([]string, error) {
+	treeish := fullRepoSha
+	if f.Path != "" {
+		var err error
+		treeish, err = r.SubtreeSha(fullRepoSha, f.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tree, err := r.GetTree(treeish, true)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return nil, nil
+	}
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.Type == "tree" || (f.MaxFileSize > 0 && entry.Size > f.MaxFileSize) {
+			continue
+		}
+		files = append(files, entry.Path)
+	}
+	return files, nil
+}
+
+// ErrSplitNotPlanned is returned by Plan for reviews that Create would split
+// into several pull requests.
+var ErrSplitNotPlanned = errors.New("reviews that are split into several pull requests cannot be planned")
+
+// forSplitPart returns a copy of f that creates part of parts of a review
+// that was split, containing paths, with its own branches and title.
+func (f FullPullRequestCreator) forSplitPart(part, parts int, paths []string) FullPullRequestCreator {
+	f.splitPaths = paths
+	f.Title = fmt.Sprintf("%s (part %d of %d)", f.Title, part, parts)
+	f.Body = fmt.Sprintf("%s\n\nThis is part %d of %d of the review, which was split as the repository has more than the %d files Github displays in a pull request.", f.Body, part, parts, MaxPullRequestFiles)
+	f.BaseBranch = fmt.Sprintf("%s-part-%d", f.BaseBranch, part)
+	f.HeadBranch = fmt.Sprintf("%s-part-%d", f.HeadBranch, part)
+	return f
+}
+
+// createSplit creates a pull request for each part of files, as divided by
+// SplitPaths, returning the pull request of the first part. Parts that were
+// already created are skipped, and if every part was already created, the
+// error of the last part is returned.
+func (f FullPullRequestCreator) createSplit(files []string) (*PullRequest, error) {
+	parts := SplitPaths(files, MaxPullRequestFiles)
+	f.warn("repository %s has %d files, more than the %d Github displays in a pull request, so the review is split into %d pull requests", f.Repo, len(files), MaxPullRequestFiles, len(parts))
+	var first *PullRequest
+	var existsErr error
+	var created bool
+	for i, paths := range parts {
+		PR, err := f.forSplitPart(i+1, len(parts), paths).CreateWithResult()
+		switch {
+		case errors.Is(err, ErrAlreadyCreated) || errors.Is(err, ErrReviewExists):
+			existsErr = err
+		case err != nil:
+			return first, fmt.Errorf("while creating part %d of %d of the review: %w", i+1, len(parts), err)
+		default:
+			created = true
+		}
+		if i == 0 {
+			first = PR
+		}
+		if PR != nil {
+			f.progress("part %d of %d of the review is at %s", i+1, len(parts), PR.HTMLURL)
+		}
+	}
+	if !created {
+		return first, existsErr
+	}
+	return first, nil
+}
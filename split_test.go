@@ -0,0 +1,51 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"testing"
+)
+
+func TestSplitPaths(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"README.md", "go.mod"}
+	for i := 0; i < 3; i++ {
+		files = append(files, fmt.Sprintf("api/file%d.go", i))
+	}
+	for i := 0; i < 2; i++ {
+		files = append(files, fmt.Sprintf("web/file%d.js", i))
+	}
+	for i := 0; i < 3; i++ {
+		files = append(files, fmt.Sprintf("vendor/a/file%d.go", i), fmt.Sprintf("vendor/b/file%d.go", i))
+	}
+	got := prme.SplitPaths(files, 4)
+	want := [][]string{
+		{"README.md", "api"},
+		{"go.mod", "vendor/a"},
+		{"vendor/b"},
+		{"web"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect parts\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestSplitPathsLargeDirectory(t *testing.T) {
+	t.Parallel()
+
+	var files []string
+	for i := 0; i < 5; i++ {
+		files = append(files, fmt.Sprintf("data/file%d.csv", i))
+	}
+	got := prme.SplitPaths(files, 2)
+	want := [][]string{
+		{"data/file0.csv", "data/file1.csv"},
+		{"data/file2.csv", "data/file3.csv"},
+		{"data/file4.csv"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect parts\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
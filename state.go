@@ -0,0 +1,276 @@
+package prme
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReviewRecord is a full review pull request created by prme, as recorded
+// in the state store.
+type ReviewRecord struct {
+	Repo           string    `json:"repo"`
+	Number         int       `json:"number"`
+	URL            string    `json:"url"`
+	State          string    `json:"state"`
+	FullRepoBranch string    `json:"full_repo_branch"`
+	BaseBranch     string    `json:"base_branch"`
+	HeadBranch     string    `json:"head_branch"`
+	CreatedAt      time.Time `json:"created_at"`
+	// IdempotencyKey identifies the repository content and options used to
+	// create the review, as returned by FullPullRequestCreator.IdempotencyKey.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RemindAfterDays is the days without activity after which requested
+	// reviewers are reminded. Zero disables reminders.
+	RemindAfterDays int `json:"remind_after_days,omitempty"`
+	// Filtered is true if the head branch only contains some content of the
+	// full repository branch, or changed content, such as for -path, or a
+	// part of a split review, so merging the full repository branch into
+	// it would add the unfiltered repository.
+	Filtered bool `json:"filtered,omitempty"`
+}
+
+// State is the content of the state store.
+type State struct {
+	Reviews []ReviewRecord `json:"reviews"`
+}
+
+// StateStore persists the State of prme as JSON, using a StateBackend.
+type StateStore struct {
+	backend StateBackend
+	// location describes the backend in errors.
+	location string
+}
+
+// DefaultStateFile returns the default state store file, in the
+// per-user configuration directory.
+func DefaultStateFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "prme", "state.json"), nil
+}
+
+// NewStateStore returns a state store using location, which is a local file
+// path, or a URL of cloud object storage as supported by NewStateBackend.
+// The state is encrypted at rest if the PRME_STATE_KEY environment variable
+// is set to a passphrase, or PRME_STATE_KEYCHAIN to the OS keychain service
+// of a key, as returned by KeychainStateKey.
+func NewStateStore(location string) (*StateStore, error) {
+	backend, err := NewStateBackend(location)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := stateKeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		backend = &EncryptedBackend{Backend: backend, Passphrase: passphrase}
+	}
+	return &StateStore{backend: backend, location: location}, nil
+}
+
+// NewStateStoreWithBackend returns a state store using backend, such as for
+// storage that NewStateBackend does not support.
+func NewStateStoreWithBackend(backend StateBackend) (*StateStore, error) {
+	if backend == nil {
+		return nil, errors.New("the state store backend cannot be nil")
+	}
+	return &StateStore{backend: backend, location: fmt.Sprintf("%T", backend)}, nil
+}
+
+// Load returns the stored state, which is empty if nothing has been stored.
+func (s StateStore) Load() (*State, error) {
+	data, err := s.backend.Read()
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(data)
+}
+
+// decode returns the state encoded in data.
+func (s StateStore) decode(data []byte) (*State, error) {
+	if data == nil {
+		return &State{}, nil
+	}
+	if bytes.HasPrefix(data, encryptedStateMagic) {
+		return nil, fmt.Errorf("%w: %s", ErrStateEncrypted, s.location)
+	}
+	var st State
+	err := json.Unmarshal(data, &st)
+	if err != nil {
+		return nil, fmt.Errorf("while reading state store %s: %w", s.location, err)
+	}
+	return &st, nil
+}
+
+// Save replaces the stored state with st.
+func (s StateStore) Save(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.backend.Write(data)
+}
+
+// maxStateUpdateAttempts is the number of times update retries modifying
+// state that was changed by another writer.
+const maxStateUpdateAttempts = 5
+
+// stateUpdateLocks holds a *sync.Mutex for each state store location, which
+// serializes updates within a process, so concurrent updates, such as by the
+// jobs and reminders of serve, are not lost.
+var stateUpdateLocks sync.Map
+
+// lockUpdates locks updates of the state store location, returning the
+// function that unlocks them.
+func (s StateStore) lockUpdates() (unlock func()) {
+	mu, _ := stateUpdateLocks.LoadOrStore(s.location, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// update loads the state, modifies it using modify, and saves it. When the
+// backend is a ConditionalStateBackend, the state is only saved if it is
+// unchanged since it was loaded, otherwise it is loaded and modified again.
+// Each location is only updated by one goroutine at a time, as backends
+// such as FileBackend, including when encrypted, cannot be written
+// conditionally.
+func (s StateStore) update(modify func(st *State)) error {
+	defer s.lockUpdates()()
+	cb, ok := s.backend.(ConditionalStateBackend)
+	if !ok {
+		st, err := s.Load()
+		if err != nil {
+			return err
+		}
+		modify(st)
+		return s.Save(st)
+	}
+	var err error
+	for attempt := 0; attempt < maxStateUpdateAttempts; attempt++ {
+		var data []byte
+		var version string
+		data, version, err = cb.ReadVersion()
+		if err != nil {
+			return err
+		}
+		var st *State
+		st, err = s.decode(data)
+		if err != nil {
+			return err
+		}
+		modify(st)
+		data, err = json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = cb.WriteIfVersion(data, version)
+		if !errors.Is(err, ErrStateChanged) {
+			return err
+		}
+	}
+	return fmt.Errorf("while updating state store %s after %d attempts: %w", s.location, maxStateUpdateAttempts, err)
+}
+
+// writeJSONFile replaces the file path with v encoded as JSON, creating
+// parent directories as needed.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, data)
+}
+
+// AddReview records a created review in the state store.
+func (s StateStore) AddReview(record ReviewRecord) error {
+	return s.update(func(st *State) {
+		st.Reviews = append(st.Reviews, record)
+	})
+}
+
+// FindReview returns the recorded review with the idempotency key, or nil
+// if none has been recorded.
+func (s StateStore) FindReview(idempotencyKey string) (*ReviewRecord, error) {
+	st, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range st.Reviews {
+		if st.Reviews[i].IdempotencyKey == idempotencyKey {
+			return &st.Reviews[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ErrAlreadyCreated is returned by Create, along with the previously created
+// pull request, when the state store shows a review was already created
+// with the same IdempotencyKey.
+var ErrAlreadyCreated = errors.New("a full pull request was already created for this content and options")
+
+// IdempotencyKey returns a key identifying a review of the repository at
+// the full repository branch commit fullRepoSha, using the options of f
+// that affect the pull request. Runs with the same key would create the
+// same review.
+func (f FullPullRequestCreator) IdempotencyKey(fullRepoSha string) string {
+	symlinks, submodules := f.Symlinks, f.Submodules
+	if symlinks == LinkKeep {
+		symlinks = ""
+	}
+	if submodules == LinkKeep {
+		submodules = ""
+	}
+	keyJSON, _ := json.Marshal(struct {
+		Repo, FullRepoSha, FullRepoBranch, BaseBranch, HeadBranch, Title, Body string
+		Labels, Reviewers                                                      []string
+		Assignees                                                              []string `json:",omitempty"`
+		Milestone                                                              string   `json:",omitempty"`
+		SetCommitStatus                                                        bool
+		// Options filtering content are omitted when empty, so keys of
+		// reviews of the entire repository are unchanged.
+		Path                 string   `json:",omitempty"`
+		MaxFileSize          int64    `json:",omitempty"`
+		Symlinks, Submodules string   `json:",omitempty"`
+		NormalizeText        bool     `json:",omitempty"`
+		TableOfContents      bool     `json:",omitempty"`
+		Draft                bool     `json:",omitempty"`
+		HowTo                bool     `json:",omitempty"`
+		SplitPaths           []string `json:",omitempty"`
+	}{
+		Repo:            strings.ToLower(f.Repo),
+		FullRepoSha:     fullRepoSha,
+		FullRepoBranch:  f.FullRepoBranch,
+		BaseBranch:      f.BaseBranch,
+		HeadBranch:      f.HeadBranch,
+		Title:           f.Title,
+		Body:            f.Body,
+		Labels:          f.Labels,
+		Reviewers:       f.Reviewers,
+		Assignees:       f.Assignees,
+		Milestone:       f.Milestone,
+		SetCommitStatus: f.SetCommitStatus,
+		Path:            f.Path,
+		MaxFileSize:     f.MaxFileSize,
+		Symlinks:        symlinks,
+		Submodules:      submodules,
+		NormalizeText:   f.NormalizeText,
+		TableOfContents: f.TableOfContents,
+		Draft:           f.Draft,
+		HowTo:           f.HowTo,
+		SplitPaths:      f.splitPaths,
+	})
+	sum := sha256.Sum256(keyJSON)
+	return hex.EncodeToString(sum[:])
+}
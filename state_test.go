@@ -0,0 +1,153 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStateStoreAddReview(t *testing.T) {
+	t.Parallel()
+
+	store, err := prme.NewStateStore(filepath.Join(t.TempDir(), "prme", "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Reviews) != 0 {
+		t.Fatalf("want no reviews in a new state store, got %+v", st.Reviews)
+	}
+	want := []prme.ReviewRecord{
+		{
+			Repo:           "ivanfetch/ghapitest",
+			Number:         7,
+			URL:            "https://github.com/ivanfetch/ghapitest/pull/7",
+			State:          "open",
+			FullRepoBranch: "main",
+			BaseBranch:     "prme-full-review",
+			HeadBranch:     "prme-full-content",
+			CreatedAt:      time.Date(2021, 8, 21, 3, 10, 25, 0, time.UTC),
+		},
+	}
+	err = store.AddReview(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, st.Reviews) {
+		t.Fatalf("got incorrect reviews from the state store\ndiff reflects want vs. got: %s", cmp.Diff(want, st.Reviews))
+	}
+}
+
+func TestStateStoreFindReviewByIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	f, err := prme.NewFullPullRequestCreator("ivanfetch/ghapitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := f.IdempotencyKey("9d9e8c5f0bd8c41a2b1b1b0c7b1d1e4a4c7a0b51")
+	if key != f.IdempotencyKey("9d9e8c5f0bd8c41a2b1b1b0c7b1d1e4a4c7a0b51") {
+		t.Fatal("want the same idempotency key for the same commit and options")
+	}
+	if key == f.IdempotencyKey("0a0b0c0d0e0f0a0b0c0d0e0f0a0b0c0d0e0f0a0b") {
+		t.Fatal("want a different idempotency key for a different commit")
+	}
+	changed := *f
+	changed.Title = "Another Review"
+	if key == changed.IdempotencyKey("9d9e8c5f0bd8c41a2b1b1b0c7b1d1e4a4c7a0b51") {
+		t.Fatal("want a different idempotency key for different options")
+	}
+
+	store, err := prme.NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := prme.ReviewRecord{
+		Repo:           "ivanfetch/ghapitest",
+		Number:         7,
+		URL:            "https://github.com/ivanfetch/ghapitest/pull/7",
+		State:          "open",
+		IdempotencyKey: key,
+	}
+	err = store.AddReview(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.FindReview(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || !cmp.Equal(want, *got) {
+		t.Fatalf("got incorrect review for idempotency key %s: %+v", key, got)
+	}
+	got, err = store.FindReview("unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("want no review for an unknown idempotency key, got %+v", got)
+	}
+}
+
+// slowBackend is a FileBackend that is slow to write, so concurrent updates
+// interleave.
+type slowBackend struct {
+	prme.FileBackend
+}
+
+func (sb slowBackend) Write(data []byte) error {
+	time.Sleep(time.Millisecond)
+	return sb.FileBackend.Write(data)
+}
+
+func TestStateStoreKeepsConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	backend := slowBackend{prme.FileBackend{Path: filepath.Join(t.TempDir(), "state.json")}}
+	const updates = 20
+	var wg sync.WaitGroup
+	errs := make([]error, updates)
+	for i := 0; i < updates; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each update uses its own store, as the jobs and reminders of
+			// serve do.
+			store, err := prme.NewStateStoreWithBackend(backend)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = store.AddReview(prme.ReviewRecord{Repo: "ivanfetch/ghapitest", Number: i + 1, State: "open"})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := prme.NewStateStoreWithBackend(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Reviews) != updates {
+		t.Fatalf("want %d reviews recorded concurrently, got %d", updates, len(st.Reviews))
+	}
+}
@@ -0,0 +1,333 @@
+package prme
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StateBackend stores the encoded state of prme, such as in a local file or
+// cloud object storage, so the state can be shared between machines.
+type StateBackend interface {
+	// Read returns the stored state, or nil if nothing has been stored.
+	Read() ([]byte, error)
+	// Write replaces the stored state.
+	Write(data []byte) error
+}
+
+// ConditionalStateBackend is a StateBackend that can replace the stored
+// state only if it is unchanged since it was read, so concurrent updates
+// from different machines are not lost.
+type ConditionalStateBackend interface {
+	StateBackend
+	// ReadVersion returns the stored state, or nil if nothing has been
+	// stored, and a version identifying it.
+	ReadVersion() (data []byte, version string, err error)
+	// WriteIfVersion replaces the stored state if it is still the version
+	// returned by ReadVersion, otherwise returning ErrStateChanged.
+	WriteIfVersion(data []byte, version string) error
+}
+
+// ErrStateChanged is returned by ConditionalStateBackend.WriteIfVersion
+// when the state has been changed since it was read.
+var ErrStateChanged = errors.New("the state store was changed by another writer")
+
+// NewStateBackend returns the StateBackend for location, which is a local
+// file path, or an s3://bucket/key or gs://bucket/key URL.
+//
+// Amazon S3 credentials are read from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and optional AWS_SESSION_TOKEN environment
+// variables, and the region from AWS_REGION, which defaults to us-east-1.
+// Other S3-compatible storage can be used by setting AWS_ENDPOINT_URL.
+//
+// Google Cloud Storage is accessed using its S3-compatible API, with HMAC
+// keys read from the PRME_GCS_ACCESS_KEY_ID and PRME_GCS_SECRET_ACCESS_KEY
+// environment variables.
+func NewStateBackend(location string) (StateBackend, error) {
+	if location == "" {
+		return nil, errors.New("the state store location cannot be empty")
+	}
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := os.Getenv("AWS_ENDPOINT_URL")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return newObjectBackend(location, endpoint, region, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+	case strings.HasPrefix(location, "gs://"):
+		ob, err := newObjectBackend(location, "https://storage.googleapis.com", "auto", os.Getenv("PRME_GCS_ACCESS_KEY_ID"), os.Getenv("PRME_GCS_SECRET_ACCESS_KEY"), "")
+		if err != nil {
+			return nil, err
+		}
+		ob.Generations = true
+		return ob, nil
+	case strings.Contains(location, "://"):
+		return nil, fmt.Errorf("unsupported state store location %q, use a file path, or an s3:// or gs:// URL", location)
+	}
+	return FileBackend{Path: location}, nil
+}
+
+// FileBackend stores state in a local file.
+type FileBackend struct {
+	Path string
+}
+
+func (fb FileBackend) Read() ([]byte, error) {
+	data, err := os.ReadFile(fb.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (fb FileBackend) Write(data []byte) error {
+	return writeFileAtomically(fb.Path, data)
+}
+
+// writeFileAtomically replaces the file path with data, creating parent
+// directories as needed.
+func writeFileAtomically(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+	// Write a uniquely named temporary file then rename it, so an
+	// interrupted write does not corrupt the file, and concurrent writers
+	// do not write to the same temporary file.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// ObjectBackend stores state as an object in Amazon S3, or storage with an
+// S3-compatible API such as Google Cloud Storage.
+type ObjectBackend struct {
+	// Endpoint is the URL of the storage API, such as
+	// https://s3.us-east-1.amazonaws.com. Objects are addressed as
+	// Endpoint/Bucket/Key.
+	Endpoint, Region, Bucket, Key string
+	AccessKeyID, SecretAccessKey  string
+	// SessionToken is only required for temporary credentials.
+	SessionToken string
+	HTTPClient   *http.Client
+	// Generations makes conditional writes use Google Cloud Storage object
+	// generations, instead of ETags.
+	Generations bool
+}
+
+// newObjectBackend returns an ObjectBackend for a location of the form
+// scheme://bucket/key.
+func newObjectBackend(location, endpoint, region, accessKeyID, secretAccessKey, sessionToken string) (*ObjectBackend, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("the state store location %q must be of the form %s://bucket/key", location, u.Scheme)
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("credentials are required for the state store %q", location)
+	}
+	return &ObjectBackend{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Region:          region,
+		Bucket:          u.Host,
+		Key:             key,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
+		HTTPClient:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (ob ObjectBackend) Read() ([]byte, error) {
+	data, _, err := ob.ReadVersion()
+	return data, err
+}
+
+// ReadVersion returns the object and its version, which is its ETag, or
+// its generation if Generations is true. The version is empty if there is
+// no object.
+func (ob ObjectBackend) ReadVersion() ([]byte, string, error) {
+	resp, err := ob.do(http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d while reading state from %s/%s", resp.StatusCode, ob.Bucket, ob.Key)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	version := resp.Header.Get("ETag")
+	if ob.Generations {
+		version = resp.Header.Get("X-Goog-Generation")
+	}
+	return data, version, nil
+}
+
+func (ob ObjectBackend) Write(data []byte) error {
+	return ob.write(data, nil)
+}
+
+// WriteIfVersion replaces the object if it is still the version returned
+// by ReadVersion, or creates it if the version is empty and there is no
+// object.
+func (ob ObjectBackend) WriteIfVersion(data []byte, version string) error {
+	header := make(http.Header)
+	switch {
+	case ob.Generations && version == "":
+		header.Set("X-Goog-If-Generation-Match", "0")
+	case ob.Generations:
+		header.Set("X-Goog-If-Generation-Match", version)
+	case version == "":
+		header.Set("If-None-Match", "*")
+	default:
+		header.Set("If-Match", version)
+	}
+	return ob.write(data, header)
+}
+
+func (ob ObjectBackend) write(data []byte, header http.Header) error {
+	resp, err := ob.do(http.MethodPut, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 returns a conflict when a concurrent conditional write is in
+	// progress.
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s/%s", ErrStateChanged, ob.Bucket, ob.Key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d while writing state to %s/%s", resp.StatusCode, ob.Bucket, ob.Key)
+	}
+	return nil
+}
+
+// do makes a signed request for the object, with the additional header.
+func (ob ObjectBackend) do(method string, body []byte, header http.Header) (*http.Response, error) {
+	objectURL := fmt.Sprintf("%s/%s/%s", ob.Endpoint, ob.Bucket, escapeObjectKey(ob.Key))
+	req, err := http.NewRequest(method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if ob.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", ob.SessionToken)
+	}
+	signAWSV4(req, body, ob.AccessKeyID, ob.SecretAccessKey, ob.Region, "s3", time.Now())
+	hc := ob.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return hc.Do(req)
+}
+
+// escapeObjectKey escapes each segment of an object key for use in a URL
+// path, as required by AWS signatures.
+func escapeObjectKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsURIEscape escapes s as required by AWS signatures, leaving only
+// unreserved characters.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// signAWSV4 signs req, which has the payload body, using AWS Signature
+// Version 4. The host and all headers already set on req are signed.
+func signAWSV4(req *http.Request, body []byte, accessKeyID, secretAccessKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+	hmacSHA256 := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	signingKey := hmacSHA256(hmacSHA256(hmacSHA256(hmacSHA256([]byte("AWS4"+secretAccessKey), date), region), service), "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyID, scope, signedHeaders, signature))
+}
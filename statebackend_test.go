@@ -0,0 +1,219 @@
+package prme_test
+
+import (
+	"fmt"
+	"github.com/ivanfetch/prme"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newFakeObjectStorage returns a test server storing objects in memory,
+// which requires requests to be signed, and supports conditional writes
+// using ETags.
+func newFakeObjectStorage(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	etags := make(map[string]string)
+	var writes int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=testkey/") {
+			t.Errorf("got unsigned request %s %s, Authorization %q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", etags[r.URL.Path])
+			w.Write(data)
+		case http.MethodPut:
+			etag, exists := etags[r.URL.Path]
+			ifMatch := r.Header.Get("If-Match")
+			if ifMatch != "" && ifMatch != etag || r.Header.Get("If-None-Match") == "*" && exists {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			objects[r.URL.Path] = data
+			writes++
+			etags[r.URL.Path] = fmt.Sprintf(`"%d"`, writes)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestObjectBackendStateStore(t *testing.T) {
+	ts := newFakeObjectStorage(t)
+	t.Setenv("AWS_ENDPOINT_URL", ts.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "testkey")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "testsecret")
+
+	store, err := prme.NewStateStore("s3://prme-state/ci/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Reviews) != 0 {
+		t.Fatalf("want no reviews in a new state store, got %+v", st.Reviews)
+	}
+	want := []prme.ReviewRecord{
+		{
+			Repo:   "ivanfetch/ghapitest",
+			Number: 7,
+			State:  "open",
+		},
+	}
+	err = store.AddReview(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A separate store represents another machine sharing the state.
+	otherStore, err := prme.NewStateStore("s3://prme-state/ci/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err = otherStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, st.Reviews) {
+		t.Fatalf("got incorrect reviews from the state store\ndiff reflects want vs. got: %s", cmp.Diff(want, st.Reviews))
+	}
+}
+
+func TestNewStateBackend(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "testkey")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "testsecret")
+	t.Setenv("PRME_GCS_ACCESS_KEY_ID", "")
+	t.Setenv("PRME_GCS_SECRET_ACCESS_KEY", "")
+
+	testCases := []struct {
+		description string
+		location    string
+		wantError   bool
+	}{
+		{
+			description: "local file",
+			location:    "/tmp/prme/state.json",
+		},
+		{
+			description: "S3 object",
+			location:    "s3://prme-state/state.json",
+		},
+		{
+			description: "S3 bucket without an object key",
+			location:    "s3://prme-state",
+			wantError:   true,
+		},
+		{
+			description: "GCS object without credentials",
+			location:    "gs://prme-state/state.json",
+			wantError:   true,
+		},
+		{
+			description: "unsupported URL scheme",
+			location:    "ftp://example.com/state.json",
+			wantError:   true,
+		},
+		{
+			description: "empty location",
+			wantError:   true,
+		},
+	}
+	for _, tc := range testCases {
+		_, err := prme.NewStateBackend(tc.location)
+		if tc.wantError && err == nil {
+			t.Errorf("%s: want an error for location %q, got none", tc.description, tc.location)
+		}
+		if !tc.wantError && err != nil {
+			t.Errorf("%s: %v", tc.description, err)
+		}
+	}
+}
+
+// interleavingBackend runs beforeWrite before its first conditional write,
+// such as to simulate another machine updating the state meanwhile.
+type interleavingBackend struct {
+	prme.ConditionalStateBackend
+	beforeWrite func()
+}
+
+func (ib *interleavingBackend) WriteIfVersion(data []byte, version string) error {
+	if ib.beforeWrite != nil {
+		ib.beforeWrite()
+		ib.beforeWrite = nil
+	}
+	return ib.ConditionalStateBackend.WriteIfVersion(data, version)
+}
+
+func TestObjectBackendStateStoreKeepsConcurrentUpdates(t *testing.T) {
+	ts := newFakeObjectStorage(t)
+	t.Setenv("AWS_ENDPOINT_URL", ts.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "testkey")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "testsecret")
+
+	otherStore, err := prme.NewStateStore("s3://prme-state/ci/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := prme.NewStateBackend("s3://prme-state/ci/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []prme.ReviewRecord{
+		{
+			Repo:   "ivanfetch/ghapitest2",
+			Number: 3,
+			State:  "open",
+		},
+		{
+			Repo:   "ivanfetch/ghapitest",
+			Number: 7,
+			State:  "open",
+		},
+	}
+	store, err := prme.NewStateStoreWithBackend(&interleavingBackend{
+		ConditionalStateBackend: backend.(prme.ConditionalStateBackend),
+		beforeWrite: func() {
+			err := otherStore.AddReview(want[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AddReview(want[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := otherStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, st.Reviews) {
+		t.Fatalf("got incorrect reviews from the state store\ndiff reflects want vs. got: %s", cmp.Diff(want, st.Reviews))
+	}
+}
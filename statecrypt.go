@@ -0,0 +1,296 @@
+package prme
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// encryptedStateMagic prefixes state encrypted by EncryptedBackend, followed
+// by the salt used to derive the key, the nonce, and the ciphertext.
+var encryptedStateMagic = []byte("PRME-ENC1")
+
+const (
+	stateSaltSize = 16
+	// stateKeyIterations is the PBKDF2 iteration count used to derive an
+	// encryption key from a passphrase.
+	stateKeyIterations = 600000
+)
+
+// ErrStateEncrypted is returned when loading an encrypted state store
+// without a key.
+var ErrStateEncrypted = errors.New("the state store is encrypted, please set the PRME_STATE_KEY or PRME_STATE_KEYCHAIN environment variable")
+
+// EncryptedBackend encrypts state at rest using AES-256-GCM, with a key
+// derived from Passphrase, before storing it in Backend. Unencrypted state
+// is still read, so existing state stores are encrypted by their next
+// write.
+type EncryptedBackend struct {
+	Backend    StateBackend
+	Passphrase string
+}
+
+// derivedStateKey is a key derived from a passphrase and salt.
+type derivedStateKey struct {
+	salt, key []byte
+}
+
+// derivedStateKeys are the most recently derived key of each passphrase,
+// keyed by the SHA-256 of the passphrase. Deriving a key is intentionally
+// slow, and an EncryptedBackend is created each time a state store is
+// opened, so keys are reused while the salt is unchanged.
+var derivedStateKeys = struct {
+	sync.Mutex
+	keys map[[sha256.Size]byte]derivedStateKey
+}{keys: make(map[[sha256.Size]byte]derivedStateKey)}
+
+// keyForSalt returns the key derived from the passphrase and salt. A nil
+// salt returns the most recently derived key, or a key for a new random
+// salt.
+func (eb *EncryptedBackend) keyForSalt(salt []byte) (key, keySalt []byte, err error) {
+	derivedStateKeys.Lock()
+	defer derivedStateKeys.Unlock()
+	id := sha256.Sum256([]byte(eb.Passphrase))
+	derived, ok := derivedStateKeys.keys[id]
+	if ok && (salt == nil || bytes.Equal(salt, derived.salt)) {
+		return derived.key, derived.salt, nil
+	}
+	if salt == nil {
+		salt = make([]byte, stateSaltSize)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	derived = derivedStateKey{salt: salt, key: pbkdf2SHA256([]byte(eb.Passphrase), salt, stateKeyIterations)}
+	derivedStateKeys.keys[id] = derived
+	return derived.key, derived.salt, nil
+}
+
+func (eb *EncryptedBackend) Read() ([]byte, error) {
+	data, err := eb.Backend.Read()
+	if err != nil || data == nil {
+		return data, err
+	}
+	return eb.decrypt(data)
+}
+
+// ReadVersion returns the decrypted state and its version, if Backend is a
+// ConditionalStateBackend.
+func (eb *EncryptedBackend) ReadVersion() ([]byte, string, error) {
+	cb, ok := eb.Backend.(ConditionalStateBackend)
+	if !ok {
+		data, err := eb.Read()
+		return data, "", err
+	}
+	data, version, err := cb.ReadVersion()
+	if err != nil || data == nil {
+		return data, version, err
+	}
+	data, err = eb.decrypt(data)
+	return data, version, err
+}
+
+// decrypt returns the plaintext of state written by EncryptedBackend, or
+// data as is if it is not encrypted.
+func (eb *EncryptedBackend) decrypt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedStateMagic) {
+		return data, nil
+	}
+	data = data[len(encryptedStateMagic):]
+	if len(data) < stateSaltSize {
+		return nil, errors.New("the encrypted state is truncated")
+	}
+	key, _, err := eb.keyForSalt(data[:stateSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[stateSaltSize:]
+	gcm, err := newStateGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("the encrypted state is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedStateMagic)
+	if err != nil {
+		return nil, errors.New("unable to decrypt the state store, the key is incorrect or the state has been modified")
+	}
+	return plaintext, nil
+}
+
+func (eb *EncryptedBackend) Write(data []byte) error {
+	encrypted, err := eb.encrypt(data)
+	if err != nil {
+		return err
+	}
+	return eb.Backend.Write(encrypted)
+}
+
+// WriteIfVersion encrypts and conditionally writes data if Backend is a
+// ConditionalStateBackend, otherwise data is written unconditionally.
+func (eb *EncryptedBackend) WriteIfVersion(data []byte, version string) error {
+	encrypted, err := eb.encrypt(data)
+	if err != nil {
+		return err
+	}
+	cb, ok := eb.Backend.(ConditionalStateBackend)
+	if !ok {
+		return eb.Backend.Write(encrypted)
+	}
+	return cb.WriteIfVersion(encrypted, version)
+}
+
+// encrypt returns data encrypted with a key derived from the passphrase.
+func (eb *EncryptedBackend) encrypt(data []byte) ([]byte, error) {
+	key, salt, err := eb.keyForSalt(nil)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newStateGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := append([]byte{}, encryptedStateMagic...)
+	encrypted = append(encrypted, salt...)
+	encrypted = append(encrypted, nonce...)
+	return gcm.Seal(encrypted, nonce, data, encryptedStateMagic), nil
+}
+
+func newStateGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a 32 byte key from password and salt using PBKDF2
+// with HMAC-SHA256. Only one block is computed, as the key is the size of
+// the hash.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blockIndex := make([]byte, 4)
+	binary.BigEndian.PutUint32(blockIndex, 1)
+	prf.Write(salt)
+	prf.Write(blockIndex)
+	u := prf.Sum(nil)
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// stateKeyFromEnv returns the state store passphrase from the PRME_STATE_KEY
+// environment variable, or from the OS keychain entry for the service named
+// by PRME_STATE_KEYCHAIN. An empty passphrase is returned when neither is
+// set.
+func stateKeyFromEnv() (string, error) {
+	if key := os.Getenv("PRME_STATE_KEY"); key != "" {
+		return key, nil
+	}
+	if service := os.Getenv("PRME_STATE_KEYCHAIN"); service != "" {
+		return KeychainStateKey(service)
+	}
+	return "", nil
+}
+
+// keychainAccount is the account of prme entries in the OS keychain.
+const keychainAccount = "prme"
+
+// keychainNotFoundExitCode is the exit code of the macOS security command
+// when there is no matching keychain item.
+const keychainNotFoundExitCode = 44
+
+// KeychainStateKey returns the state store key stored in the OS keychain for
+// service, generating and storing a random key if there is none. The macOS
+// keychain is accessed using the security command, and the Linux secret
+// service, such as GNOME Keyring, using secret-tool. Errors looking up the
+// key, other than there being no key, are returned rather than replacing an
+// existing key that could not be read.
+func KeychainStateKey(service string) (string, error) {
+	var lookup, store *exec.Cmd
+	newKey := make([]byte, 32)
+	_, err := rand.Read(newKey)
+	if err != nil {
+		return "", err
+	}
+	newKeyHex := hex.EncodeToString(newKey)
+	switch runtime.GOOS {
+	case "darwin":
+		if strings.ContainsAny(service, "\"\\\n") {
+			return "", fmt.Errorf("the OS keychain service %q cannot contain quotes, backslashes, or newlines", service)
+		}
+		lookup = exec.Command("security", "find-generic-password", "-s", service, "-a", keychainAccount, "-w")
+		// The key is written to the interactive mode of the security
+		// command, so that it is not visible in the arguments of the
+		// process.
+		store = exec.Command("security", "-i")
+		store.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -s \"%s\" -a %s -w %s\n", service, keychainAccount, newKeyHex))
+	case "linux":
+		lookup = exec.Command("secret-tool", "lookup", "service", service, "account", keychainAccount)
+		store = exec.Command("secret-tool", "store", "--label", "prme state store key", "service", service, "account", keychainAccount)
+		store.Stdin = strings.NewReader(newKeyHex)
+	default:
+		return "", fmt.Errorf("the OS keychain is not supported on %s, please set the PRME_STATE_KEY environment variable instead", runtime.GOOS)
+	}
+	_, err = exec.LookPath(lookup.Args[0])
+	if err != nil {
+		return "", fmt.Errorf("%s is required to use the OS keychain: %w", lookup.Args[0], err)
+	}
+	var stderr bytes.Buffer
+	lookup.Stderr = &stderr
+	output, err := lookup.Output()
+	key := strings.TrimSpace(string(output))
+	if err == nil && key != "" {
+		return key, nil
+	}
+	if err != nil && !keychainKeyNotFound(err, stderr.String()) {
+		return "", fmt.Errorf("while looking up the state store key in the OS keychain: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	storeOutput, err := store.CombinedOutput()
+	if err != nil {
+		// The output may include the new key.
+		return "", fmt.Errorf("while storing a new state store key in the OS keychain: %v: %s", err, Redact(string(storeOutput), newKeyHex))
+	}
+	return newKeyHex, nil
+}
+
+// keychainKeyNotFound returns true if err, from a keychain lookup command,
+// means that there is no key. The macOS security command exits with a
+// specific code, and secret-tool exits with 1 and no output, unlike when
+// the secret service is unavailable or locked.
+func keychainKeyNotFound(err error, stderr string) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	if runtime.GOOS == "darwin" {
+		return exitErr.ExitCode() == keychainNotFoundExitCode
+	}
+	return exitErr.ExitCode() == 1 && strings.TrimSpace(stderr) == ""
+}
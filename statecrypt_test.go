@@ -0,0 +1,131 @@
+package prme_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ivanfetch/prme"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncryptedStateStore(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	plainStore, err := prme.NewStateStoreWithBackend(prme.FileBackend{Path: stateFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []prme.ReviewRecord{
+		{
+			Repo:   "ivanfetch/ghapitest",
+			Number: 7,
+			State:  "open",
+		},
+	}
+	// State stored before encryption was enabled is encrypted by the next
+	// write.
+	err = plainStore.AddReview(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PRME_STATE_KEY", "correct horse battery staple")
+	store, err := prme.NewStateStore(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, prme.ReviewRecord{
+		Repo:   "ivanfetch/ghapitest2",
+		Number: 3,
+		State:  "open",
+	})
+	err = store.AddReview(want[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ghapitest")) {
+		t.Fatalf("want the state file encrypted, got %s", data)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, st.Reviews) {
+		t.Fatalf("got incorrect reviews from the state store\ndiff reflects want vs. got: %s", cmp.Diff(want, st.Reviews))
+	}
+
+	_, err = plainStore.Load()
+	if !errors.Is(err, prme.ErrStateEncrypted) {
+		t.Fatalf("want %v loading the encrypted state without a key, got %v", prme.ErrStateEncrypted, err)
+	}
+	wrongKeyStore, err := prme.NewStateStoreWithBackend(&prme.EncryptedBackend{
+		Backend:    prme.FileBackend{Path: stateFile},
+		Passphrase: "incorrect",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wrongKeyStore.Load()
+	if err == nil {
+		t.Fatal("want an error loading the encrypted state with an incorrect key, got none")
+	}
+}
+
+func TestKeychainStateKeyDoesNotReplaceAnUnreadableKey(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the fake secret-tool is a shell script for Linux")
+	}
+	dir := t.TempDir()
+	storeLog := filepath.Join(dir, "store.log")
+	// The fake secret-tool fails to look up the key because the secret
+	// service is locked, and records any attempt to store a new key.
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = lookup ]; then echo 'Cannot get secret of a locked object' >&2; exit 1; fi\n" +
+		"cat > " + storeLog + "\n"
+	err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	_, err = prme.KeychainStateKey("prme-test")
+	if err == nil {
+		t.Fatal("want an error when the key cannot be looked up, got none")
+	}
+	_, err = os.Stat(storeLog)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("want no new key stored when the key cannot be looked up, got %v", err)
+	}
+}
+
+func TestKeychainStateKeyStoresANewKeyWhenNotFound(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the fake secret-tool is a shell script for Linux")
+	}
+	dir := t.TempDir()
+	storeLog := filepath.Join(dir, "store.log")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = lookup ]; then exit 1; fi\n" +
+		"cat > " + storeLog + "\n"
+	err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	key, err := prme.KeychainStateKey("prme-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := os.ReadFile(storeLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == "" || string(stored) != key {
+		t.Fatalf("want the new key %q stored in the keychain, got %q", key, stored)
+	}
+}
@@ -0,0 +1,144 @@
+package prme
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ReviewStats summarizes full reviews, for tracking an audit program.
+type ReviewStats struct {
+	Reviews   int
+	Completed int
+	// CompletedPerMonth maps a month, as 2006-01, to the number of reviews
+	// that were merged or closed that month.
+	CompletedPerMonth map[string]int
+	// AverageTimeToMerge only includes merged reviews.
+	AverageTimeToMerge time.Duration
+	// AverageFiles and AverageComments are per review, where comments
+	// include review comments on lines of files.
+	AverageFiles    float64
+	AverageComments float64
+}
+
+// NewReviewStats returns the statistics of reviews, which are pull requests
+// as returned by GetPullRequest.
+func NewReviewStats(reviews []PullRequest) ReviewStats {
+	stats := ReviewStats{
+		Reviews:           len(reviews),
+		CompletedPerMonth: make(map[string]int),
+	}
+	if len(reviews) == 0 {
+		return stats
+	}
+	var merged int
+	var timeToMerge time.Duration
+	var files, comments int
+	for _, PR := range reviews {
+		files += PR.ChangedFiles
+		comments += PR.Comments + PR.ReviewComments
+		if PR.ClosedAt.IsZero() {
+			continue
+		}
+		stats.Completed++
+		stats.CompletedPerMonth[PR.ClosedAt.Format("2006-01")]++
+		if !PR.MergedAt.IsZero() {
+			merged++
+			timeToMerge += PR.MergedAt.Sub(PR.CreatedAt)
+		}
+	}
+	if merged > 0 {
+		stats.AverageTimeToMerge = timeToMerge / time.Duration(merged)
+	}
+	stats.AverageFiles = float64(files) / float64(len(reviews))
+	stats.AverageComments = float64(comments) / float64(len(reviews))
+	return stats
+}
+
+// Write displays the statistics to w.
+func (s ReviewStats) Write(w io.Writer) {
+	fmt.Fprintf(w, "Reviews:\t%d, %d completed\n", s.Reviews, s.Completed)
+	fmt.Fprintf(w, "Average time to merge:\t%s\n", formatDays(s.AverageTimeToMerge))
+	fmt.Fprintf(w, "Average files per review:\t%.1f\n", s.AverageFiles)
+	fmt.Fprintf(w, "Average comments per review:\t%.1f\n", s.AverageComments)
+	if len(s.CompletedPerMonth) == 0 {
+		return
+	}
+	months := make([]string, 0, len(s.CompletedPerMonth))
+	for month := range s.CompletedPerMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	fmt.Fprintln(w, "Completed per month:")
+	for _, month := range months {
+		fmt.Fprintf(w, "  %s\t%d\n", month, s.CompletedPerMonth[month])
+	}
+}
+
+// formatDays formats d in days and hours, which suits the duration of
+// reviews better than time.Duration.String.
+func formatDays(d time.Duration) string {
+	if d == 0 {
+		return "n/a"
+	}
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)).Round(time.Hour) / time.Hour
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
+
+// runStatsCommand displays statistics of the full reviews recorded in the
+// state store.
+func runStatsCommand(args []string, output, errOutput io.Writer) error {
+	fs := flag.NewFlagSet("prme stats", flag.ExitOnError)
+	fs.SetOutput(errOutput)
+	fs.Usage = func() {
+		fmt.Fprintf(errOutput, `This subcommand displays statistics of the full reviews recorded in the state store, such as reviews completed per month, and average time to merge.
+
+Usage: %s [flags]
+
+Available command-line flags:
+`,
+			fs.Name())
+		fs.PrintDefaults()
+	}
+	defaultStateFile, _ := DefaultStateFile()
+	CLIStateFile := fs.String("state-file", defaultStateFile, "The file, or s3://bucket/key or gs://bucket/key URL, in which prme records created pull requests. This is also set via the PRME_STATE_FILE environment variable.")
+	CLIClient := addClientFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.VisitAll(flagOrEnvValue)
+	store, err := NewStateStore(*CLIStateFile)
+	if err != nil {
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if len(st.Reviews) == 0 {
+		fmt.Fprintf(output, "No pull requests are recorded in the state store %s\n", *CLIStateFile)
+		return nil
+	}
+	token, clientOptions, err := CLIClient.tokenAndOptions()
+	if err != nil {
+		return err
+	}
+	reviews := make([]PullRequest, 0, len(st.Reviews))
+	for _, review := range st.Reviews {
+		r, err := NewRepo(review.Repo, token, clientOptions...)
+		if err != nil {
+			return err
+		}
+		PR, err := r.GetPullRequest(review.Number)
+		if err != nil {
+			return err
+		}
+		reviews = append(reviews, *PR)
+	}
+	NewReviewStats(reviews).Write(output)
+	return nil
+}
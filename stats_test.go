@@ -0,0 +1,33 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewReviewStats(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	reviews := []prme.PullRequest{
+		{CreatedAt: created, ClosedAt: created.AddDate(0, 0, 2), MergedAt: created.AddDate(0, 0, 2), ChangedFiles: 10, Comments: 3, ReviewComments: 5},
+		{CreatedAt: created, ClosedAt: created.AddDate(0, 1, 0), MergedAt: created.AddDate(0, 0, 4), ChangedFiles: 20, Comments: 1},
+		{CreatedAt: created, ClosedAt: created.AddDate(0, 1, 1), ChangedFiles: 30},
+		{CreatedAt: created, ChangedFiles: 40, ReviewComments: 3},
+	}
+	want := prme.ReviewStats{
+		Reviews:            4,
+		Completed:          3,
+		CompletedPerMonth:  map[string]int{"2021-08": 1, "2021-09": 2},
+		AverageTimeToMerge: 3 * 24 * time.Hour,
+		AverageFiles:       25,
+		AverageComments:    3,
+	}
+	got := prme.NewReviewStats(reviews)
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect statistics\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,178 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrPathNotFound is returned when the path to review is not a directory of
+// the full repository branch.
+var ErrPathNotFound = errors.New("the path is not a directory of the full repository branch")
+
+// cleanReviewPath returns p, a directory relative to the root of the
+// repository, in canonical form.
+func cleanReviewPath(p string) (string, error) {
+	cleaned := path.Clean(strings.TrimSuffix(p, "/"))
+	if p == "" || cleaned == "." {
+		return "", errors.New("the path cannot be empty, omit it to review the entire repository")
+	}
+	if strings.HasPrefix(cleaned, "/") || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("the path %q must be relative to the root of the repository", p)
+	}
+	return cleaned, nil
+}
+
+// WithPath limits the review to the directory p of the repository, such as
+// one service of a monorepo. The head branch only contains that directory,
+// at the same path.
+func WithPath(p string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		cleaned, err := cleanReviewPath(p)
+		if err != nil {
+			return err
+		}
+		f.Path = cleaned
+		return nil
+	}
+}
+
+// SubtreeSha returns the sha of the tree of the directory p, within the tree
+// of commitSha. ErrPathNotFound is returned if p does not exist or is not a
+// directory.
+func (r Repo) SubtreeSha(commitSha, p string) (string, error) {
+	treeSha := commitSha
+	for _, name := range strings.Split(p, "/") {
+		tree, err := r.GetTree(treeSha, false)
+		if err != nil {
+			return "", err
+		}
+		treeSha = ""
+		for _, entry := range tree.Entries {
+			if entry.Path == name && entry.Type == "tree" {
+				treeSha = entry.Sha
+				break
+			}
+		}
+		if treeSha == "" {
+			return "", fmt.Errorf("%w: %q at commit %s of repository %q", ErrPathNotFound, p, commitSha, r)
+		}
+	}
+	return treeSha, nil
+}
+
+// CommitPath adds a commit to branch containing only the directory p of
+// fullRepoSha, returning the sha of the new commit. The commit has branch,
+// then fullRepoSha, as its parents, so the review includes the history of the
+// full repository branch like a merge, while the pull request only shows the
+// directory.
+func (r Repo) CommitPath(branch, fullRepoSha, p, message string) (sha string, err error) {
+	subtreeSha, err := r.SubtreeSha(fullRepoSha, p)
+	if err != nil {
+		return "", err
+	}
+	treeSha, err := r.nestTree(p, subtreeSha)
+	if err != nil {
+		return "", err
+	}
+	return r.commitReviewTree(branch, fullRepoSha, treeSha, message)
+}
+
+// nestTree creates a tree containing only the tree subtreeSha at the
+// directory p, returning the sha of the new tree.
+func (r Repo) nestTree(p, subtreeSha string) (string, error) {
+	// Github creates the trees of the parent directories of p.
+	treeJSON, err := json.Marshal(struct {
+		Tree []TreeEntry `json:"tree"`
+	}{
+		Tree: []TreeEntry{{Path: p, Mode: "040000", Type: "tree", Sha: subtreeSha}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return r.postTree(treeJSON)
+}
+
+// commitReviewTree adds a commit of treeSha to branch, with branch then
+// fullRepoSha as its parents, returning the sha of the new commit.
+func (r Repo) commitReviewTree(branch, fullRepoSha, treeSha, message string) (sha string, err error) {
+	branchSha, err := r.GetRef("heads/" + branch)
+	if err != nil {
+		return "", err
+	}
+	sha, err = r.createCommit(message, treeSha, []string{branchSha, fullRepoSha})
+	if err != nil {
+		return "", err
+	}
+	err = r.UpdateRef("heads/"+branch, sha, false)
+	if err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// filtersContent returns true if only some content of the full repository
+// branch is added to the head branch, or it is changed, so the branch cannot
+// simply be merged.
+func (f FullPullRequestCreator) filtersContent() bool {
+	symlinks, submodules := f.linkHandling()
+	return f.Path != "" || len(f.splitPaths) > 0 || f.MaxFileSize > 0 || symlinks != LinkKeep || submodules != LinkKeep || f.NormalizeText
+}
+
+// commitFilteredContent adds a commit to the head branch containing the
+// content of fullRepoSha limited to f.Path and f.splitPaths, with symlinks and submodules
+// handled as set by f.Symlinks and f.Submodules, without files larger than
+// f.MaxFileSize, and with text normalized if f.NormalizeText is set,
+// returning the sha of the new commit.
+func (f FullPullRequestCreator) commitFilteredContent(r *Repo, fullRepoSha string) (string, error) {
+	treeSha := fullRepoSha
+	if f.Path != "" {
+		var err error
+		treeSha, err = r.SubtreeSha(fullRepoSha, f.Path)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(f.splitPaths) > 0 {
+		var err error
+		treeSha, err = r.SelectPaths(treeSha, f.splitPaths)
+		if err != nil {
+			return "", err
+		}
+	}
+	symlinks, submodules := f.linkHandling()
+	if symlinks != LinkKeep || submodules != LinkKeep {
+		var err error
+		treeSha, err = r.HandleLinks(treeSha, symlinks, submodules)
+		if err != nil {
+			return "", err
+		}
+	}
+	// Large files are omitted after materializing symlinks, which may
+	// target them.
+	if f.MaxFileSize > 0 {
+		var err error
+		treeSha, _, err = r.OmitLargeFiles(treeSha, f.MaxFileSize)
+		if err != nil {
+			return "", err
+		}
+	}
+	// Text is normalized last, so omitted files are not downloaded.
+	if f.NormalizeText {
+		var err error
+		treeSha, _, err = r.NormalizeText(treeSha)
+		if err != nil {
+			return "", err
+		}
+	}
+	if f.Path != "" {
+		var err error
+		treeSha, err = r.nestTree(f.Path, treeSha)
+		if err != nil {
+			return "", err
+		}
+	}
+	return r.commitReviewTree(f.HeadBranch, fullRepoSha, treeSha, f.mergeCommitMessage())
+}
@@ -0,0 +1,117 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestCommitPath(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", `{"sha": "roottree", "tree": [
+		{"path": "README.md", "type": "blob", "sha": "readme"},
+		{"path": "services", "type": "tree", "sha": "servicestree"}
+	]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/servicestree", `{"sha": "servicestree", "tree": [
+		{"path": "api", "type": "tree", "sha": "apitree"},
+		{"path": "web", "type": "tree", "sha": "webtree"}
+	]}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/ref/heads/prme-full-content", `{"ref": "refs/heads/prme-full-content", "object": {"sha": "emptytreecommit"}}`)
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/git/trees", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha": "filteredtree"}`})
+	fc.SetResponse(http.MethodPost, "/repos/owner/repo/git/commits", prme.FakeResponse{StatusCode: http.StatusCreated, Body: `{"sha": "pathcommit"}`})
+	fc.SetJSONResponse(http.MethodPatch, "/repos/owner/repo/git/refs/heads/prme-full-content", `{}`)
+
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha, err := r.CommitPath("prme-full-content", "fullsha", "services/api", "Add services/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sha != "pathcommit" {
+		t.Errorf("want commit %q, got %q", "pathcommit", sha)
+	}
+	var gotTree, gotCommit map[string]interface{}
+	for _, req := range fc.Requests() {
+		switch {
+		case req.Method == http.MethodPost && req.URI == "/repos/owner/repo/git/trees":
+			err = json.Unmarshal(req.Body, &gotTree)
+		case req.Method == http.MethodPost && req.URI == "/repos/owner/repo/git/commits":
+			err = json.Unmarshal(req.Body, &gotCommit)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantTree := map[string]interface{}{
+		"tree": []interface{}{map[string]interface{}{"path": "services/api", "mode": "040000", "type": "tree", "sha": "apitree"}},
+	}
+	if !cmp.Equal(wantTree, gotTree) {
+		t.Errorf("got incorrect tree request\ndiff reflects want vs. got: %s", cmp.Diff(wantTree, gotTree))
+	}
+	wantParents := []interface{}{"emptytreecommit", "fullsha"}
+	if !cmp.Equal(wantParents, gotCommit["parents"]) {
+		t.Errorf("got incorrect commit parents\ndiff reflects want vs. got: %s", cmp.Diff(wantParents, gotCommit["parents"]))
+	}
+}
+
+func TestSubtreeShaNotFound(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/owner/repo/git/trees/fullsha", `{"sha": "roottree", "tree": [
+		{"path": "services", "type": "blob", "sha": "servicesfile"}
+	]}`)
+	r, err := prme.NewRepo("owner/repo", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.SubtreeSha("fullsha", "services/api")
+	if !errors.Is(err, prme.ErrPathNotFound) {
+		t.Fatalf("want ErrPathNotFound, got %v", err)
+	}
+}
+
+func TestWithPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path, want string
+		wantErr    bool
+	}{
+		{path: "services/api", want: "services/api"},
+		{path: "services/api/", want: "services/api"},
+		{path: "./services//api", want: "services/api"},
+		{path: "", wantErr: true},
+		{path: ".", wantErr: true},
+		{path: "/services", wantErr: true},
+		{path: "../services", wantErr: true},
+	}
+	for _, tc := range testCases {
+		f, err := prme.NewFullPullRequestCreator("owner/repo", prme.WithToken("dummyToken"), prme.WithPath(tc.path))
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("want an error for path %q", tc.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Path != tc.want {
+			t.Errorf("want path %q for %q, got %q", tc.want, tc.path, f.Path)
+		}
+	}
+}
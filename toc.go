@@ -0,0 +1,128 @@
+package prme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithTableOfContents adds a table of contents of the files in the review to
+// the pull request body, grouped by directory in collapsible blocks, linking
+// to each file in the diff.
+func WithTableOfContents() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.TableOfContents = true
+		return nil
+	}
+}
+
+// fileDiffURL returns the URL of the diff of file in the pull request
+// PRURL, which Github anchors by the SHA-256 of the file path.
+func fileDiffURL(PRURL, file string) string {
+	sum := sha256.Sum256([]byte(file))
+	return fmt.Sprintf("%s/files#diff-%s", PRURL, hex.EncodeToString(sum[:]))
+}
+
+// TableOfContents returns a markdown table of contents of files, the paths
+// in pull request PRURL, with a collapsible block for each directory linking
+// to the diff of each of its files. If that would be longer than maxLength
+// characters, only directories are listed, linking to their first file, and
+// directories that do not fit are counted instead.
+func TableOfContents(PRURL string, files []string, maxLength int) string {
+	byDir := make(map[string][]string)
+	var dirs []string
+	for _, file := range files {
+		dir := path.Dir(file)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], file)
+	}
+	sort.Strings(dirs)
+	dirName := func(dir string) string {
+		if dir == "." {
+			return "(repository root)"
+		}
+		return dir + "/"
+	}
+	heading := fmt.Sprintf("## Files\n\n%d files in %d directories.\n\n", len(files), len(dirs))
+	var b strings.Builder
+	b.WriteString(heading)
+	for _, dir := range dirs {
+		sort.Strings(byDir[dir])
+		fmt.Fprintf(&b, "<details><summary>%s (%d files)</summary>\n\n", dirName(dir), len(byDir[dir]))
+		for _, file := range byDir[dir] {
+			fmt.Fprintf(&b, "* [`%s`](%s)\n", path.Base(file), fileDiffURL(PRURL, file))
+		}
+		b.WriteString("\n</details>\n")
+	}
+	if utf8.RuneCountInString(b.String()) <= maxLength {
+		return b.String()
+	}
+	b.Reset()
+	b.WriteString(heading)
+	b.WriteString("<details><summary>Directories</summary>\n\n")
+	const footer = "\n</details>\n"
+	for i, dir := range dirs {
+		line := fmt.Sprintf("* [`%s`](%s) (%d files)\n", dirName(dir), fileDiffURL(PRURL, byDir[dir][0]), len(byDir[dir]))
+		remaining := fmt.Sprintf("* ...and %d more directories\n", len(dirs)-i)
+		// Leave room to count the directories that follow.
+		if utf8.RuneCountInString(b.String())+utf8.RuneCountInString(line)+len(remaining)+len(footer) > maxLength {
+			b.WriteString(remaining)
+			break
+		}
+		b.WriteString(line)
+	}
+	b.WriteString(footer)
+	return b.String()
+}
+
+// UpdatePullRequestBody replaces the body of the pull request number.
+func (r Repo) UpdatePullRequestBody(number int, body string) error {
+	apiURI := fmt.Sprintf("/repos/%s/pulls/%d", r, number)
+	bodyJSON, err := json.Marshal(struct {
+		Body string `json:"body"`
+	}{
+		Body: body,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPatch, apiURI, bodyJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while updating the body of pull request %d in repository %q", resp.StatusCode, apiURI, number, r)
+	}
+	return nil
+}
+
+// addTableOfContents adds a TableOfContents of the files of the head branch
+// to the body of PR, which is only possible once the pull request exists as
+// the links include its URL.
+func (f FullPullRequestCreator) addTableOfContents(r *Repo, PR *PullRequest) error {
+	tree, err := r.GetTree(f.HeadBranch, true)
+	if err != nil {
+		return err
+	}
+	if tree.Truncated {
+		return fmt.Errorf("Github truncated the files of branch %q in repository %q, so a table of contents cannot be created", f.HeadBranch, r)
+	}
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.Type != "tree" {
+			files = append(files, entry.Path)
+		}
+	}
+	const separator = "\n\n"
+	toc := TableOfContents(PR.HTMLURL, files, MaxBodyLength-utf8.RuneCountInString(f.Body)-len(separator))
+	return r.UpdatePullRequestBody(PR.Number, f.Body+separator+toc)
+}
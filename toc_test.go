@@ -0,0 +1,59 @@
+package prme_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// sha256Hex returns the hex encoded SHA-256 of s, which Github uses to
+// anchor files in pull request diffs.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTableOfContents(t *testing.T) {
+	t.Parallel()
+
+	got := prme.TableOfContents("https://github.com/owner/repo/pull/1", []string{"README.md", "cmd/prme/main.go", "cmd/prme/flags.go"}, prme.MaxBodyLength)
+	want := "## Files\n\n3 files in 2 directories.\n\n" +
+		"<details><summary>(repository root) (1 files)</summary>\n\n" +
+		"* [`README.md`](https://github.com/owner/repo/pull/1/files#diff-%s)\n" +
+		"\n</details>\n" +
+		"<details><summary>cmd/prme/ (2 files)</summary>\n\n" +
+		"* [`flags.go`](https://github.com/owner/repo/pull/1/files#diff-%s)\n" +
+		"* [`main.go`](https://github.com/owner/repo/pull/1/files#diff-%s)\n" +
+		"\n</details>\n"
+	want = fmt.Sprintf(want, sha256Hex("README.md"), sha256Hex("cmd/prme/flags.go"), sha256Hex("cmd/prme/main.go"))
+	if !cmp.Equal(want, got) {
+		t.Errorf("got incorrect table of contents\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestTableOfContentsTooLong(t *testing.T) {
+	t.Parallel()
+
+	var files []string
+	for dir := 0; dir < 500; dir++ {
+		for file := 0; file < 10; file++ {
+			files = append(files, fmt.Sprintf("service%03d/file%d.go", dir, file))
+		}
+	}
+	const maxLength = 20000
+	got := prme.TableOfContents("https://github.com/owner/repo/pull/1", files, maxLength)
+	if n := utf8.RuneCountInString(got); n > maxLength {
+		t.Fatalf("want at most %d characters, got %d", maxLength, n)
+	}
+	if !strings.Contains(got, "* [`service000/`](https://github.com/owner/repo/pull/1/files#diff-"+sha256Hex("service000/file0.go")+") (10 files)\n") {
+		t.Errorf("want the first directory to link to its first file, got:\n%s", got)
+	}
+	if !strings.Contains(got, "more directories\n") {
+		t.Errorf("want the remaining directories to be counted, got:\n%s", got)
+	}
+}
@@ -0,0 +1,149 @@
+package prme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// topicPattern matches Github repository topics, which are lowercase
+// alphanumeric characters or hyphens, beginning with an alphanumeric.
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// validateTopic returns an error if topic is not a valid Github repository
+// topic.
+func validateTopic(topic string) error {
+	if !topicPattern.MatchString(topic) {
+		return fmt.Errorf("invalid topic %q, topics are up to 50 lowercase letters, numbers, or hyphens, beginning with a letter or number", topic)
+	}
+	return nil
+}
+
+// topicsRequest is the request and response body of the topics of a
+// repository.
+type topicsRequest struct {
+	Names []string `json:"names"`
+}
+
+// GetTopics returns the topics of the repository.
+func (r Repo) GetTopics() ([]string, error) {
+	apiURI := fmt.Sprintf("/repos/%s/topics", r)
+	resp, err := r.Client.MakeAPIRequest(http.MethodGet, apiURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s while getting topics of repository %q", resp.StatusCode, apiURI, r)
+	}
+	var topicsAPIResp topicsRequest
+	err = json.NewDecoder(resp.Body).Decode(&topicsAPIResp)
+	if err != nil {
+		return nil, err
+	}
+	return topicsAPIResp.Names, nil
+}
+
+// ReplaceTopics replaces all topics of the repository with names.
+func (r Repo) ReplaceTopics(names []string) error {
+	apiURI := fmt.Sprintf("/repos/%s/topics", r)
+	if names == nil {
+		// Github requires an empty list to remove all topics.
+		names = []string{}
+	}
+	topicsJSON, err := json.Marshal(topicsRequest{Names: names})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.MakeAPIRequestWithData(http.MethodPut, apiURI, topicsJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d for %s while replacing topics of repository %q", resp.StatusCode, apiURI, r)
+	}
+	return nil
+}
+
+// WithTopic only creates pull requests for repositories having topic, such
+// as needs-audit.
+func WithTopic(topic string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		err := validateTopic(topic)
+		if err != nil {
+			return err
+		}
+		f.Topic = topic
+		return nil
+	}
+}
+
+// WithRemoveTopic removes the topic set by WithTopic from the repository,
+// once the pull request is created, to mark progress.
+func WithRemoveTopic() FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		f.RemoveTopic = true
+		return nil
+	}
+}
+
+// WithAddTopic adds topic to the repository, such as audit-in-progress,
+// once the pull request is created, to mark progress.
+func WithAddTopic(topic string) FullPullRequestCreatorOption {
+	return func(f *FullPullRequestCreator) error {
+		err := validateTopic(topic)
+		if err != nil {
+			return err
+		}
+		f.AddTopic = topic
+		return nil
+	}
+}
+
+// checkTopic returns ErrRepoExcluded if a topic is required, and the
+// repository does not have it.
+func (f FullPullRequestCreator) checkTopic(r *Repo) error {
+	if f.Topic == "" {
+		return nil
+	}
+	topics, err := r.GetTopics()
+	if err != nil {
+		return err
+	}
+	if !stringsContain(topics, f.Topic) {
+		return fmt.Errorf("%w: %s does not have the topic %q", ErrRepoExcluded, r, f.Topic)
+	}
+	return nil
+}
+
+// updatedTopics returns topics after removing the required topic and adding
+// the topic to add, as requested by RemoveTopic and AddTopic.
+func (f FullPullRequestCreator) updatedTopics(topics []string) []string {
+	updated := []string{}
+	for _, topic := range topics {
+		if f.RemoveTopic && topic == f.Topic {
+			continue
+		}
+		updated = append(updated, topic)
+	}
+	if f.AddTopic != "" && !stringsContain(updated, f.AddTopic) {
+		updated = append(updated, f.AddTopic)
+	}
+	return updated
+}
+
+// updateTopics removes and adds topics of the repository, as requested by
+// RemoveTopic and AddTopic, to mark that a review was created.
+func (f FullPullRequestCreator) updateTopics(r *Repo) error {
+	if f.RemoveTopic && f.Topic == "" {
+		return errors.New("the topic to remove is not set")
+	}
+	topics, err := r.GetTopics()
+	if err != nil {
+		return err
+	}
+	return r.ReplaceTopics(f.updatedTopics(topics))
+}
@@ -0,0 +1,95 @@
+package prme_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ivanfetch/prme"
+	"net/http"
+	"testing"
+)
+
+func TestOwnerReposWithTopic(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/orgs/myorg/repos", `[
+		{"full_name": "myorg/api", "topics": ["go", "needs-audit"]},
+		{"full_name": "myorg/web", "topics": ["javascript"]},
+		{"full_name": "myorg/old", "archived": true, "topics": ["needs-audit"]}
+	]`)
+	f, err := prme.NewFullPullRequestCreator("myorg/*", prme.WithToken("dummyToken"), prme.WithTopic("needs-audit"), prme.WithAPIMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.OwnerRepos("myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"myorg/api"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("got incorrect repositories\ndiff reflects want vs. got: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestPlanWithoutTopicIsExcluded(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodGet, "/repos/myorg/web", `{"full_name": "myorg/web"}`)
+	fc.SetJSONResponse(http.MethodGet, "/repos/myorg/web/topics", `{"names": ["javascript"]}`)
+	f, err := prme.NewFullPullRequestCreator("myorg/web", prme.WithToken("dummyToken"), prme.WithTopic("needs-audit"), prme.WithAPIMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SkipOrgConfig = true
+	_, err = f.Plan()
+	if !errors.Is(err, prme.ErrRepoExcluded) {
+		t.Fatalf("want ErrRepoExcluded for a repository without the topic, got %v", err)
+	}
+}
+
+func TestReplaceTopics(t *testing.T) {
+	t.Parallel()
+
+	fc, err := prme.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.SetJSONResponse(http.MethodPut, "/repos/myorg/api/topics", `{"names": []}`)
+	r, err := prme.NewRepo("myorg/api", "dummyToken", prme.WithMiddleware(fc.Middleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.ReplaceTopics(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := fc.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("want 1 request, got %d", len(requests))
+	}
+	var got struct{ Names []string }
+	err = json.Unmarshal(requests[0].Body, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Names == nil || len(got.Names) != 0 {
+		t.Fatalf("want an empty list of topics to remove all topics, got %s", requests[0].Body)
+	}
+}
+
+func TestWithTopicInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := prme.NewFullPullRequestCreator("myorg/api", prme.WithTopic("Needs Audit"))
+	if err == nil {
+		t.Fatal("want an error for an invalid topic")
+	}
+}
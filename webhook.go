@@ -0,0 +1,160 @@
+package prme
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxWebhookBodySize is the largest webhook delivery that is read.
+	// Github caps webhook payloads at 25MB.
+	maxWebhookBodySize = 25 << 20
+	// webhookDeliveryTTL is how long webhook delivery IDs are remembered,
+	// to ignore repeated deliveries.
+	webhookDeliveryTTL = 24 * time.Hour
+	// maxWebhookDeliveries is the most webhook delivery IDs remembered.
+	maxWebhookDeliveries = 10000
+)
+
+// ErrInvalidWebhookSignature is returned by VerifyWebhookSignature when the
+// signature does not match the webhook payload.
+var ErrInvalidWebhookSignature = errors.New("the webhook signature is missing or invalid")
+
+// VerifyWebhookSignature verifies that signature, the value of the
+// X-Hub-Signature-256 header of a Github webhook delivery, is the
+// HMAC-SHA256 of body using secret.
+func VerifyWebhookSignature(secret string, body []byte, signature string) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidWebhookSignature
+	}
+	gotMAC, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(gotMAC, mac.Sum(nil)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// deliveryCache remembers recent webhook delivery IDs, so that replayed or
+// redelivered webhooks are only processed once.
+type deliveryCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newDeliveryCache() *deliveryCache {
+	return &deliveryCache{seen: make(map[string]time.Time)}
+}
+
+// seenBefore returns whether the delivery ID was recorded within
+// webhookDeliveryTTL of now.
+func (d *deliveryCache) seenBefore(ID string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seenAt, ok := d.seen[ID]
+	return ok && now.Sub(seenAt) < webhookDeliveryTTL
+}
+
+// record records the delivery ID at now, once it has been processed, so
+// Github can redeliver it if processing failed.
+func (d *deliveryCache) record(ID string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.seen) >= maxWebhookDeliveries {
+		for seenID, seenAt := range d.seen {
+			if now.Sub(seenAt) >= webhookDeliveryTTL {
+				delete(d.seen, seenID)
+			}
+		}
+	}
+	if len(d.seen) >= maxWebhookDeliveries {
+		// Forget the oldest delivery, to bound memory use.
+		var oldestID string
+		var oldestAt time.Time
+		for seenID, seenAt := range d.seen {
+			if oldestID == "" || seenAt.Before(oldestAt) {
+				oldestID, oldestAt = seenID, seenAt
+			}
+		}
+		delete(d.seen, oldestID)
+	}
+	d.seen[ID] = now
+}
+
+// webhookEvent is the part of a Github webhook payload used by prme.
+type webhookEvent struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// handleWebhook queues a review of each repository created, as reported by
+// Github webhook deliveries with a valid signature.
+func (s *reviewServer) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeJSONMessage(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		writeJSONMessage(w, http.StatusBadRequest, "unable to read the webhook payload")
+		return
+	}
+	err = VerifyWebhookSignature(s.webhookSecret, body, r.Header.Get("X-Hub-Signature-256"))
+	if err != nil {
+		s.logger.Printf("rejected a webhook delivery from %s: %v", r.RemoteAddr, err)
+		writeJSONMessage(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	deliveryID := r.Header.Get("X-GitHub-Delivery")
+	if deliveryID == "" {
+		writeJSONMessage(w, http.StatusBadRequest, "the X-GitHub-Delivery header is missing")
+		return
+	}
+	if s.deliveries.seenBefore(deliveryID, s.creator.now()) {
+		s.logger.Printf("ignored repeated webhook delivery %s", deliveryID)
+		writeJSONMessage(w, http.StatusOK, fmt.Sprintf("delivery %s was already processed", deliveryID))
+		return
+	}
+	var event webhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		writeJSONMessage(w, http.StatusBadRequest, "the webhook payload is not valid JSON")
+		return
+	}
+	eventType := r.Header.Get("X-GitHub-Event")
+	if eventType != "repository" || event.Action != "created" || event.Repository.FullName == "" {
+		s.deliveries.record(deliveryID, s.creator.now())
+		writeJSONMessage(w, http.StatusOK, fmt.Sprintf("ignored %s event", eventType))
+		return
+	}
+	if !s.creator.repoAllowed(event.Repository.FullName) {
+		s.deliveries.record(deliveryID, s.creator.now())
+		writeJSONMessage(w, http.StatusOK, fmt.Sprintf("%v: %s", ErrRepoExcluded, event.Repository.FullName))
+		return
+	}
+	job, err := s.enqueue(event.Repository.FullName)
+	if err != nil {
+		writeJSONMessage(w, http.StatusInternalServerError, "unable to queue the review")
+		return
+	}
+	s.deliveries.record(deliveryID, s.creator.now())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
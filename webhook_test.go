@@ -0,0 +1,40 @@
+package prme_test
+
+import (
+	"errors"
+	"github.com/ivanfetch/prme"
+	"testing"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"action":"created","repository":{"full_name":"ivanfetch/ghapitest"}}`)
+	testCases := []struct {
+		description string
+		signature   string
+		wantErr     bool
+	}{
+		{
+			description: "valid signature",
+			// The HMAC-SHA256 of body using the secret "It's a Secret to Everybody".
+			signature: "sha256=7f4155458b7e5dd039556bf1ee53d9ecc1c85d21bc8fe6b56e107bbb45a5b6c4",
+		},
+		{description: "incorrect signature", signature: "sha256=0000", wantErr: true},
+		{description: "missing signature", signature: "", wantErr: true},
+		{description: "SHA1 signature", signature: "sha1=0ea8e1f5e1a0ad0cc21ff1db5a3a8d5c8bb0fd6b", wantErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			err := prme.VerifyWebhookSignature("It's a Secret to Everybody", body, tc.signature)
+			if tc.wantErr && !errors.Is(err, prme.ErrInvalidWebhookSignature) {
+				t.Fatalf("want %v, got %v", prme.ErrInvalidWebhookSignature, err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
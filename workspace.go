@@ -0,0 +1,54 @@
+package prme
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WorkspaceBranchPlaceholder is replaced by the full repository branch in
+// the base and head branch names, when creating a review of each of several
+// branches of a repository.
+const WorkspaceBranchPlaceholder = "{branch}"
+
+// ForFullRepoBranch returns a copy of f that reviews branch, such as
+// release/2.x, so separate reviews can be created for several branches of a
+// repository. WorkspaceBranchPlaceholder in the base and head branch names is
+// replaced by branch, otherwise branch is appended to them with slashes
+// replaced by hyphens, keeping the reviews of each branch distinct. Unlike
+// the full repository branch of f, branch is not replaced by another branch
+// if it does not exist.
+func (f FullPullRequestCreator) ForFullRepoBranch(branch string) FullPullRequestCreator {
+	f.FullRepoBranch = branch
+	f.workspaceBranch = branch
+	f.BaseBranch = workspaceBranchName(f.BaseBranch, branch)
+	f.HeadBranch = workspaceBranchName(f.HeadBranch, branch)
+	f.BranchPicker = func(repo string, branches []string, defaultBranch string) (string, error) {
+		return "", fmt.Errorf("full repository branch %q does not exist in repository %q", branch, repo)
+	}
+	return f
+}
+
+// workspaceBranchName returns the base or head branch name for reviewing
+// fullRepoBranch, as described by ForFullRepoBranch.
+func workspaceBranchName(name, fullRepoBranch string) string {
+	if strings.Contains(name, WorkspaceBranchPlaceholder) {
+		return strings.ReplaceAll(name, WorkspaceBranchPlaceholder, fullRepoBranch)
+	}
+	return name + "-" + strings.ReplaceAll(fullRepoBranch, "/", "-")
+}
+
+// CreateWorkspace creates a full pull request for each of branches of each
+// of repos, as described by ForFullRepoBranch and CreateBatch. Results are
+// ordered by branch, then repository.
+func (f FullPullRequestCreator) CreateWorkspace(repos, branches []string, output io.Writer) []BatchResult {
+	f = f.shareHTTPClient()
+	var results []BatchResult
+	for _, branch := range branches {
+		for _, result := range f.ForFullRepoBranch(branch).CreateBatch(repos, output) {
+			result.FullRepoBranch = branch
+			results = append(results, result)
+		}
+	}
+	return results
+}
@@ -0,0 +1,56 @@
+package prme_test
+
+import (
+	"github.com/ivanfetch/prme"
+	"testing"
+)
+
+func TestForFullRepoBranch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description                    string
+		baseBranch, headBranch, branch string
+		wantBase, wantHead             string
+	}{
+		{
+			description: "branch is appended with slashes replaced",
+			baseBranch:  "prme-full-review",
+			headBranch:  "prme-full-content",
+			branch:      "release/2.x",
+			wantBase:    "prme-full-review-release-2.x",
+			wantHead:    "prme-full-content-release-2.x",
+		},
+		{
+			description: "branch replaces the placeholder",
+			baseBranch:  "review/{branch}",
+			headBranch:  "content/{branch}",
+			branch:      "release/2.x",
+			wantBase:    "review/release/2.x",
+			wantHead:    "content/release/2.x",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			f, err := prme.NewFullPullRequestCreator("owner/repo", prme.WithToken("dummyToken"), prme.WithBaseBranchName(tc.baseBranch), prme.WithHeadBranchName(tc.headBranch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := f.ForFullRepoBranch(tc.branch)
+			if got.FullRepoBranch != tc.branch {
+				t.Errorf("want full repository branch %q, got %q", tc.branch, got.FullRepoBranch)
+			}
+			if got.BaseBranch != tc.wantBase {
+				t.Errorf("want base branch %q, got %q", tc.wantBase, got.BaseBranch)
+			}
+			if got.HeadBranch != tc.wantHead {
+				t.Errorf("want head branch %q, got %q", tc.wantHead, got.HeadBranch)
+			}
+			if f.BaseBranch != tc.baseBranch {
+				t.Errorf("the original creator was modified, its base branch is %q", f.BaseBranch)
+			}
+		})
+	}
+}